@@ -0,0 +1,143 @@
+// Package app wires one instance of the expense tracker together: its databases,
+// repositories, services, handlers, router and background jobs
+package app
+
+import (
+	"database/sql"
+	"errors"
+	"fenmo-ai-assignment/config"
+	"fenmo-ai-assignment/database"
+	"fenmo-ai-assignment/handler"
+	"fenmo-ai-assignment/repository"
+	"fenmo-ai-assignment/routes"
+	"fenmo-ai-assignment/service"
+	"fmt"
+	"log"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrPostgresUnsupported is returned by New for the postgres driver. Only expenses and
+// their tags can be stored in PostgreSQL so far; categories, budgets, envelopes,
+// recurring templates, FX rates and idempotency keys would stay in each instance's
+// SQLite database, so instances sharing one Postgres database would disagree on them.
+var ErrPostgresUnsupported = errors.New("DB_DRIVER=postgres is not supported yet: only expenses and tags can be stored in PostgreSQL, and the other data would stay in each instance's SQLite database")
+
+// App owns everything an instance needs. Instances share no state, so several can run
+// in one process, each over its own databases.
+type App struct {
+	Config *config.Config
+
+	// DB is the SQLite database holding everything but, with the memory driver, the
+	// expenses and their tags
+	DB *sql.DB
+	// Expenses is the expense store selected by Config.DBDriver
+	Expenses repository.ExpenseStore
+
+	Router *gin.Engine
+
+	purger    *service.TrashPurger
+	scheduler *service.RecurringScheduler
+	started   bool
+}
+
+// New opens the databases of cfg and builds the application over them. Background
+// jobs are not running until Start, and Close releases the databases.
+func New(cfg *config.Config) (*App, error) {
+	if cfg.DBDriver == config.DriverPostgres {
+		return nil, ErrPostgresUnsupported
+	}
+
+	// In demo mode everything besides expenses lives in an in-memory database, which
+	// always starts empty and so is migrated regardless
+	dbPath, autoMigrate := cfg.DBPath, cfg.AutoMigrate
+	if cfg.DBDriver == config.DriverMemory {
+		dbPath, autoMigrate = database.MemoryPath(), true
+		log.Println("Demo mode: data is kept in memory and lost on exit")
+	}
+	db, err := database.Init(dbPath, autoMigrate)
+	if err != nil {
+		return nil, fmt.Errorf("initialize database: %w", err)
+	}
+
+	a := &App{Config: cfg, DB: db}
+	a.openExpenseStore()
+
+	// Create repositories
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
+	fxRateRepo := repository.NewFXRateRepository(db)
+	budgetRepo := repository.NewBudgetRepository(db)
+	envelopeRepo := repository.NewEnvelopeRepository(db)
+	recurringRepo := repository.NewRecurringRepository(db)
+	categoryRepo := repository.NewCategoryRepository(db, a.externalExpenses())
+
+	// Create services
+	budgetService := service.NewBudgetService(budgetRepo, a.Expenses, cfg.DefaultCurrency)
+	categoryService := service.NewCategoryService(categoryRepo, cfg.AutoCreateCategories)
+	tagService := service.NewTagService(a.Expenses)
+	expenseService := service.NewExpenseService(a.Expenses, categoryService, budgetService, cfg.DefaultCurrency)
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo, cfg.IdempotencyKeyTTL)
+	fxService := service.NewFXService(fxRateRepo)
+	summaryService := service.NewSummaryService(a.Expenses, categoryRepo, fxService, cfg.WeekStart)
+	envelopeService := service.NewEnvelopeService(envelopeRepo, a.Expenses, cfg.DefaultCurrency)
+	recurringService := service.NewRecurringService(recurringRepo, expenseService)
+
+	// Create background jobs
+	a.purger = service.NewTrashPurger(a.Expenses, cfg.TrashRetention, cfg.TrashPurgeInterval)
+	a.scheduler = service.NewRecurringScheduler(recurringService, cfg.RecurringInterval)
+
+	// Create handlers and routes
+	a.Router = routes.SetupRoutes(routes.Handlers{
+		Expense:   handler.NewExpenseHandler(expenseService, idempotencyService, fxService),
+		FX:        handler.NewFXHandler(fxService),
+		Summary:   handler.NewSummaryHandler(summaryService),
+		Budget:    handler.NewBudgetHandler(budgetService),
+		Envelope:  handler.NewEnvelopeHandler(envelopeService),
+		Recurring: handler.NewRecurringHandler(recurringService),
+		Category:  handler.NewCategoryHandler(categoryService),
+		Tag:       handler.NewTagHandler(tagService),
+	})
+
+	return a, nil
+}
+
+// openExpenseStore creates the expense storage backend selected by the configuration
+func (a *App) openExpenseStore() {
+	categories := repository.NewCategoryRepository(a.DB, nil)
+
+	switch a.Config.DBDriver {
+	case config.DriverMemory:
+		a.Expenses = repository.NewMemoryExpenseStore(categories)
+	default:
+		a.Expenses = repository.NewExpenseRepository(a.DB)
+	}
+}
+
+// externalExpenses returns the expense store when it lives outside DB, for the
+// category repository to rewrite expenses there, or nil
+func (a *App) externalExpenses() repository.ExpenseStore {
+	if a.Config.DBDriver == config.DriverSQLite {
+		return nil
+	}
+	return a.Expenses
+}
+
+// Start starts the background jobs: purging expired trash, and generating recurring
+// expenses, catching up on any missed while stopped
+func (a *App) Start() {
+	a.purger.Start()
+	a.scheduler.Start()
+	a.started = true
+}
+
+// Close stops the background jobs, waiting for a run in progress to finish, and
+// closes the databases
+func (a *App) Close() error {
+	if a.started {
+		a.scheduler.Stop()
+		a.purger.Stop()
+		a.started = false
+	}
+
+	return a.DB.Close()
+}
@@ -0,0 +1,296 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fenmo-ai-assignment/config"
+	"fenmo-ai-assignment/models"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestApp builds an application over a private database, closed when the test ends
+func newTestApp(t *testing.T, driver string) *App {
+	t.Helper()
+	a, err := New(testConfig(t, driver))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { a.Close() })
+	return a
+}
+
+// testConfig configures an application over a private database
+func testConfig(t *testing.T, driver string) *config.Config {
+	return &config.Config{
+		DBPath:               filepath.Join(t.TempDir(), "expenses.db"),
+		DBDriver:             driver,
+		AutoMigrate:          true,
+		IdempotencyKeyTTL:    time.Hour,
+		DefaultCurrency:      "INR",
+		AutoCreateCategories: true,
+		WeekStart:            time.Monday,
+	}
+}
+
+func TestApp_RefusesPostgres(t *testing.T) {
+	cfg := testConfig(t, config.DriverPostgres)
+	cfg.DBDSN = "postgres://localhost/fenmo"
+	if a, err := New(cfg); !errors.Is(err, ErrPostgresUnsupported) {
+		if a != nil {
+			a.Close()
+		}
+		t.Errorf("New() with the postgres driver error = %v, want ErrPostgresUnsupported", err)
+	}
+}
+
+func TestApp_InstancesAreIsolated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, driver := range []string{config.DriverSQLite, config.DriverMemory} {
+		driver := driver
+		t.Run(driver, func(t *testing.T) {
+			t.Parallel()
+			first, second := newTestApp(t, driver), newTestApp(t, driver)
+
+			body := `{"amount":"12.50","category":"Food","description":"Lunch","date":"2024-01-15"}`
+			req := httptest.NewRequest(http.MethodPost, "/api/expenses", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			first.Router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusCreated {
+				t.Fatalf("POST /api/expenses = %d %s", rec.Code, rec.Body)
+			}
+
+			if got := listExpenses(t, first); len(got) != 1 || got[0].Description != "Lunch" {
+				t.Errorf("first instance lists %+v, want the created expense", got)
+			}
+			if got := listExpenses(t, second); len(got) != 0 {
+				t.Errorf("second instance lists %+v, want none", got)
+			}
+		})
+	}
+}
+
+func TestApp_CategoriesAndTagsFollowTheExpenseStore(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, driver := range []string{config.DriverSQLite, config.DriverMemory} {
+		driver := driver
+		t.Run(driver, func(t *testing.T) {
+			t.Parallel()
+			a := newTestApp(t, driver)
+
+			body := `{"amount":"12.50","category":"Food","description":"Lunch","date":"2024-01-15","tags":["work"]}`
+			if rec := serveRequest(a, http.MethodPost, "/api/expenses", body); rec.Code != http.StatusCreated {
+				t.Fatalf("POST /api/expenses = %d %s", rec.Code, rec.Body)
+			}
+
+			var tags []models.Tag
+			rec := serveRequest(a, http.MethodGet, "/api/tags", "")
+			if err := json.Unmarshal(rec.Body.Bytes(), &tags); err != nil || len(tags) != 1 || tags[0].Name != "work" || tags[0].ExpenseCount != 1 {
+				t.Errorf("GET /api/tags = %d %s, want work on one expense", rec.Code, rec.Body)
+			}
+
+			foodID := categoryID(t, a, "Food")
+
+			if rec := serveRequest(a, http.MethodDelete, "/api/categories/"+foodID, ""); rec.Code != http.StatusConflict {
+				t.Errorf("DELETE of a category in use = %d %s, want 409", rec.Code, rec.Body)
+			}
+
+			var change models.CategoryChange
+			rec = serveRequest(a, http.MethodPost, "/api/categories/"+foodID+"/rename", `{"name":"Meals"}`)
+			if err := json.Unmarshal(rec.Body.Bytes(), &change); err != nil || rec.Code != http.StatusOK || change.Audit.RowsChanged.Expenses != 1 {
+				t.Errorf("rename = %d %s, want one expense changed", rec.Code, rec.Body)
+			}
+			if got := listExpenses(t, a); len(got) != 1 || got[0].Category != "Meals" {
+				t.Errorf("expenses after rename = %+v, want the expense under Meals", got)
+			}
+
+			// A merge failing in the database leaves the expenses where they were
+			body = `{"amount":"3.00","category":"Snacks","description":"Crisps","date":"2024-01-16"}`
+			if rec := serveRequest(a, http.MethodPost, "/api/expenses", body); rec.Code != http.StatusCreated {
+				t.Fatalf("POST /api/expenses = %d %s", rec.Code, rec.Body)
+			}
+			for _, category := range []string{"Meals", "Snacks"} {
+				if rec := serveRequest(a, http.MethodPost, "/api/budgets", `{"category":"`+category+`","limit":"10"}`); rec.Code != http.StatusCreated {
+					t.Fatalf("POST /api/budgets = %d %s", rec.Code, rec.Body)
+				}
+			}
+			merge := `{"source_ids":["` + categoryID(t, a, "Snacks") + `"],"target_id":"` + foodID + `"}`
+			if rec := serveRequest(a, http.MethodPost, "/api/categories/merge", merge); rec.Code != http.StatusConflict {
+				t.Errorf("merge of clashing budgets = %d %s, want 409", rec.Code, rec.Body)
+			}
+			for _, expense := range listExpenses(t, a) {
+				if expense.Description == "Crisps" && expense.Category != "Snacks" {
+					t.Errorf("expense after failed merge = %+v, want it under Snacks", expense)
+				}
+			}
+		})
+	}
+}
+
+// categoryID looks up the ID of a category by name
+func categoryID(t *testing.T, a *App, name string) string {
+	t.Helper()
+	var categories []models.Category
+	json.Unmarshal(serveRequest(a, http.MethodGet, "/api/categories", "").Body.Bytes(), &categories)
+	for _, category := range categories {
+		if category.Name == name {
+			return category.ID
+		}
+	}
+	t.Fatalf("GET /api/categories = %+v, want %s", categories, name)
+	return ""
+}
+
+func TestApp_StartClose(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	a := newTestApp(t, config.DriverSQLite)
+
+	a.Start()
+	if err := a.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := a.DB.Ping(); err == nil {
+		t.Error("database still open after Close()")
+	}
+}
+
+func TestApp_ServeDrainsInFlightRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	a := newTestApp(t, config.DriverSQLite)
+	a.Config.ShutdownTimeout = 5 * time.Second
+
+	entered := make(chan struct{})
+	a.Router.GET("/slow", func(c *gin.Context) {
+		close(entered)
+		time.Sleep(200 * time.Millisecond)
+		c.String(http.StatusOK, "done")
+	})
+
+	url, shutdown, served := serveTestApp(t, a)
+	responses := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(url + "/slow")
+		if err != nil {
+			responses <- 0
+			return
+		}
+		resp.Body.Close()
+		responses <- resp.StatusCode
+	}()
+
+	<-entered
+	shutdown()
+	if code := <-responses; code != http.StatusOK {
+		t.Errorf("in-flight request during shutdown = %d, want 200", code)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("Serve() error = %v", err)
+	}
+	if _, err := http.Get(url + "/api/expenses"); err == nil {
+		t.Error("server still accepts requests after shutdown")
+	}
+}
+
+func TestApp_ServeCutsOffRequestsAtDeadline(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	a := newTestApp(t, config.DriverSQLite)
+	a.Config.ShutdownTimeout = 50 * time.Millisecond
+	a.Config.ShutdownGrace = 50 * time.Millisecond
+
+	entered, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	a.Router.GET("/stuck", func(c *gin.Context) {
+		close(entered)
+		<-release
+	})
+
+	url, shutdown, served := serveTestApp(t, a)
+	go http.Get(url + "/stuck")
+
+	<-entered
+	shutdown()
+	if err := <-served; err == nil || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Serve() with a request past the deadline error = %v, want deadline exceeded", err)
+	}
+}
+
+func TestApp_ServeWaitsForHandlersCutOff(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	a := newTestApp(t, config.DriverSQLite)
+	a.Config.ShutdownTimeout = 50 * time.Millisecond
+	a.Config.ShutdownGrace = 5 * time.Second
+
+	// The handler outlives the drain deadline but returns within the grace period
+	entered := make(chan struct{})
+	var finished atomic.Bool
+	a.Router.GET("/late", func(c *gin.Context) {
+		close(entered)
+		time.Sleep(300 * time.Millisecond)
+		finished.Store(true)
+	})
+
+	url, shutdown, served := serveTestApp(t, a)
+	go http.Get(url + "/late")
+
+	<-entered
+	shutdown()
+	if err := <-served; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Serve() error = %v, want deadline exceeded", err)
+	}
+	if !finished.Load() {
+		t.Error("Serve() returned while a handler cut off was still running")
+	}
+}
+
+// serveTestApp serves an application on a free local port, returning its URL, a
+// function starting its shutdown, and the result of Serve
+func serveTestApp(t *testing.T, a *App) (string, context.CancelFunc, <-chan error) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	ctx, shutdown := context.WithCancel(context.Background())
+	t.Cleanup(shutdown)
+
+	served := make(chan error, 1)
+	go func() { served <- a.Serve(ctx, listener) }()
+	return "http://" + listener.Addr().String(), shutdown, served
+}
+
+// serveRequest sends a request, with a JSON body unless body is empty, to an instance
+func serveRequest(a *App, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	a.Router.ServeHTTP(rec, req)
+	return rec
+}
+
+// listExpenses fetches every expense of an instance
+func listExpenses(t *testing.T, a *App) []models.Expense {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	a.Router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/expenses", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/expenses = %d %s", rec.Code, rec.Body)
+	}
+	var expenses []models.Expense
+	if err := json.Unmarshal(rec.Body.Bytes(), &expenses); err != nil {
+		t.Fatalf("decode expenses: %v", err)
+	}
+	return expenses
+}
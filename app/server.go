@@ -0,0 +1,88 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Run serves HTTP on the configured port until ctx is cancelled, then shuts the server
+// down gracefully as Serve does
+func (a *App) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%s", a.Config.Port))
+	if err != nil {
+		return err
+	}
+	log.Printf("Server listening on %s", listener.Addr())
+	return a.Serve(ctx, listener)
+}
+
+// Serve serves HTTP on listener until ctx is cancelled. It then stops accepting
+// connections and waits for in-flight requests to finish, cutting them off once
+// Config.ShutdownTimeout has passed. Handlers of requests cut off get up to
+// Config.ShutdownGrace more to return. Background jobs and databases are left to Close.
+func (a *App) Serve(ctx context.Context, listener net.Listener) error {
+	// Closing a connection does not stop its handler, so running handlers are counted
+	var running atomic.Int64
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			running.Add(1)
+			defer running.Add(-1)
+			a.Router.ServeHTTP(w, r)
+		}),
+		ReadHeaderTimeout: a.Config.ReadTimeout,
+		ReadTimeout:       a.Config.ReadTimeout,
+		WriteTimeout:      a.Config.WriteTimeout,
+		IdleTimeout:       a.Config.IdleTimeout,
+	}
+
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(listener) }()
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Println("Shutting down: draining in-flight requests")
+	drainCtx := context.Background()
+	if a.Config.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		drainCtx, cancel = context.WithTimeout(drainCtx, a.Config.ShutdownTimeout)
+		defer cancel()
+	}
+	if err := server.Shutdown(drainCtx); err != nil {
+		// Requests still running at the deadline are cut off, and their handlers get
+		// a last chance to finish before the databases are closed
+		server.Close()
+		if !waitForHandlers(&running, a.Config.ShutdownGrace) {
+			log.Printf("Shutting down with %d handlers still running; their database writes may fail", running.Load())
+		}
+		return fmt.Errorf("drain in-flight requests: %w", err)
+	}
+
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	log.Println("Server stopped")
+	return nil
+}
+
+// waitForHandlers waits up to grace for the running handler count to reach zero and
+// reports whether it did
+func waitForHandlers(running *atomic.Int64, grace time.Duration) bool {
+	deadline := time.Now().Add(grace)
+	for running.Load() > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return true
+}
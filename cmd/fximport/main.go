@@ -0,0 +1,76 @@
+// Command fximport loads historical exchange rates from ECB-style CSV or XML files
+// into the expenses database, so currency conversion can run fully offline.
+//
+// Usage:
+//
+//	go run ./cmd/fximport [-base EUR] [-format csv|xml] eurofxref-hist.csv [more files...]
+package main
+
+import (
+	"fenmo-ai-assignment/config"
+	"fenmo-ai-assignment/database"
+	"fenmo-ai-assignment/repository"
+	"fenmo-ai-assignment/service"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	base := flag.String("base", "EUR", "currency the rates are quoted against")
+	format := flag.String("format", "", "file format (csv or xml); detected from the file extension when empty")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] FILE...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Load configuration
+	cfg := config.GetConfig()
+
+	// Initialize database
+	db, err := database.Init(cfg.DBPath, cfg.AutoMigrate)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	fxService := service.NewFXService(repository.NewFXRateRepository(db))
+
+	for _, path := range flag.Args() {
+		if err := importFile(fxService, path, *format, *base); err != nil {
+			log.Printf("Failed to import %s: %v", path, err)
+			db.Close()
+			os.Exit(1)
+		}
+	}
+}
+
+// importFile imports a single rates file
+func importFile(fxService *service.FXService, path, format, base string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+
+	result, err := fxService.Import(file, format, base)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Imported %d rates from %s (%s to %s)", result.Imported, path, result.From, result.To)
+	return nil
+}
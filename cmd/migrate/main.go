@@ -0,0 +1,130 @@
+// Command migrate shows and changes the schema version of the expenses database: the
+// SQLite database at DB_PATH, or with -db postgres the PostgreSQL database at DB_DSN.
+// The server applies pending migrations itself on startup unless AUTO_MIGRATE=false.
+//
+// Usage:
+//
+//	go run ./cmd/migrate [-db sqlite|postgres] status     # list migrations and whether they are applied
+//	go run ./cmd/migrate [-db sqlite|postgres] up         # apply every pending migration
+//	go run ./cmd/migrate [-db sqlite|postgres] down [N]   # roll back the latest N migrations (default 1)
+package main
+
+import (
+	"database/sql"
+	"fenmo-ai-assignment/config"
+	"fenmo-ai-assignment/database"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+func main() {
+	driver := flag.String("db", database.DriverSQLite, "database to migrate (sqlite or postgres)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] status | up | down [N]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Load configuration
+	cfg := config.GetConfig()
+
+	db, err := open(cfg, *driver)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := run(db, *driver, flag.Arg(0), flag.Args()[1:]); err != nil {
+		log.Printf("migrate %s: %v", flag.Arg(0), err)
+		db.Close()
+		os.Exit(1)
+	}
+}
+
+// open connects to the database of a driver without touching its schema
+func open(cfg *config.Config, driver string) (*sql.DB, error) {
+	switch driver {
+	case database.DriverSQLite:
+		return database.Open(cfg.DBPath)
+	case database.DriverPostgres:
+		if cfg.DBDSN == "" {
+			return nil, fmt.Errorf("DB_DSN is not set")
+		}
+		db, err := sql.Open("postgres", cfg.DBDSN)
+		if err != nil {
+			return nil, err
+		}
+		return db, db.Ping()
+	default:
+		return nil, fmt.Errorf("unsupported database %q", driver)
+	}
+}
+
+// run executes one migrate command
+func run(db *sql.DB, driver, command string, args []string) error {
+	switch command {
+	case "status":
+		return printStatus(db, driver)
+	case "up":
+		applied, err := database.MigrateUp(db, driver)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d migrations\n", len(applied))
+		return nil
+	case "down":
+		steps := 1
+		if len(args) > 0 {
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of migrations %q", args[0])
+			}
+			steps = n
+		}
+		rolledBack, err := database.MigrateDown(db, driver, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Rolled back %d migrations\n", len(rolledBack))
+		return nil
+	default:
+		flag.Usage()
+		os.Exit(2)
+		return nil
+	}
+}
+
+// printStatus prints a table of the migrations and their state
+func printStatus(db *sql.DB, driver string) error {
+	statuses, err := database.GetMigrationStatus(db, driver)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+	for _, s := range statuses {
+		state, appliedAt := "pending", ""
+		if !s.Pending() {
+			state, appliedAt = "applied", s.AppliedAt.Local().Format(time.DateTime)
+		}
+		if s.Modified {
+			state = "modified"
+		}
+		if s.Unknown {
+			state = "unknown"
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+	}
+	return w.Flush()
+}
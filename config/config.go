@@ -1,17 +1,78 @@
 package config
 
 import (
+	"fenmo-ai-assignment/money"
+	"fenmo-ai-assignment/utils"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// Expense storage drivers
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+	DriverMemory   = "memory"
+)
+
 // Config holds application configuration
 type Config struct {
 	Port   string
 	DBPath string
 	Env    string
+
+	// DBDriver selects where expenses are stored: DriverSQLite, or DriverMemory for a
+	// demo mode that keeps all data in memory and loses it on exit. Everything else is
+	// stored in SQLite at DBPath. DriverPostgres is refused by app.New until the
+	// other data can be stored in PostgreSQL too.
+	DBDriver string
+	// DBDSN is the connection string of the PostgreSQL database, used by the migrate
+	// command
+	DBDSN string
+
+	// ReadTimeout, WriteTimeout and IdleTimeout bound how long the HTTP server takes
+	// to read a request, to write its response, and keeps an idle connection open.
+	// Zero means no limit.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	// ShutdownTimeout is how long in-flight requests may take to finish once a
+	// shutdown starts before they are cut off. Zero waits for them indefinitely.
+	ShutdownTimeout time.Duration
+	// ShutdownGrace is how much longer handlers still running after their requests
+	// were cut off may take to return before the databases are closed under them
+	ShutdownGrace time.Duration
+
+	// AutoMigrate applies pending schema migrations on startup. When disabled the
+	// server refuses to start until they are applied with the migrate command.
+	AutoMigrate bool
+
+	// TrashRetention is how long soft-deleted expenses are kept before being purged.
+	// Zero or negative disables purging.
+	TrashRetention time.Duration
+	// TrashPurgeInterval is how often the background purge runs
+	TrashPurgeInterval time.Duration
+
+	// RecurringInterval is how often due recurring expenses are generated.
+	// Zero or negative disables the scheduler.
+	RecurringInterval time.Duration
+
+	// IdempotencyKeyTTL is how long Idempotency-Key responses are kept for replay
+	IdempotencyKeyTTL time.Duration
+
+	// DefaultCurrency is the ISO 4217 code used when an expense omits its currency
+	DefaultCurrency string
+
+	// AutoCreateCategories creates unknown categories when an expense first uses them
+	// instead of rejecting the expense
+	AutoCreateCategories bool
+
+	// WeekStart is the first day of weekly summary buckets
+	WeekStart time.Weekday
 }
 
 // Load loads configuration from environment variables
@@ -23,6 +84,29 @@ func Load() *Config {
 		Port:   getEnv("PORT", "8080"),
 		DBPath: getEnv("DB_PATH", "./expenses.db"),
 		Env:    getEnv("ENV", "development"),
+
+		DBDriver:    getEnvChoice("DB_DRIVER", DriverSQLite, DriverSQLite, DriverPostgres, DriverMemory),
+		DBDSN:       getEnv("DB_DSN", ""),
+		AutoMigrate: getEnvBool("AUTO_MIGRATE", true),
+
+		ReadTimeout:     getEnvDuration("HTTP_READ_TIMEOUT", 15*time.Second),
+		WriteTimeout:    getEnvDuration("HTTP_WRITE_TIMEOUT", 30*time.Second),
+		IdleTimeout:     getEnvDuration("HTTP_IDLE_TIMEOUT", 60*time.Second),
+		ShutdownTimeout: getEnvDuration("SHUTDOWN_TIMEOUT", 15*time.Second),
+		ShutdownGrace:   getEnvDuration("SHUTDOWN_GRACE", 5*time.Second),
+
+		TrashRetention:     getEnvDuration("TRASH_RETENTION", 30*24*time.Hour),
+		TrashPurgeInterval: getEnvDuration("TRASH_PURGE_INTERVAL", time.Hour),
+
+		RecurringInterval: getEnvDuration("RECURRING_INTERVAL", time.Hour),
+
+		IdempotencyKeyTTL: getEnvDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+
+		DefaultCurrency: getEnvCurrency("DEFAULT_CURRENCY", "INR"),
+
+		AutoCreateCategories: getEnvBool("CATEGORY_AUTO_CREATE", true),
+
+		WeekStart: getEnvWeekday("WEEK_START", time.Monday),
 	}
 
 	return config
@@ -37,9 +121,90 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
+// getEnvDuration parses an environment variable as a time.Duration (e.g. "720h"),
+// falling back to the default when it is unset or invalid
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid duration for %s=%q, using default %v", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// getEnvBool parses an environment variable as a boolean ("true", "0", ...),
+// falling back to the default when it is unset or invalid
+func getEnvBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Invalid boolean for %s=%q, using default %v", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// getEnvChoice reads one of a fixed set of values, ignoring case, falling back to the
+// default when it is unset or not one of them
+func getEnvChoice(key, defaultValue string, choices ...string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	for _, choice := range choices {
+		if strings.EqualFold(value, choice) {
+			return choice
+		}
+	}
+	log.Printf("Invalid value for %s=%q, using default %s", key, value, defaultValue)
+	return defaultValue
+}
+
+// getEnvCurrency reads an ISO 4217 currency code, falling back to the default
+// when it is unset or not a supported currency
+func getEnvCurrency(key, defaultValue string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	currency, ok := money.LookupCurrency(value)
+	if !ok {
+		log.Printf("Unsupported currency for %s=%q, using default %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return currency.Code
+}
+
+// getEnvWeekday reads a weekday name (e.g. "monday"), falling back to the default
+// when it is unset or invalid
+func getEnvWeekday(key string, defaultValue time.Weekday) time.Weekday {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	day, err := utils.ParseWeekday(value)
+	if err != nil {
+		log.Printf("Invalid weekday for %s=%q, using default %v", key, value, defaultValue)
+		return defaultValue
+	}
+	return day
+}
+
 // GetConfig returns the application configuration
 var GetConfig = func() *Config {
 	cfg := Load()
-	log.Printf("Configuration loaded: Port=%s, DBPath=%s, Env=%s", cfg.Port, cfg.DBPath, cfg.Env)
+	log.Printf("Configuration loaded: Port=%s, DBPath=%s, Env=%s, DBDriver=%s", cfg.Port, cfg.DBPath, cfg.Env, cfg.DBDriver)
 	return cfg
 }
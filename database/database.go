@@ -2,60 +2,176 @@ package database
 
 import (
 	"database/sql"
+	"fenmo-ai-assignment/utils"
+	"fmt"
 	"log"
 
+	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
 )
 
-// DB is the database connection
-var DB *sql.DB
+// MemoryPath returns the path of a new private in-memory database, shared by every
+// connection of the pool that opens it, for demo mode. Each call names a different
+// database, and its contents are lost when the pool is closed.
+func MemoryPath() string {
+	return fmt.Sprintf("file:fenmo-%s?mode=memory&cache=shared", utils.GenerateUUID())
+}
 
-// Init initializes the database connection and creates tables
-func Init(dbPath string) error {
-	var err error
-	DB, err = sql.Open("sqlite3", dbPath)
+// Init opens the database and brings its schema up to date. With autoMigrate pending
+// migrations are applied; otherwise they are reported as an error, leaving them to be
+// applied with the migrate command.
+func Init(dbPath string, autoMigrate bool) (*sql.DB, error) {
+	db, err := Open(dbPath)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if err := prepareSchema(db, DriverSQLite, autoMigrate); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// The search index depends on how SQLite was built, so it is set up on every start
+	// rather than by a migration
+	if err := createSearchIndex(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	log.Println("Database initialized successfully")
+	return db, nil
+}
+
+// Open opens the database without touching its schema, for the migrate command
+func Open(dbPath string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, err
 	}
 
 	// Test connection
-	if err = DB.Ping(); err != nil {
-		return err
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// OpenPostgres opens the PostgreSQL database holding expenses and brings its schema up
+// to date like Init
+func OpenPostgres(dsn string, autoMigrate bool) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
 	}
 
-	// Create tables
-	if err = createTables(); err != nil {
+	if err := prepareSchema(db, DriverPostgres, autoMigrate); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	log.Println("PostgreSQL database initialized successfully")
+	return db, nil
+}
+
+// prepareSchema applies pending migrations with autoMigrate, and otherwise fails when
+// any are pending
+func prepareSchema(db *sql.DB, driver string, autoMigrate bool) error {
+	if autoMigrate {
+		_, err := MigrateUp(db, driver)
 		return err
 	}
 
-	log.Println("Database initialized successfully")
+	pending, err := PendingMigrations(db, driver)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%s database has %d pending migrations; run the migrate command or set AUTO_MIGRATE=true", driver, len(pending))
+	}
 	return nil
 }
 
-// createTables creates the expenses table and indexes
-func createTables() error {
-	createTableSQL := `
-	CREATE TABLE IF NOT EXISTS expenses (
-		id TEXT PRIMARY KEY,
-		amount TEXT NOT NULL,
-		category TEXT NOT NULL,
-		description TEXT NOT NULL,
-		date TEXT NOT NULL,
-		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_category ON expenses(category);
-	CREATE INDEX IF NOT EXISTS idx_date ON expenses(date);
-	`
-
-	_, err := DB.Exec(createTableSQL)
-	return err
-}
+// createSearchIndex creates the FTS5 index over expense descriptions and the triggers
+// keeping it in sync, filling it from existing expenses the first time. SQLite builds
+// without FTS5 (go-sqlite3 needs the sqlite_fts5 build tag) skip the index, and
+// description searches fall back to substring matching.
+func createSearchIndex(db *sql.DB) error {
+	// The index is rebuilt whenever its triggers are missing or outdated: on first use,
+	// after a build without FTS5 dropped them and expenses changed unindexed, and when
+	// they still look entries up by the unindexed expense_id
+	rebuild, err := searchTriggersStale(db)
+	if err != nil {
+		return err
+	}
 
-// Close closes the database connection
-func Close() error {
-	if DB != nil {
-		return DB.Close()
+	var fts5 bool
+	if err := db.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&fts5); err != nil {
+		return err
+	}
+	if !fts5 || rebuild {
+		// Triggers left by an FTS5 build would make every expense write fail
+		_, err = db.Exec(`
+		DROP TRIGGER IF EXISTS expenses_fts_insert;
+		DROP TRIGGER IF EXISTS expenses_fts_update;
+		DROP TRIGGER IF EXISTS expenses_fts_delete;
+		`)
+		if err != nil {
+			return err
+		}
+	}
+	if !fts5 {
+		log.Printf("SQLite was built without FTS5; description search falls back to substring matching")
+		return nil
+	}
+
+	_, err = db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS expenses_fts USING fts5(
+		expense_id UNINDEXED,
+		description,
+		tokenize = 'unicode61 remove_diacritics 2'
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// Entries share the rowid of their expense, so updates and deletes find them
+	// without scanning the index
+	_, err = db.Exec(`
+	CREATE TRIGGER IF NOT EXISTS expenses_fts_insert AFTER INSERT ON expenses BEGIN
+		INSERT INTO expenses_fts (rowid, expense_id, description) VALUES (new.rowid, new.id, new.description);
+	END;
+	CREATE TRIGGER IF NOT EXISTS expenses_fts_update AFTER UPDATE OF description ON expenses BEGIN
+		UPDATE expenses_fts SET description = new.description WHERE rowid = old.rowid;
+	END;
+	CREATE TRIGGER IF NOT EXISTS expenses_fts_delete AFTER DELETE ON expenses BEGIN
+		DELETE FROM expenses_fts WHERE rowid = old.rowid;
+	END;
+	`)
+	if err != nil {
+		return err
+	}
+
+	if rebuild {
+		_, err = db.Exec(`
+		DELETE FROM expenses_fts;
+		INSERT INTO expenses_fts (rowid, expense_id, description) SELECT rowid, id, description FROM expenses;
+		`)
+		return err
 	}
 	return nil
 }
+
+// searchTriggersStale reports whether the search index triggers are missing, or were
+// created before index entries were keyed by the rowid of their expense
+func searchTriggersStale(db *sql.DB) (bool, error) {
+	var count int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM sqlite_master
+		WHERE type = 'trigger' AND name LIKE 'expenses_fts_%' AND sql LIKE '%rowid%'
+	`).Scan(&count)
+	return count < 3, err
+}
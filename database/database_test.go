@@ -0,0 +1,81 @@
+package database
+
+import "testing"
+
+func TestCreateSearchIndex_RekeysLegacyIndex(t *testing.T) {
+	db := openTestDB(t)
+	if _, err := MigrateUp(db, DriverSQLite); err != nil {
+		t.Fatalf("MigrateUp() error = %v", err)
+	}
+	var fts5 bool
+	if err := db.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&fts5); err != nil || !fts5 {
+		t.Skip("SQLite built without FTS5 (build with -tags sqlite_fts5)")
+	}
+
+	// An index from before entries were keyed by rowid
+	if _, err := db.Exec(`
+	INSERT INTO expenses (id, amount, category, description, date) VALUES ('e1', '1.00', 'Food', 'Lunch', '2024-01-01');
+	CREATE VIRTUAL TABLE expenses_fts USING fts5(expense_id UNINDEXED, description, tokenize = 'unicode61 remove_diacritics 2');
+	INSERT INTO expenses_fts (expense_id, description) VALUES ('e1', 'Lunch');
+	CREATE TRIGGER expenses_fts_insert AFTER INSERT ON expenses BEGIN
+		INSERT INTO expenses_fts (expense_id, description) VALUES (new.id, new.description);
+	END;
+	CREATE TRIGGER expenses_fts_update AFTER UPDATE OF description ON expenses BEGIN
+		UPDATE expenses_fts SET description = new.description WHERE expense_id = old.id;
+	END;
+	CREATE TRIGGER expenses_fts_delete AFTER DELETE ON expenses BEGIN
+		DELETE FROM expenses_fts WHERE expense_id = old.id;
+	END;
+	`); err != nil {
+		t.Fatalf("create legacy index: %v", err)
+	}
+
+	if err := createSearchIndex(db); err != nil {
+		t.Fatalf("createSearchIndex() error = %v", err)
+	}
+	if stale, err := searchTriggersStale(db); err != nil || stale {
+		t.Errorf("searchTriggersStale() after upgrade = %v, %v; want false", stale, err)
+	}
+
+	// Entries share the rowid of their expense, and follow edits and deletes
+	matches := func(term string) []string {
+		t.Helper()
+		rows, err := db.Query(`
+			SELECT fts.expense_id FROM expenses_fts fts JOIN expenses ON expenses.rowid = fts.rowid
+			WHERE expenses_fts MATCH ? AND expenses.id = fts.expense_id`, term)
+		if err != nil {
+			t.Fatalf("search %q: %v", term, err)
+		}
+		defer rows.Close()
+		var ids []string
+		for rows.Next() {
+			var id string
+			rows.Scan(&id)
+			ids = append(ids, id)
+		}
+		return ids
+	}
+	if got := matches("lunch"); len(got) != 1 || got[0] != "e1" {
+		t.Errorf("search(lunch) after rebuild = %v, want [e1]", got)
+	}
+	if _, err := db.Exec(`
+	INSERT INTO expenses (id, amount, category, description, date) VALUES ('e2', '2.00', 'Food', 'Lunch again', '2024-01-02');
+	UPDATE expenses SET description = 'Dinner' WHERE id = 'e1';
+	`); err != nil {
+		t.Fatalf("write expenses: %v", err)
+	}
+	if got := matches("lunch"); len(got) != 1 || got[0] != "e2" {
+		t.Errorf("search(lunch) after edit = %v, want [e2]", got)
+	}
+	if _, err := db.Exec(`DELETE FROM expenses WHERE id = 'e2'`); err != nil {
+		t.Fatalf("delete expense: %v", err)
+	}
+	if got := matches("lunch"); len(got) != 0 {
+		t.Errorf("search(lunch) after delete = %v, want none", got)
+	}
+	var entries int
+	db.QueryRow(`SELECT COUNT(*) FROM expenses_fts`).Scan(&entries)
+	if entries != 1 {
+		t.Errorf("index holds %d entries, want 1", entries)
+	}
+}
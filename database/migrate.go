@@ -0,0 +1,466 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sqlMigrations holds the SQL migrations of each driver in migrations/<driver>, named
+// NNNN_name.up.sql and NNNN_name.down.sql
+//
+//go:embed migrations/*/*.sql
+var sqlMigrations embed.FS
+
+// Database drivers migrations can run against
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
+// migrationLockKey identifies the PostgreSQL advisory lock held while migrating
+const migrationLockKey = 4417262801
+
+// dialect is what differs between the databases migrations run against
+type dialect struct {
+	goMigrations  []Migration
+	timestampType string
+	// bind numbers the ? placeholders of a query when the database needs it
+	bind func(query string) string
+	// lockSQL and unlockSQL take and release a session lock serialising migration
+	// runs, for databases where several instances may migrate at once
+	lockSQL, unlockSQL string
+}
+
+// migrationConn is the part of *sql.DB and *sql.Conn that migrations run on
+type migrationConn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// dialects maps each driver onto its dialect
+var dialects = map[string]dialect{
+	DriverSQLite: {
+		goMigrations:  goMigrations,
+		timestampType: "DATETIME",
+		bind:          func(query string) string { return query },
+	},
+	DriverPostgres: {
+		timestampType: "TIMESTAMPTZ",
+		bind:          numberPlaceholders,
+		lockSQL:       fmt.Sprintf(`SELECT pg_advisory_lock(%d)`, migrationLockKey),
+		unlockSQL:     fmt.Sprintf(`SELECT pg_advisory_unlock(%d)`, migrationLockKey),
+	},
+}
+
+// lookupDialect returns the dialect of a driver
+func lookupDialect(driver string) (dialect, error) {
+	d, ok := dialects[driver]
+	if !ok {
+		return dialect{}, fmt.Errorf("unsupported database driver %q", driver)
+	}
+	return d, nil
+}
+
+// numberPlaceholders rewrites ? placeholders as $1, $2, ... for PostgreSQL
+func numberPlaceholders(query string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Migration is one versioned schema change. SQL migrations set UpSQL and DownSQL; Go
+// migrations set Up and Down for changes that need more than plain statements.
+type Migration struct {
+	Version int
+	Name    string
+
+	UpSQL   string
+	DownSQL string
+
+	Up   func(tx *sql.Tx) error
+	Down func(tx *sql.Tx) error
+}
+
+// Checksum identifies the migration's content, so a migration edited after it was
+// applied is detected. Go migrations can only be checked by version and name.
+func (m Migration) Checksum() string {
+	content := m.UpSQL
+	if m.Up != nil {
+		content = fmt.Sprintf("go:%d:%s", m.Version, m.Name)
+	}
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// reversible reports whether the migration can be rolled back
+func (m Migration) reversible() bool {
+	return m.Down != nil || m.DownSQL != ""
+}
+
+// MigrationStatus is the state of one migration in a database
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+	// Modified is set when the migration changed after it was applied
+	Modified bool
+	// Unknown is set for applied migrations this build does not have, i.e. the
+	// database was migrated by a newer version
+	Unknown bool
+}
+
+// Pending reports whether the migration has not been applied yet
+func (s MigrationStatus) Pending() bool {
+	return s.AppliedAt == nil
+}
+
+// appliedMigration is a row of schema_migrations
+type appliedMigration struct {
+	version   int
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Migrations returns every known migration of a driver ordered by version
+func Migrations(driver string) ([]Migration, error) {
+	d, err := lookupDialect(driver)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int]*Migration)
+
+	// The up and down files of a SQL migration combine into one migration
+	dir := "migrations/" + driver
+	entries, err := fs.ReadDir(sqlMigrations, dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		m, err := parseMigrationFile(dir, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		existing, ok := byVersion[m.Version]
+		if !ok {
+			byVersion[m.Version] = &m
+			continue
+		}
+		if existing.Name != m.Name {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", existing.Name, m.Name, m.Version)
+		}
+		existing.UpSQL += m.UpSQL
+		existing.DownSQL += m.DownSQL
+	}
+
+	for _, m := range d.goMigrations {
+		if existing, ok := byVersion[m.Version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", existing.Name, m.Name, m.Version)
+		}
+		m := m
+		byVersion[m.Version] = &m
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == nil && m.UpSQL == "" {
+			return nil, fmt.Errorf("migration %d (%s) has no up migration", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// parseMigrationFile reads an embedded SQL migration named NNNN_name.up.sql or
+// NNNN_name.down.sql
+func parseMigrationFile(dir, filename string) (Migration, error) {
+	base, direction := strings.TrimSuffix(filename, ".sql"), ""
+	switch {
+	case strings.HasSuffix(base, ".up"):
+		base, direction = strings.TrimSuffix(base, ".up"), "up"
+	case strings.HasSuffix(base, ".down"):
+		base, direction = strings.TrimSuffix(base, ".down"), "down"
+	default:
+		return Migration{}, fmt.Errorf("migration %s must end in .up.sql or .down.sql", filename)
+	}
+
+	prefix, name, ok := strings.Cut(base, "_")
+	version, err := strconv.Atoi(prefix)
+	if !ok || err != nil || version <= 0 || name == "" {
+		return Migration{}, fmt.Errorf("migration %s must be named NNNN_name.%s.sql", filename, direction)
+	}
+
+	content, err := sqlMigrations.ReadFile(dir + "/" + filename)
+	if err != nil {
+		return Migration{}, err
+	}
+
+	m := Migration{Version: version, Name: name}
+	if direction == "up" {
+		m.UpSQL = string(content)
+	} else {
+		m.DownSQL = string(content)
+	}
+	return m, nil
+}
+
+// MigrateUp applies every pending migration in order, each in its own transaction,
+// and returns the migrations applied. It refuses to run when an applied migration
+// was edited or is unknown to this build. On PostgreSQL it holds an advisory lock
+// for the whole run, so instances starting together apply each migration once.
+func MigrateUp(db *sql.DB, driver string) ([]Migration, error) {
+	var done []Migration
+	err := withMigrationLock(db, driver, func(conn *sql.Conn) error {
+		migrations, applied, err := loadMigrations(conn, driver)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+			if err := runMigration(conn, driver, m, true); err != nil {
+				return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+			}
+			log.Printf("Applied migration %d (%s)", m.Version, m.Name)
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// MigrateDown rolls back the latest steps applied migrations, newest first, and
+// returns the migrations rolled back. It takes the same lock as MigrateUp.
+func MigrateDown(db *sql.DB, driver string, steps int) ([]Migration, error) {
+	var done []Migration
+	err := withMigrationLock(db, driver, func(conn *sql.Conn) error {
+		migrations, applied, err := loadMigrations(conn, driver)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			m := migrations[i]
+			if _, ok := applied[m.Version]; !ok {
+				continue
+			}
+			if !m.reversible() {
+				return fmt.Errorf("migration %d (%s) cannot be rolled back", m.Version, m.Name)
+			}
+			if err := runMigration(conn, driver, m, false); err != nil {
+				return fmt.Errorf("rolling back migration %d (%s): %w", m.Version, m.Name, err)
+			}
+			log.Printf("Rolled back migration %d (%s)", m.Version, m.Name)
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// withMigrationLock runs fn on one connection holding the migration lock of the
+// driver, if it has one. A connection whose lock cannot be released is discarded
+// rather than returned to the pool still holding it.
+func withMigrationLock(db *sql.DB, driverName string, fn func(conn *sql.Conn) error) error {
+	d, err := lookupDialect(driverName)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if d.lockSQL == "" {
+		return fn(conn)
+	}
+	if _, err := conn.ExecContext(ctx, d.lockSQL); err != nil {
+		return fmt.Errorf("take migration lock: %w", err)
+	}
+	err = fn(conn)
+	if _, unlockErr := conn.ExecContext(ctx, d.unlockSQL); unlockErr != nil {
+		conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		if err == nil {
+			err = fmt.Errorf("release migration lock: %w", unlockErr)
+		}
+	}
+	return err
+}
+
+// GetMigrationStatus lists every known migration with whether and when it was
+// applied, followed by any applied migrations this build does not know
+func GetMigrationStatus(db *sql.DB, driver string) ([]MigrationStatus, error) {
+	migrations, err := Migrations(driver)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db, driver)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		status := MigrationStatus{Version: m.Version, Name: m.Name}
+		if row, ok := applied[m.Version]; ok {
+			appliedAt := row.appliedAt
+			status.AppliedAt = &appliedAt
+			status.Modified = row.checksum != m.Checksum()
+			delete(applied, m.Version)
+		}
+		statuses = append(statuses, status)
+	}
+
+	for _, row := range applied {
+		appliedAt := row.appliedAt
+		statuses = append(statuses, MigrationStatus{Version: row.version, Name: row.name, AppliedAt: &appliedAt, Unknown: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// PendingMigrations returns the migrations not yet applied to the database, failing
+// like MigrateUp when applied migrations were edited or are unknown
+func PendingMigrations(db *sql.DB, driver string) ([]Migration, error) {
+	migrations, applied, err := loadMigrations(db, driver)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; !ok {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// loadMigrations returns the known migrations and those applied to the database,
+// after checking the two agree
+func loadMigrations(db migrationConn, driver string) ([]Migration, map[int]appliedMigration, error) {
+	migrations, err := Migrations(driver)
+	if err != nil {
+		return nil, nil, err
+	}
+	applied, err := appliedMigrations(db, driver)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	known := make(map[int]bool, len(migrations))
+	for _, m := range migrations {
+		known[m.Version] = true
+		if row, ok := applied[m.Version]; ok && row.checksum != m.Checksum() {
+			return nil, nil, fmt.Errorf("migration %d (%s) was changed after it was applied; add a new migration instead of editing it", m.Version, m.Name)
+		}
+	}
+	for version, row := range applied {
+		if !known[version] {
+			return nil, nil, fmt.Errorf("database has migration %d (%s) applied, which this build does not know", version, row.name)
+		}
+	}
+	return migrations, applied, nil
+}
+
+// appliedMigrations reads schema_migrations, creating it on first use
+func appliedMigrations(db migrationConn, driver string) (map[int]appliedMigration, error) {
+	d, err := lookupDialect(driver)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	_, err = db.ExecContext(ctx, `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		checksum TEXT NOT NULL,
+		applied_at `+d.timestampType+` NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]appliedMigration)
+	for rows.Next() {
+		var row appliedMigration
+		if err := rows.Scan(&row.version, &row.name, &row.checksum, &row.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[row.version] = row
+	}
+	return applied, rows.Err()
+}
+
+// runMigration applies or rolls back one migration, recording the change in
+// schema_migrations within the same transaction
+func runMigration(db migrationConn, driver string, m Migration, up bool) error {
+	d, err := lookupDialect(driver)
+	if err != nil {
+		return err
+	}
+	tx, err := db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	switch {
+	case up && m.Up != nil:
+		err = m.Up(tx)
+	case up:
+		_, err = tx.Exec(m.UpSQL)
+	case m.Down != nil:
+		err = m.Down(tx)
+	default:
+		_, err = tx.Exec(m.DownSQL)
+	}
+	if err != nil {
+		return err
+	}
+
+	if up {
+		_, err = tx.Exec(
+			d.bind(`INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)`),
+			m.Version, m.Name, m.Checksum(), time.Now().UTC(),
+		)
+	} else {
+		_, err = tx.Exec(d.bind(`DELETE FROM schema_migrations WHERE version = ?`), m.Version)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,232 @@
+package database
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "migrate.db"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMigrations_OrderedAndComplete(t *testing.T) {
+	migrations, err := Migrations(DriverSQLite)
+	if err != nil {
+		t.Fatalf("Migrations(DriverSQLite) error = %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("Migrations(DriverSQLite) returned none")
+	}
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %d has version %d, want %d", i, m.Version, i+1)
+		}
+		if !m.reversible() {
+			t.Errorf("migration %d (%s) has no down migration", m.Version, m.Name)
+		}
+	}
+}
+
+func TestMigrateUpDown(t *testing.T) {
+	db := openTestDB(t)
+	migrations, _ := Migrations(DriverSQLite)
+
+	applied, err := MigrateUp(db, DriverSQLite)
+	if err != nil {
+		t.Fatalf("MigrateUp() error = %v", err)
+	}
+	if len(applied) != len(migrations) {
+		t.Errorf("MigrateUp() applied %d migrations, want %d", len(applied), len(migrations))
+	}
+	if _, err := db.Exec(`INSERT INTO expenses (id, amount, category, description, date) VALUES ('e1', '1.00', 'Food', 'x', '2024-01-01')`); err != nil {
+		t.Errorf("insert after MigrateUp: %v", err)
+	}
+
+	// Applying again is a no-op
+	if again, err := MigrateUp(db, DriverSQLite); err != nil || len(again) != 0 {
+		t.Errorf("second MigrateUp() = %d migrations, %v; want none", len(again), err)
+	}
+
+	rolledBack, err := MigrateDown(db, DriverSQLite, 1)
+	if err != nil || len(rolledBack) != 1 || rolledBack[0].Version != migrations[len(migrations)-1].Version {
+		t.Fatalf("MigrateDown(1) = %+v, %v; want the latest migration", rolledBack, err)
+	}
+	pending, err := PendingMigrations(db, DriverSQLite)
+	if err != nil || len(pending) != 1 {
+		t.Errorf("PendingMigrations(DriverSQLite) after MigrateDown(1) = %d, %v; want 1", len(pending), err)
+	}
+
+	if _, err := MigrateDown(db, DriverSQLite, len(migrations)); err != nil {
+		t.Fatalf("MigrateDown(all) error = %v", err)
+	}
+	if missing, _ := tableMissing(db, "expenses"); !missing {
+		t.Error("expenses table still exists after rolling back every migration")
+	}
+	if _, err := MigrateUp(db, DriverSQLite); err != nil {
+		t.Fatalf("MigrateUp() after full rollback error = %v", err)
+	}
+}
+
+func TestMigrationStatus(t *testing.T) {
+	db := openTestDB(t)
+	migrations, _ := Migrations(DriverSQLite)
+	if _, err := MigrateUp(db, DriverSQLite); err != nil {
+		t.Fatalf("MigrateUp() error = %v", err)
+	}
+	if _, err := MigrateDown(db, DriverSQLite, 1); err != nil {
+		t.Fatalf("MigrateDown() error = %v", err)
+	}
+
+	statuses, err := GetMigrationStatus(db, DriverSQLite)
+	if err != nil {
+		t.Fatalf("GetMigrationStatus() error = %v", err)
+	}
+	if len(statuses) != len(migrations) {
+		t.Fatalf("GetMigrationStatus() returned %d entries, want %d", len(statuses), len(migrations))
+	}
+	for i, s := range statuses {
+		if wantPending := i == len(statuses)-1; s.Pending() != wantPending {
+			t.Errorf("migration %d pending = %v, want %v", s.Version, s.Pending(), wantPending)
+		}
+		if s.Modified || s.Unknown {
+			t.Errorf("migration %d marked modified or unknown: %+v", s.Version, s)
+		}
+	}
+}
+
+func TestMigrateUp_DetectsEditedAndUnknownMigrations(t *testing.T) {
+	db := openTestDB(t)
+	if _, err := MigrateUp(db, DriverSQLite); err != nil {
+		t.Fatalf("MigrateUp() error = %v", err)
+	}
+
+	if _, err := db.Exec(`UPDATE schema_migrations SET checksum = 'edited' WHERE version = 1`); err != nil {
+		t.Fatalf("edit checksum: %v", err)
+	}
+	if _, err := MigrateUp(db, DriverSQLite); err == nil || !strings.Contains(err.Error(), "changed after it was applied") {
+		t.Errorf("MigrateUp() with an edited migration error = %v", err)
+	}
+	if _, err := MigrateDown(db, DriverSQLite, 1); err == nil {
+		t.Error("MigrateDown() with an edited migration should fail")
+	}
+	statuses, _ := GetMigrationStatus(db, DriverSQLite)
+	if !statuses[0].Modified {
+		t.Errorf("GetMigrationStatus()[0] = %+v, want modified", statuses[0])
+	}
+
+	migrations, _ := Migrations(DriverSQLite)
+	db.Exec(`UPDATE schema_migrations SET checksum = ? WHERE version = 1`, migrations[0].Checksum())
+	if _, err := db.Exec(`INSERT INTO schema_migrations (version, name, checksum) VALUES (999, 'from_the_future', '')`); err != nil {
+		t.Fatalf("insert unknown migration: %v", err)
+	}
+	if _, err := PendingMigrations(db, DriverSQLite); err == nil || !strings.Contains(err.Error(), "does not know") {
+		t.Errorf("PendingMigrations(DriverSQLite) with an unknown migration error = %v", err)
+	}
+	statuses, _ = GetMigrationStatus(db, DriverSQLite)
+	if last := statuses[len(statuses)-1]; last.Version != 999 || !last.Unknown {
+		t.Errorf("GetMigrationStatus() last = %+v, want unknown 999", last)
+	}
+}
+
+func TestMigrateUp_AdoptsLegacyDatabase(t *testing.T) {
+	db := openTestDB(t)
+
+	// The schema of a database from before soft delete, currencies and categories
+	_, err := db.Exec(`
+	CREATE TABLE expenses (
+		id TEXT PRIMARY KEY,
+		amount TEXT NOT NULL,
+		category TEXT NOT NULL,
+		description TEXT NOT NULL,
+		date TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	INSERT INTO expenses (id, amount, category, description, date) VALUES
+		('e1', '1.00', 'Food', 'x', '2024-01-01'),
+		('e2', '2.00', 'food ', 'y', '2024-01-02'),
+		('e3', '3.00', 'Travel', 'z', '2024-01-03');
+	`)
+	if err != nil {
+		t.Fatalf("create legacy schema: %v", err)
+	}
+
+	if _, err := MigrateUp(db, DriverSQLite); err != nil {
+		t.Fatalf("MigrateUp() error = %v", err)
+	}
+
+	var currency string
+	var deletedAt, recurringID sql.NullString
+	err = db.QueryRow(`SELECT currency, deleted_at, recurring_id FROM expenses WHERE id = 'e1'`).Scan(&currency, &deletedAt, &recurringID)
+	if err != nil || currency != "INR" || deletedAt.Valid || recurringID.Valid {
+		t.Errorf("legacy expense after migration = %q, %v, %v, %v", currency, deletedAt, recurringID, err)
+	}
+
+	var categories, spellings int
+	db.QueryRow(`SELECT COUNT(*) FROM categories`).Scan(&categories)
+	db.QueryRow(`SELECT COUNT(DISTINCT category) FROM expenses`).Scan(&spellings)
+	if categories != 2 || spellings != 2 {
+		t.Errorf("seeded %d categories over %d spellings, want 2 and 2", categories, spellings)
+	}
+}
+
+func tableMissing(db *sql.DB, name string) (bool, error) {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, name).Scan(&count)
+	return count == 0, err
+}
+
+func TestMigrateUpDown_Postgres(t *testing.T) {
+	// Runs against a local server, e.g. the postgres service in docker-compose.yml
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := MigrateUp(db, DriverPostgres); err != nil {
+		t.Fatalf("MigrateUp() error = %v", err)
+	}
+	migrations, _ := Migrations(DriverPostgres)
+	if _, err := MigrateDown(db, DriverPostgres, len(migrations)); err != nil {
+		t.Fatalf("MigrateDown(all) error = %v", err)
+	}
+	if pending, err := PendingMigrations(db, DriverPostgres); err != nil || len(pending) != len(migrations) {
+		t.Errorf("PendingMigrations(DriverPostgres) after full rollback = %d, %v; want %d", len(pending), err, len(migrations))
+	}
+	if _, err := MigrateDown(db, DriverPostgres, len(migrations)); err != nil {
+		t.Fatalf("MigrateDown(all) error = %v", err)
+	}
+
+	// Instances starting together take turns, so each migration is applied once
+	const instances = 4
+	applied := make(chan int, instances)
+	for i := 0; i < instances; i++ {
+		go func() {
+			done, err := MigrateUp(db, DriverPostgres)
+			if err != nil {
+				t.Errorf("concurrent MigrateUp() error = %v", err)
+			}
+			applied <- len(done)
+		}()
+	}
+	total := 0
+	for i := 0; i < instances; i++ {
+		total += <-applied
+	}
+	if total != len(migrations) {
+		t.Errorf("concurrent MigrateUp() applied %d migrations in total, want %d", total, len(migrations))
+	}
+}
@@ -0,0 +1,141 @@
+package database
+
+import (
+	"database/sql"
+	"fenmo-ai-assignment/utils"
+	"fmt"
+	"log"
+	"time"
+)
+
+// goMigrations are the SQLite migrations written in Go, interleaved by version with the
+// SQL migrations in migrations/sqlite
+var goMigrations = []Migration{
+	{
+		Version: 2,
+		Name:    "legacy_columns",
+		Up:      addLegacyColumns,
+		// The columns belong to the baseline tables and are dropped with them
+		Down: func(tx *sql.Tx) error { return nil },
+	},
+	{
+		Version: 4,
+		Name:    "seed_categories",
+		Up:      seedCategories,
+		// Seeded categories are ordinary categories once created, so they are kept
+		Down: func(tx *sql.Tx) error { return nil },
+	},
+}
+
+// addLegacyColumns adds the columns that databases created before the baseline were
+// given one by one as features arrived
+func addLegacyColumns(tx *sql.Tx) error {
+	// Databases created before soft delete existed lack the deleted_at column
+	if err := addColumnIfMissing(tx, "expenses", "deleted_at", "DATETIME"); err != nil {
+		return err
+	}
+
+	// Rows recorded before multi-currency support were entered in rupees
+	if err := addColumnIfMissing(tx, "expenses", "currency", "TEXT NOT NULL DEFAULT 'INR'"); err != nil {
+		return err
+	}
+
+	// Expenses generated from a recurring template link back to it
+	if err := addColumnIfMissing(tx, "expenses", "recurring_id", "TEXT"); err != nil {
+		return err
+	}
+
+	// Categories created before subcategories existed are top-level
+	return addColumnIfMissing(tx, "categories", "parent_id", "TEXT")
+}
+
+// seedCategories builds the categories table from the distinct categories already
+// used by expenses, when it is still empty. Spellings differing only in case or
+// surrounding spaces ("Food", "food", "Food ") become one category, and the expenses
+// are rewritten to its name.
+func seedCategories(tx *sql.Tx) error {
+	var existing int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM categories`).Scan(&existing); err != nil {
+		return err
+	}
+	if existing > 0 {
+		return nil
+	}
+
+	rows, err := tx.Query(`
+		SELECT MIN(TRIM(category)) FROM expenses
+		WHERE TRIM(category) != ''
+		GROUP BY LOWER(TRIM(category))
+		ORDER BY 1
+	`)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for _, name := range names {
+		if _, err := tx.Exec(
+			`INSERT INTO categories (id, name, created_at) VALUES (?, ?, ?)`,
+			utils.GenerateUUID(), name, now,
+		); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(
+			`UPDATE expenses SET category = ? WHERE LOWER(TRIM(category)) = LOWER(?) AND category != ?`,
+			name, name, name,
+		); err != nil {
+			return err
+		}
+	}
+
+	if len(names) > 0 {
+		log.Printf("Migrated %d categories from existing expenses", len(names))
+	}
+	return nil
+}
+
+// addColumnIfMissing adds a column to an existing table unless it is already present
+func addColumnIfMissing(tx *sql.Tx, table, column, definition string) error {
+	rows, err := tx.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
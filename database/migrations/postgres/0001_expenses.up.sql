@@ -0,0 +1,34 @@
+-- Expenses and their tags. Amounts are exact NUMERICs keeping the scale they were
+-- entered with; dates and timestamps use the native types.
+
+CREATE TABLE IF NOT EXISTS expenses (
+	id TEXT PRIMARY KEY,
+	amount NUMERIC NOT NULL CHECK (amount >= 0),
+	currency TEXT NOT NULL DEFAULT 'INR',
+	category TEXT NOT NULL,
+	description TEXT NOT NULL,
+	date DATE NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	deleted_at TIMESTAMPTZ,
+	recurring_id TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_category ON expenses(category);
+CREATE INDEX IF NOT EXISTS idx_deleted_at ON expenses(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_currency ON expenses(currency);
+CREATE INDEX IF NOT EXISTS idx_date_created_id ON expenses(date, created_at, id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_recurring_occurrence ON expenses(recurring_id, date) WHERE recurring_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS tags (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS expense_tags (
+	expense_id TEXT NOT NULL REFERENCES expenses(id) ON DELETE CASCADE,
+	tag_id TEXT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+	PRIMARY KEY (expense_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_expense_tags_tag ON expense_tags(tag_id);
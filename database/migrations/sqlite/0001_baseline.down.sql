@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS category_audit;
+DROP TABLE IF EXISTS expense_tags;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS categories;
+DROP TABLE IF EXISTS recurring_expenses;
+DROP TABLE IF EXISTS envelope_transfers;
+DROP TABLE IF EXISTS envelope_incomes;
+DROP TABLE IF EXISTS envelope_allocations;
+DROP TABLE IF EXISTS envelopes;
+DROP TABLE IF EXISTS budgets;
+DROP TABLE IF EXISTS fx_rates;
+DROP TABLE IF EXISTS idempotency_keys;
+DROP TABLE IF EXISTS expenses;
@@ -0,0 +1,146 @@
+-- Baseline schema: every table as it stood when versioned migrations were introduced.
+-- IF NOT EXISTS lets databases created before then adopt it in place.
+
+CREATE TABLE IF NOT EXISTS expenses (
+	id TEXT PRIMARY KEY,
+	amount TEXT NOT NULL,
+	category TEXT NOT NULL,
+	description TEXT NOT NULL,
+	date TEXT NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	deleted_at DATETIME,
+	currency TEXT NOT NULL DEFAULT 'INR',
+	recurring_id TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_category ON expenses(category);
+CREATE INDEX IF NOT EXISTS idx_date ON expenses(date);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+	key TEXT PRIMARY KEY,
+	request_hash TEXT NOT NULL,
+	status_code INTEGER NOT NULL DEFAULT 0,
+	response_body TEXT NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_created_at ON idempotency_keys(created_at);
+
+CREATE TABLE IF NOT EXISTS fx_rates (
+	base TEXT NOT NULL,
+	currency TEXT NOT NULL,
+	date TEXT NOT NULL,
+	rate TEXT NOT NULL,
+	PRIMARY KEY (base, currency, date)
+);
+
+CREATE TABLE IF NOT EXISTS budgets (
+	id TEXT PRIMARY KEY,
+	category TEXT NOT NULL,
+	period TEXT NOT NULL,
+	limit_amount TEXT NOT NULL,
+	currency TEXT NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (category, period, currency)
+);
+
+CREATE INDEX IF NOT EXISTS idx_budgets_period ON budgets(period);
+
+CREATE TABLE IF NOT EXISTS envelopes (
+	id TEXT PRIMARY KEY,
+	category TEXT NOT NULL,
+	currency TEXT NOT NULL,
+	allocation TEXT NOT NULL DEFAULT '0',
+	rollover INTEGER NOT NULL DEFAULT 0,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (category, currency)
+);
+
+CREATE TABLE IF NOT EXISTS envelope_allocations (
+	envelope_id TEXT NOT NULL,
+	month TEXT NOT NULL,
+	amount TEXT NOT NULL,
+	PRIMARY KEY (envelope_id, month)
+);
+
+CREATE TABLE IF NOT EXISTS envelope_incomes (
+	month TEXT NOT NULL,
+	currency TEXT NOT NULL,
+	amount TEXT NOT NULL,
+	PRIMARY KEY (month, currency)
+);
+
+CREATE TABLE IF NOT EXISTS envelope_transfers (
+	id TEXT PRIMARY KEY,
+	from_envelope_id TEXT NOT NULL,
+	to_envelope_id TEXT NOT NULL,
+	month TEXT NOT NULL,
+	amount TEXT NOT NULL,
+	currency TEXT NOT NULL,
+	note TEXT NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_envelope_transfers_month ON envelope_transfers(month);
+
+CREATE TABLE IF NOT EXISTS recurring_expenses (
+	id TEXT PRIMARY KEY,
+	amount TEXT NOT NULL,
+	currency TEXT NOT NULL,
+	category TEXT NOT NULL,
+	description TEXT NOT NULL,
+	frequency TEXT NOT NULL,
+	repeat_interval INTEGER NOT NULL DEFAULT 1,
+	month_day INTEGER NOT NULL DEFAULT 0,
+	weekday TEXT NOT NULL DEFAULT '',
+	start_date TEXT NOT NULL,
+	end_date TEXT NOT NULL DEFAULT '',
+	next_date TEXT NOT NULL DEFAULT '',
+	last_generated TEXT NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_recurring_next_date ON recurring_expenses(next_date);
+
+CREATE TABLE IF NOT EXISTS categories (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	colour TEXT NOT NULL DEFAULT '',
+	icon TEXT NOT NULL DEFAULT '',
+	archived INTEGER NOT NULL DEFAULT 0,
+	parent_id TEXT,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_name ON categories(name COLLATE NOCASE);
+
+CREATE TABLE IF NOT EXISTS tags (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name ON tags(name);
+
+CREATE TABLE IF NOT EXISTS expense_tags (
+	expense_id TEXT NOT NULL,
+	tag_id TEXT NOT NULL,
+	PRIMARY KEY (expense_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_expense_tags_tag ON expense_tags(tag_id);
+
+CREATE TABLE IF NOT EXISTS category_audit (
+	id TEXT PRIMARY KEY,
+	action TEXT NOT NULL,
+	category_id TEXT NOT NULL,
+	category_name TEXT NOT NULL,
+	previous_names TEXT NOT NULL,
+	expenses_updated INTEGER NOT NULL DEFAULT 0,
+	recurring_updated INTEGER NOT NULL DEFAULT 0,
+	budgets_updated INTEGER NOT NULL DEFAULT 0,
+	envelopes_updated INTEGER NOT NULL DEFAULT 0,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_date_created_id;
+DROP INDEX IF EXISTS idx_currency;
+DROP INDEX IF EXISTS idx_deleted_at;
+DROP INDEX IF EXISTS idx_recurring_occurrence;
+DROP INDEX IF EXISTS idx_categories_parent;
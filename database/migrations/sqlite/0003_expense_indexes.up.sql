@@ -0,0 +1,7 @@
+-- Indexes over columns that 0002 adds to databases predating them
+
+CREATE INDEX IF NOT EXISTS idx_categories_parent ON categories(parent_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_recurring_occurrence ON expenses(recurring_id, date) WHERE recurring_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_deleted_at ON expenses(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_currency ON expenses(currency);
+CREATE INDEX IF NOT EXISTS idx_date_created_id ON expenses(date, created_at, id);
@@ -32,24 +32,27 @@ expenseForm.addEventListener('submit', async (e) => {
     
     const formData = {
         amount: document.getElementById('amount').value.trim(),
+        currency: document.getElementById('currency').value.trim().toUpperCase(),
         category: document.getElementById('category').value.trim(),
         description: document.getElementById('description').value.trim(),
-        date: document.getElementById('date').value
+        date: document.getElementById('date').value,
+        tags: document.getElementById('tags').value.split(',').map(t => t.trim()).filter(t => t)
     };
     
     try {
-        const response = await fetch(`${API_BASE_URL}/expenses`, {
-            method: 'POST',
-            headers: {
-                'Content-Type': 'application/json'
-            },
-            body: JSON.stringify(formData)
-        });
+        // The same key is sent on every retry so the server creates the expense at most once
+        const idempotencyKey = generateIdempotencyKey();
+        const response = await postWithRetry(`${API_BASE_URL}/expenses`, formData, idempotencyKey);
         
         const data = await response.json();
         
         if (response.ok) {
-            showSuccess('Expense added successfully!');
+            let message = 'Expense added successfully!';
+            // The server lists any budgets this expense pushed over their limit
+            (data.exceeded_budgets || []).forEach(budget => {
+                message += ` ${budget.category} budget exceeded: ${budget.currency} ${budget.spent} of ${budget.limit}.`;
+            });
+            showSuccess(message);
             expenseForm.reset();
             document.getElementById('date').valueAsDate = new Date();
             loadExpenses();
@@ -64,6 +67,40 @@ expenseForm.addEventListener('submit', async (e) => {
     }
 });
 
+// POST JSON, retrying network failures with the same Idempotency-Key
+async function postWithRetry(url, body, idempotencyKey, attempts = 3) {
+    for (let attempt = 1; ; attempt++) {
+        try {
+            const response = await fetch(url, {
+                method: 'POST',
+                headers: {
+                    'Content-Type': 'application/json',
+                    'Idempotency-Key': idempotencyKey
+                },
+                body: JSON.stringify(body)
+            });
+            
+            // 409 means the original request is still being processed
+            if (response.status !== 409 || attempt >= attempts) {
+                return response;
+            }
+        } catch (error) {
+            if (attempt >= attempts) {
+                throw error;
+            }
+        }
+        await new Promise(resolve => setTimeout(resolve, 500 * attempt));
+    }
+}
+
+// Generate a unique key for one form submission
+function generateIdempotencyKey() {
+    if (window.crypto && crypto.randomUUID) {
+        return crypto.randomUUID();
+    }
+    return `${Date.now()}-${Math.random().toString(16).slice(2)}`;
+}
+
 // Filter and sort changes
 categoryFilter.addEventListener('change', loadExpenses);
 sortOption.addEventListener('change', loadExpenses);
@@ -78,12 +115,14 @@ async function loadExpenses() {
         const category = categoryFilter.value;
         const sort = sortOption.value;
         
-        let url = `${API_BASE_URL}/expenses?`;
+        // totals=true returns an envelope with exact per-currency totals computed by the server
+        let url = `${API_BASE_URL}/expenses?totals=true&`;
         if (category) url += `category=${encodeURIComponent(category)}&`;
         if (sort) url += `sort=${encodeURIComponent(sort)}`;
         
         const response = await fetch(url);
-        const expenses = await response.json();
+        const data = await response.json();
+        const expenses = data.items;
         
         loadingMessage.style.display = 'none';
         
@@ -94,8 +133,8 @@ async function loadExpenses() {
         }
         
         displayExpenses(expenses);
-        updateTotal(expenses);
-        updateCategoryFilter(expenses);
+        updateTotal(data.total);
+        updateCategoryFilter();
     } catch (error) {
         loadingMessage.style.display = 'none';
         expensesList.innerHTML = `<div class="error-message">Error loading expenses: ${error.message}</div>`;
@@ -111,7 +150,7 @@ function displayExpenses(expenses) {
                     <th>Date</th>
                     <th>Category</th>
                     <th>Description</th>
-                    <th>Amount (₹)</th>
+                    <th>Amount</th>
                 </tr>
             </thead>
             <tbody>
@@ -119,8 +158,8 @@ function displayExpenses(expenses) {
                     <tr>
                         <td>${formatDate(expense.date)}</td>
                         <td>${escapeHtml(expense.category)}</td>
-                        <td>${escapeHtml(expense.description)}</td>
-                        <td>${parseFloat(expense.amount).toFixed(2)}</td>
+                        <td>${escapeHtml(expense.description)}${(expense.tags || []).map(tag => ` <span class="tag">${escapeHtml(tag)}</span>`).join('')}</td>
+                        <td>${escapeHtml(expense.currency)} ${escapeHtml(expense.amount)}</td>
                     </tr>
                 `).join('')}
             </tbody>
@@ -130,18 +169,18 @@ function displayExpenses(expenses) {
     expensesList.innerHTML = table;
 }
 
-// Calculate and update total
-function updateTotal(expenses) {
-    const total = expenses.reduce((sum, expense) => {
-        return sum + parseFloat(expense.amount);
-    }, 0);
-    
-    totalAmount.textContent = total.toFixed(2);
+// Show the per-currency totals returned by the server
+function updateTotal(totals) {
+    totalAmount.textContent = Object.keys(totals)
+        .sort()
+        .map(currency => `${currency} ${totals[currency]}`)
+        .join(' · ') || '0.00';
 }
 
-// Update category filter options
-function updateCategoryFilter(expenses) {
-    const categories = [...new Set(expenses.map(e => e.category))].sort();
+// Update category filter options from the managed categories
+async function updateCategoryFilter() {
+    const response = await fetch(`${API_BASE_URL}/categories`);
+    const categories = (await response.json()).map(c => c.name);
     const currentValue = categoryFilter.value;
     
     categoryFilter.innerHTML = '<option value="">All Categories</option>';
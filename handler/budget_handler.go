@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"fenmo-ai-assignment/models"
+	"fenmo-ai-assignment/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BudgetHandler handles HTTP requests for budgets
+type BudgetHandler struct {
+	service *service.BudgetService
+}
+
+// NewBudgetHandler creates a new budget handler
+func NewBudgetHandler(service *service.BudgetService) *BudgetHandler {
+	return &BudgetHandler{service: service}
+}
+
+// CreateBudget handles POST /budgets
+func (h *BudgetHandler) CreateBudget(c *gin.Context) {
+	var req models.BudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	budget, err := h.service.CreateBudget(req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, budget)
+}
+
+// GetBudgets handles GET /budgets
+func (h *BudgetHandler) GetBudgets(c *gin.Context) {
+	budgets, err := h.service.GetBudgets()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	// Return empty array if no budgets
+	if budgets == nil {
+		budgets = []models.Budget{}
+	}
+
+	c.JSON(http.StatusOK, budgets)
+}
+
+// GetBudget handles GET /budgets/:id
+func (h *BudgetHandler) GetBudget(c *gin.Context) {
+	budget, err := h.service.GetBudget(c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, budget)
+}
+
+// UpdateBudget handles PUT /budgets/:id
+func (h *BudgetHandler) UpdateBudget(c *gin.Context) {
+	var req models.BudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	budget, err := h.service.UpdateBudget(c.Param("id"), req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, budget)
+}
+
+// DeleteBudget handles DELETE /budgets/:id
+func (h *BudgetHandler) DeleteBudget(c *gin.Context) {
+	if err := h.service.DeleteBudget(c.Param("id")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetStatus handles GET /budgets/status?month=YYYY-MM
+// The month defaults to the current one.
+func (h *BudgetHandler) GetStatus(c *gin.Context) {
+	report, err := h.service.GetStatus(c.Query("month"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"fenmo-ai-assignment/models"
+	"fenmo-ai-assignment/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CategoryHandler handles HTTP requests for categories
+type CategoryHandler struct {
+	service *service.CategoryService
+}
+
+// NewCategoryHandler creates a new category handler
+func NewCategoryHandler(service *service.CategoryService) *CategoryHandler {
+	return &CategoryHandler{service: service}
+}
+
+// CreateCategory handles POST /categories
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
+	var req models.CreateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	category, err := h.service.CreateCategory(req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, category)
+}
+
+// GetCategories handles GET /categories
+// Archived categories are included with ?archived=true.
+func (h *CategoryHandler) GetCategories(c *gin.Context) {
+	categories, err := h.service.GetCategories(c.Query("archived") == "true")
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	// Return empty array if no categories
+	if categories == nil {
+		categories = []models.Category{}
+	}
+
+	c.JSON(http.StatusOK, categories)
+}
+
+// GetCategory handles GET /categories/:id
+func (h *CategoryHandler) GetCategory(c *gin.Context) {
+	category, err := h.service.GetCategory(c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// UpdateCategory handles PATCH /categories/:id
+func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
+	var req models.UpdateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	category, err := h.service.UpdateCategory(c.Param("id"), req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// DeleteCategory handles DELETE /categories/:id
+// ?reassign_to=<id> moves the category's expenses, recurring templates, budgets,
+// envelopes and subcategories to another category.
+func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
+	if err := h.service.DeleteCategory(c.Param("id"), c.Query("reassign_to")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RenameCategory handles POST /categories/:id/rename
+func (h *CategoryHandler) RenameCategory(c *gin.Context) {
+	var req models.RenameCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	change, err := h.service.RenameCategory(c.Param("id"), req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, change)
+}
+
+// MergeCategories handles POST /categories/merge
+func (h *CategoryHandler) MergeCategories(c *gin.Context) {
+	var req models.MergeCategoriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	change, err := h.service.MergeCategories(req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, change)
+}
+
+// GetAudit handles GET /categories/audit
+func (h *CategoryHandler) GetAudit(c *gin.Context) {
+	entries, err := h.service.GetAudit()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	// Return empty array if nothing was renamed or merged yet
+	if entries == nil {
+		entries = []models.CategoryAuditEntry{}
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
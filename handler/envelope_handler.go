@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"fenmo-ai-assignment/models"
+	"fenmo-ai-assignment/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EnvelopeHandler handles HTTP requests for envelope budgeting
+type EnvelopeHandler struct {
+	service *service.EnvelopeService
+}
+
+// NewEnvelopeHandler creates a new envelope handler
+func NewEnvelopeHandler(service *service.EnvelopeService) *EnvelopeHandler {
+	return &EnvelopeHandler{service: service}
+}
+
+// CreateEnvelope handles POST /envelopes
+func (h *EnvelopeHandler) CreateEnvelope(c *gin.Context) {
+	var req models.EnvelopeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	envelope, err := h.service.CreateEnvelope(req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, envelope)
+}
+
+// GetEnvelopes handles GET /envelopes
+func (h *EnvelopeHandler) GetEnvelopes(c *gin.Context) {
+	envelopes, err := h.service.GetEnvelopes()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	// Return empty array if no envelopes
+	if envelopes == nil {
+		envelopes = []models.Envelope{}
+	}
+
+	c.JSON(http.StatusOK, envelopes)
+}
+
+// GetEnvelope handles GET /envelopes/:id
+func (h *EnvelopeHandler) GetEnvelope(c *gin.Context) {
+	envelope, err := h.service.GetEnvelope(c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, envelope)
+}
+
+// UpdateEnvelope handles PUT /envelopes/:id
+func (h *EnvelopeHandler) UpdateEnvelope(c *gin.Context) {
+	var req models.EnvelopeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	envelope, err := h.service.UpdateEnvelope(c.Param("id"), req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, envelope)
+}
+
+// DeleteEnvelope handles DELETE /envelopes/:id
+func (h *EnvelopeHandler) DeleteEnvelope(c *gin.Context) {
+	if err := h.service.DeleteEnvelope(c.Param("id")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// SetAllocation handles PUT /envelopes/:id/allocations/:month
+func (h *EnvelopeHandler) SetAllocation(c *gin.Context) {
+	var req models.AllocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	allocation, err := h.service.SetAllocation(c.Param("id"), c.Param("month"), req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, allocation)
+}
+
+// SetIncome handles PUT /envelopes/income
+func (h *EnvelopeHandler) SetIncome(c *gin.Context) {
+	var req models.IncomeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	period, err := h.service.SetIncome(req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, period)
+}
+
+// CreateTransfer handles POST /envelopes/transfers
+func (h *EnvelopeHandler) CreateTransfer(c *gin.Context) {
+	var req models.TransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	transfer, err := h.service.Transfer(req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, transfer)
+}
+
+// GetTransfers handles GET /envelopes/transfers?month=YYYY-MM
+func (h *EnvelopeHandler) GetTransfers(c *gin.Context) {
+	transfers, err := h.service.GetTransfers(c.Query("month"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	// Return empty array if no transfers
+	if transfers == nil {
+		transfers = []models.EnvelopeTransfer{}
+	}
+
+	c.JSON(http.StatusOK, transfers)
+}
+
+// GetStatus handles GET /envelopes/status
+// ?month= reports one month; ?from=&to= a range of months. Both default to the current month.
+func (h *EnvelopeHandler) GetStatus(c *gin.Context) {
+	from, to := c.Query("from"), c.Query("to")
+	if month := c.Query("month"); month != "" {
+		from, to = month, month
+	}
+
+	report, err := h.service.GetStatus(from, to)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
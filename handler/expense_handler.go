@@ -1,21 +1,29 @@
 package handler
 
 import (
+	"encoding/json"
 	"fenmo-ai-assignment/models"
 	"fenmo-ai-assignment/service"
+	"log"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
+// IdempotencyKeyHeader is the request header clients use to make POST retries safe
+const IdempotencyKeyHeader = "Idempotency-Key"
+
 // ExpenseHandler handles HTTP requests for expenses
 type ExpenseHandler struct {
-	service *service.ExpenseService
+	service     *service.ExpenseService
+	idempotency *service.IdempotencyService
+	fx          *service.FXService
 }
 
 // NewExpenseHandler creates a new expense handler
-func NewExpenseHandler(service *service.ExpenseService) *ExpenseHandler {
-	return &ExpenseHandler{service: service}
+func NewExpenseHandler(service *service.ExpenseService, idempotency *service.IdempotencyService, fx *service.FXService) *ExpenseHandler {
+	return &ExpenseHandler{service: service, idempotency: idempotency, fx: fx}
 }
 
 // CreateExpense handles POST /expenses
@@ -28,40 +36,265 @@ func (h *ExpenseHandler) CreateExpense(c *gin.Context) {
 		return
 	}
 
+	// Without an Idempotency-Key every request creates a new expense
+	key := c.GetHeader(IdempotencyKeyHeader)
+	if key == "" || h.idempotency == nil {
+		expense, err := h.service.CreateExpense(req)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, expense)
+		return
+	}
+
+	// Fingerprint the parsed request so formatting differences don't count as a different body
+	canonical, err := json.Marshal(req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	record, err := h.idempotency.Begin(key, service.HashRequest(canonical))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if record != nil {
+		// Retry of a completed request: replay the original response
+		c.Header("Idempotent-Replayed", "true")
+		c.Data(record.StatusCode, "application/json; charset=utf-8", []byte(record.ResponseBody))
+		return
+	}
+
 	// Create expense
 	expense, err := h.service.CreateExpense(req)
 	if err != nil {
-		// Check if it's a validation error
-		if validationErr, ok := err.(*service.ValidationError); ok {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + validationErr.Message})
-			return
+		// Nothing was created, so release the key and let the client retry
+		if abortErr := h.idempotency.Abort(key); abortErr != nil {
+			log.Printf("Failed to release idempotency key: %v", abortErr)
 		}
+		respondError(c, err)
+		return
+	}
 
-		// Database or other error
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	body, err := json.Marshal(expense)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if err := h.idempotency.Complete(key, http.StatusCreated, body); err != nil {
+		// The expense exists; report success but a retry may create a duplicate
+		log.Printf("Failed to store idempotent response: %v", err)
+	}
+
+	c.Data(http.StatusCreated, "application/json; charset=utf-8", body)
+}
+
+// GetExpense handles GET /expenses/:id
+func (h *ExpenseHandler) GetExpense(c *gin.Context) {
+	expense, err := h.service.GetExpense(c.Param("id"))
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, expense)
+	c.JSON(http.StatusOK, expense)
+}
+
+// UpdateExpense handles PUT /expenses/:id
+func (h *ExpenseHandler) UpdateExpense(c *gin.Context) {
+	var req models.CreateExpenseRequest
+
+	// PUT replaces the whole expense, so every field is required
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	expense, err := h.service.UpdateExpense(c.Param("id"), req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, expense)
+}
+
+// PatchExpense handles PATCH /expenses/:id
+func (h *ExpenseHandler) PatchExpense(c *gin.Context) {
+	var req models.UpdateExpenseRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	expense, err := h.service.PatchExpense(c.Param("id"), req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, expense)
+}
+
+// DeleteExpense handles DELETE /expenses/:id
+func (h *ExpenseHandler) DeleteExpense(c *gin.Context) {
+	if err := h.service.DeleteExpense(c.Param("id")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetTrash handles GET /trash
+func (h *ExpenseHandler) GetTrash(c *gin.Context) {
+	expenses, err := h.service.GetTrash()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	// Return empty array if the trash is empty
+	if expenses == nil {
+		expenses = []models.Expense{}
+	}
+
+	c.JSON(http.StatusOK, expenses)
+}
+
+// RestoreExpense handles POST /expenses/:id/restore
+func (h *ExpenseHandler) RestoreExpense(c *gin.Context) {
+	expense, err := h.service.RestoreExpense(c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, expense)
 }
 
 // GetExpenses handles GET /expenses
 func (h *ExpenseHandler) GetExpenses(c *gin.Context) {
 	// Get query parameters
-	category := c.Query("category")
+	filter := parseExpenseFilter(c)
 	sort := c.Query("sort")
+	convertTo := c.Query("convert_to")
+
+	withTotals := c.Query("totals") == "true"
+
+	// limit or cursor switches to the paginated envelope
+	if c.Query("limit") != "" || c.Query("cursor") != "" {
+		h.getExpensesPage(c, filter, sort, convertTo, withTotals)
+		return
+	}
 
 	// Get expenses
-	expenses, err := h.service.GetExpenses(category, sort)
+	expenses, err := h.service.GetExpenses(filter, sort)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		respondError(c, err)
 		return
 	}
 
+	// Optionally add converted amounts in a reporting currency
+	if convertTo != "" && h.fx != nil {
+		if err := h.fx.ConvertExpenses(expenses, convertTo); err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+
 	// Return empty array if no expenses
 	if expenses == nil {
 		expenses = []models.Expense{}
 	}
 
+	// totals=true wraps the full list in an envelope; otherwise keep the bare array
+	if withTotals {
+		totals, err := h.service.GetTotals(filter, sort)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, models.ExpensePage{Items: expenses, ExpenseTotals: totals})
+		return
+	}
+
 	c.JSON(http.StatusOK, expenses)
 }
+
+// getExpensesPage serves GET /expenses?limit=&cursor= as {items, next_cursor},
+// adding count, total and filters when totals were requested
+func (h *ExpenseHandler) getExpensesPage(c *gin.Context, filter models.ExpenseFilter, sort, convertTo string, withTotals bool) {
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: limit must be a number"})
+			return
+		}
+		limit = parsed
+	}
+
+	page, err := h.service.GetExpensesPage(filter, sort, c.Query("cursor"), limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if convertTo != "" && h.fx != nil {
+		if err := h.fx.ConvertExpenses(page.Items, convertTo); err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+
+	if withTotals {
+		if page.ExpenseTotals, err = h.service.GetTotals(filter, sort); err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// parseExpenseFilter reads the list filters from the query string.
+// category, exclude_category and tag may be repeated.
+func parseExpenseFilter(c *gin.Context) models.ExpenseFilter {
+	return models.ExpenseFilter{
+		Categories:           c.QueryArray("category"),
+		ExcludeCategories:    c.QueryArray("exclude_category"),
+		IncludeSubcategories: c.Query("include_subcategories") == "true",
+		Tags:                 c.QueryArray("tag"),
+		TagMode:              c.Query("tag_mode"),
+		Currency:             c.Query("currency"),
+		From:                 c.Query("from"),
+		To:                   c.Query("to"),
+		MinAmount:            c.Query("min_amount"),
+		MaxAmount:            c.Query("max_amount"),
+		Query:                c.Query("q"),
+	}
+}
+
+// respondError maps service errors onto HTTP status codes
+func respondError(c *gin.Context, err error) {
+	switch e := err.(type) {
+	case *service.ValidationError:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + e.Message})
+	case *service.InvalidSortError:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + e.Message, "allowed": e.Allowed})
+	case *service.NotFoundError:
+		c.JSON(http.StatusNotFound, gin.H{"error": e.Message})
+	case *service.ConflictError:
+		c.JSON(http.StatusConflict, gin.H{"error": e.Message})
+	case *service.IdempotencyMismatchError:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": e.Message})
+	default:
+		// Database or other error
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"bytes"
+	"fenmo-ai-assignment/service"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxFXImportSize bounds the size of an uploaded rates file (the full ECB history is a few MB)
+const maxFXImportSize = 32 << 20
+
+// FXHandler handles HTTP requests for exchange rates
+type FXHandler struct {
+	service *service.FXService
+}
+
+// NewFXHandler creates a new FX handler
+func NewFXHandler(service *service.FXService) *FXHandler {
+	return &FXHandler{service: service}
+}
+
+// ImportRates handles POST /fx-rates/import
+// The request body is an ECB-style CSV or XML file. The format is taken from
+// ?format=, then the Content-Type header, and is otherwise detected from the content.
+func (h *FXHandler) ImportRates(c *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxFXImportSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	format := detectFXFormat(c.Query("format"), c.ContentType(), body)
+	base := c.DefaultQuery("base", "EUR")
+
+	result, err := h.service.Import(bytes.NewReader(body), format, base)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// detectFXFormat picks the file format for an import request
+func detectFXFormat(format, contentType string, body []byte) string {
+	if format != "" {
+		return strings.ToLower(format)
+	}
+	if strings.Contains(contentType, "xml") {
+		return service.FXFormatXML
+	}
+	if strings.Contains(contentType, "csv") {
+		return service.FXFormatCSV
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(body), []byte("<")) {
+		return service.FXFormatXML
+	}
+	return service.FXFormatCSV
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"fenmo-ai-assignment/models"
+	"fenmo-ai-assignment/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecurringHandler handles HTTP requests for recurring expenses
+type RecurringHandler struct {
+	service *service.RecurringService
+}
+
+// NewRecurringHandler creates a new recurring expense handler
+func NewRecurringHandler(service *service.RecurringService) *RecurringHandler {
+	return &RecurringHandler{service: service}
+}
+
+// CreateRecurring handles POST /recurring-expenses
+func (h *RecurringHandler) CreateRecurring(c *gin.Context) {
+	var req models.RecurringExpenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	recurring, err := h.service.CreateRecurring(req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, recurring)
+}
+
+// GetRecurringExpenses handles GET /recurring-expenses
+func (h *RecurringHandler) GetRecurringExpenses(c *gin.Context) {
+	templates, err := h.service.GetRecurringExpenses()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	// Return empty array if there are no recurring expenses
+	if templates == nil {
+		templates = []models.RecurringExpense{}
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// GetRecurring handles GET /recurring-expenses/:id
+func (h *RecurringHandler) GetRecurring(c *gin.Context) {
+	recurring, err := h.service.GetRecurring(c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, recurring)
+}
+
+// UpdateRecurring handles PUT /recurring-expenses/:id
+func (h *RecurringHandler) UpdateRecurring(c *gin.Context) {
+	var req models.RecurringExpenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	recurring, err := h.service.UpdateRecurring(c.Param("id"), req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, recurring)
+}
+
+// DeleteRecurring handles DELETE /recurring-expenses/:id
+func (h *RecurringHandler) DeleteRecurring(c *gin.Context) {
+	if err := h.service.DeleteRecurring(c.Param("id")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
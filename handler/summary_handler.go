@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"fenmo-ai-assignment/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SummaryHandler handles HTTP requests for spending reports
+type SummaryHandler struct {
+	service *service.SummaryService
+}
+
+// NewSummaryHandler creates a new summary handler
+func NewSummaryHandler(service *service.SummaryService) *SummaryHandler {
+	return &SummaryHandler{service: service}
+}
+
+// GetCategorySummary handles GET /summary/categories
+// It accepts the same filters as GET /expenses (from, to, category, ...) and
+// convert_to for totals in one currency
+func (h *SummaryHandler) GetCategorySummary(c *gin.Context) {
+	summary, err := h.service.GetCategorySummary(parseExpenseFilter(c), c.Query("convert_to"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// GetTagSummary handles GET /summary/tags
+// It accepts the same filters as GET /expenses (from, to, tag, ...) and convert_to
+// for totals in one currency
+func (h *SummaryHandler) GetTagSummary(c *gin.Context) {
+	summary, err := h.service.GetTagSummary(parseExpenseFilter(c), c.Query("convert_to"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// GetTimeSeries handles GET /summary/timeseries
+// interval is day, week, month or year; week_start overrides the configured first day
+// of week buckets, breakdown=category adds one series per category and convert_to
+// adds totals in one currency. The expense filters of GET /expenses apply as well.
+func (h *SummaryHandler) GetTimeSeries(c *gin.Context) {
+	opts := service.TimeSeriesOptions{
+		Interval:  c.Query("interval"),
+		WeekStart: c.Query("week_start"),
+		ConvertTo: c.Query("convert_to"),
+	}
+	switch breakdown := c.Query("breakdown"); breakdown {
+	case "":
+	case "category":
+		opts.ByCategory = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: breakdown must be category"})
+		return
+	}
+
+	series, err := h.service.GetTimeSeries(parseExpenseFilter(c), opts)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
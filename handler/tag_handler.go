@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"fenmo-ai-assignment/models"
+	"fenmo-ai-assignment/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TagHandler handles HTTP requests for tags
+type TagHandler struct {
+	service *service.TagService
+}
+
+// NewTagHandler creates a new tag handler
+func NewTagHandler(service *service.TagService) *TagHandler {
+	return &TagHandler{service: service}
+}
+
+// GetTags handles GET /tags
+func (h *TagHandler) GetTags(c *gin.Context) {
+	tags, err := h.service.GetTags()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	// Return empty array if no tags
+	if tags == nil {
+		tags = []models.Tag{}
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// GetTag handles GET /tags/:id
+func (h *TagHandler) GetTag(c *gin.Context) {
+	tag, err := h.service.GetTag(c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// RenameTag handles POST /tags/:id/rename
+func (h *TagHandler) RenameTag(c *gin.Context) {
+	var req models.RenameTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	tag, err := h.service.RenameTag(c.Param("id"), req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// MergeTags handles POST /tags/merge
+func (h *TagHandler) MergeTags(c *gin.Context) {
+	var req models.MergeTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	merge, err := h.service.MergeTags(req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, merge)
+}
+
+// DeleteTag handles DELETE /tags/:id
+func (h *TagHandler) DeleteTag(c *gin.Context) {
+	if err := h.service.DeleteTag(c.Param("id")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
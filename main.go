@@ -1,30 +1,41 @@
 package main
 
 import (
+	"context"
+	"fenmo-ai-assignment/app"
 	"fenmo-ai-assignment/config"
-	"fenmo-ai-assignment/database"
-	"fenmo-ai-assignment/routes"
-	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
 	// Load configuration
 	cfg := config.GetConfig()
 
-	// Initialize database
-	if err := database.Init(cfg.DBPath); err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+	// Build the application and start its background jobs
+	application, err := app.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize application: %v", err)
 	}
-	defer database.Close()
+	application.Start()
 
-	// Setup routes
-	router := routes.SetupRoutes()
+	// Serve until SIGINT or SIGTERM, then drain in-flight requests
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	serveErr := application.Run(ctx)
+	stop()
+	if serveErr != nil {
+		log.Printf("Server error: %v", serveErr)
+	}
 
-	// Start server
-	addr := fmt.Sprintf(":%s", cfg.Port)
-	log.Printf("Server starting on %s", addr)
-	if err := router.Run(addr); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	// Stop background jobs and close the databases, flushing SQLite to disk
+	if err := application.Close(); err != nil {
+		log.Printf("Failed to close application: %v", err)
+		os.Exit(1)
+	}
+	log.Println("Shutdown complete")
+	if serveErr != nil {
+		os.Exit(1)
 	}
 }
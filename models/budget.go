@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// BudgetPeriodMonthly applies a budget to every month. A budget whose period is a
+// specific month (YYYY-MM) replaces the monthly budget of its category for that month.
+const BudgetPeriodMonthly = "monthly"
+
+// Budget caps the spend of one category in one currency per month
+type Budget struct {
+	ID        string    `json:"id" db:"id"`
+	Category  string    `json:"category" db:"category"`
+	Period    string    `json:"period" db:"period"` // "monthly" or a specific month (YYYY-MM)
+	Limit     string    `json:"limit" db:"limit_amount"`
+	Currency  string    `json:"currency" db:"currency"` // Only expenses in this currency count
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// BudgetRequest represents the request body for creating or replacing a budget
+type BudgetRequest struct {
+	Category string `json:"category" binding:"required"`
+	Period   string `json:"period"` // Optional; defaults to monthly
+	Limit    string `json:"limit" binding:"required"`
+	Currency string `json:"currency"` // Optional; defaults to the configured currency
+}
+
+// BudgetStatus compares a budget with the actual spend of a month
+type BudgetStatus struct {
+	Budget
+	Month      string `json:"month"`
+	Spent      string `json:"spent"`
+	Remaining  string `json:"remaining"`  // Negative once the budget is exceeded
+	Percentage string `json:"percentage"` // Spent as a percentage of the limit
+	Over       bool   `json:"over"`
+}
+
+// BudgetReport is the status of every budget applying to a month
+type BudgetReport struct {
+	Month   string         `json:"month"`
+	Budgets []BudgetStatus `json:"budgets"`
+}
@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// Category is a named expense category. Names are unique ignoring case.
+// Categories form a tree: a subcategory such as Groceries has Food as its parent.
+type Category struct {
+	ID        string    `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	ParentID  *string   `json:"parent_id" db:"parent_id"` // nil for a top-level category
+	Colour    string    `json:"colour" db:"colour"`       // Hex colour, e.g. #4caf50; empty for none
+	Icon      string    `json:"icon" db:"icon"`           // Emoji or icon name; empty for none
+	Archived  bool      `json:"archived" db:"archived"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// CreateCategoryRequest represents the request body for creating a category
+type CreateCategoryRequest struct {
+	Name     string `json:"name" binding:"required"`
+	ParentID string `json:"parent_id"` // Empty for a top-level category
+	Colour   string `json:"colour"`
+	Icon     string `json:"icon"`
+}
+
+// UpdateCategoryRequest represents the request body for a partial category update.
+// Fields left nil keep their current value.
+type UpdateCategoryRequest struct {
+	ParentID *string `json:"parent_id"` // Empty string moves the category to the top level
+	Colour   *string `json:"colour"`
+	Icon     *string `json:"icon"`
+	Archived *bool   `json:"archived"`
+}
+
+// RenameCategoryRequest represents the request body for renaming a category
+type RenameCategoryRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// MergeCategoriesRequest represents the request body for merging categories. The
+// sources are folded into the target and then deleted.
+type MergeCategoriesRequest struct {
+	SourceIDs []string `json:"source_ids" binding:"required"`
+	TargetID  string   `json:"target_id" binding:"required"`
+}
+
+// Category audit actions
+const (
+	CategoryActionRename = "rename"
+	CategoryActionMerge  = "merge"
+)
+
+// CategoryRowsChanged counts the rows rewritten from one category name to another
+type CategoryRowsChanged struct {
+	Expenses  int `json:"expenses"` // Including expenses in the trash
+	Recurring int `json:"recurring_expenses"`
+	Budgets   int `json:"budgets"`
+	Envelopes int `json:"envelopes"`
+}
+
+// CategoryAuditEntry records a rename or merge and the rows it rewrote
+type CategoryAuditEntry struct {
+	ID            string              `json:"id" db:"id"`
+	Action        string              `json:"action" db:"action"`               // rename or merge
+	CategoryID    string              `json:"category_id" db:"category_id"`     // The renamed category or merge target
+	CategoryName  string              `json:"category_name" db:"category_name"` // Its name afterwards
+	PreviousNames []string            `json:"previous_names" db:"previous_names"`
+	RowsChanged   CategoryRowsChanged `json:"rows_changed"`
+	CreatedAt     time.Time           `json:"created_at" db:"created_at"`
+}
+
+// CategoryChange is the result of a rename or merge
+type CategoryChange struct {
+	Category *Category          `json:"category"`
+	Audit    CategoryAuditEntry `json:"audit"`
+}
@@ -0,0 +1,107 @@
+package models
+
+import "time"
+
+// Envelope holds the money set aside for one category in one currency. Spending in
+// the category is drawn from it.
+type Envelope struct {
+	ID         string    `json:"id" db:"id"`
+	Category   string    `json:"category" db:"category"`
+	Currency   string    `json:"currency" db:"currency"`
+	Allocation string    `json:"allocation" db:"allocation"` // Allocated in months without an explicit allocation
+	Rollover   bool      `json:"rollover" db:"rollover"`     // Carry the closing balance, positive or negative, into the next month
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// EnvelopeRequest represents the request body for creating or replacing an envelope
+type EnvelopeRequest struct {
+	Category   string `json:"category" binding:"required"`
+	Currency   string `json:"currency"`   // Optional; defaults to the configured currency
+	Allocation string `json:"allocation"` // Optional; defaults to zero
+	Rollover   bool   `json:"rollover"`
+}
+
+// EnvelopeAllocation is the amount assigned to an envelope for one month
+type EnvelopeAllocation struct {
+	EnvelopeID string `json:"envelope_id" db:"envelope_id"`
+	Month      string `json:"month" db:"month"` // YYYY-MM
+	Amount     string `json:"amount" db:"amount"`
+}
+
+// AllocationRequest represents the request body for setting a monthly allocation
+type AllocationRequest struct {
+	Amount string `json:"amount" binding:"required"`
+}
+
+// Income is the money available to allocate in one month and currency
+type Income struct {
+	Month    string `json:"month" db:"month"`
+	Currency string `json:"currency" db:"currency"`
+	Amount   string `json:"amount" db:"amount"`
+}
+
+// IncomeRequest records a month's income and splits it across envelopes
+type IncomeRequest struct {
+	Month    string        `json:"month" binding:"required"`
+	Currency string        `json:"currency"` // Optional; defaults to the configured currency
+	Amount   string        `json:"amount" binding:"required"`
+	Split    []IncomeSplit `json:"split"`
+}
+
+// IncomeSplit assigns part of an income to an envelope
+type IncomeSplit struct {
+	EnvelopeID string `json:"envelope_id" binding:"required"`
+	Amount     string `json:"amount" binding:"required"`
+}
+
+// EnvelopeTransfer is the audit record of money moved between two envelopes
+type EnvelopeTransfer struct {
+	ID             string    `json:"id" db:"id"`
+	FromEnvelopeID string    `json:"from_envelope_id" db:"from_envelope_id"`
+	ToEnvelopeID   string    `json:"to_envelope_id" db:"to_envelope_id"`
+	Month          string    `json:"month" db:"month"`
+	Amount         string    `json:"amount" db:"amount"`
+	Currency       string    `json:"currency" db:"currency"`
+	Note           string    `json:"note" db:"note"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// TransferRequest represents the request body for moving money between envelopes
+type TransferRequest struct {
+	FromEnvelopeID string `json:"from_envelope_id" binding:"required"`
+	ToEnvelopeID   string `json:"to_envelope_id" binding:"required"`
+	Month          string `json:"month" binding:"required"`
+	Amount         string `json:"amount" binding:"required"`
+	Note           string `json:"note"`
+}
+
+// EnvelopeBalance is the movement of one envelope over one month.
+// Closing = Opening + Allocated + TransfersIn - TransfersOut - Spent.
+type EnvelopeBalance struct {
+	EnvelopeID   string `json:"envelope_id"`
+	Category     string `json:"category"`
+	Currency     string `json:"currency"`
+	Opening      string `json:"opening"`
+	Allocated    string `json:"allocated"`
+	TransfersIn  string `json:"transfers_in"`
+	TransfersOut string `json:"transfers_out"`
+	Spent        string `json:"spent"`
+	Closing      string `json:"closing"`
+}
+
+// EnvelopePeriod is the state of every envelope in one month. Income, allocations
+// and the unassigned remainder are keyed by currency.
+type EnvelopePeriod struct {
+	Month      string            `json:"month"`
+	Income     map[string]string `json:"income"`
+	Allocated  map[string]string `json:"allocated"`
+	Unassigned map[string]string `json:"unassigned"` // Income not yet allocated; negative when over-allocated
+	Envelopes  []EnvelopeBalance `json:"envelopes"`
+}
+
+// EnvelopeReport lists envelope periods from one month to another
+type EnvelopeReport struct {
+	From    string           `json:"from"`
+	To      string           `json:"to"`
+	Periods []EnvelopePeriod `json:"periods"`
+}
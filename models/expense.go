@@ -4,18 +4,51 @@ import "time"
 
 // Expense represents an expense entry
 type Expense struct {
-	ID          string    `json:"id" db:"id"`
-	Amount      string    `json:"amount" db:"amount"`      // Decimal as string for precision
-	Category    string    `json:"category" db:"category"`
-	Description string    `json:"description" db:"description"`
-	Date        string    `json:"date" db:"date"`          // ISO date format: YYYY-MM-DD
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	ID          string     `json:"id" db:"id"`
+	Amount      string     `json:"amount" db:"amount"`     // Decimal as string for precision
+	Currency    string     `json:"currency" db:"currency"` // ISO 4217 code, e.g. INR
+	Category    string     `json:"category" db:"category"`
+	Description string     `json:"description" db:"description"`
+	Date        string     `json:"date" db:"date"` // ISO date format: YYYY-MM-DD
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`     // Set while the expense is in the trash
+	RecurringID *string    `json:"recurring_id,omitempty" db:"recurring_id"` // Template this expense was generated from
+	Tags        []string   `json:"tags" db:"-"`                              // Lower-case labels, sorted; stored in expense_tags
+
+	// Populated only when a conversion currency is requested; not stored
+	ConvertedAmount   *string `json:"converted_amount,omitempty" db:"-"`
+	ConvertedCurrency string  `json:"converted_currency,omitempty" db:"-"`
+
+	// Populated by full-text searches (q); not stored. Snippet is the matching part of
+	// the description with each match wrapped in <mark></mark>; a higher Relevance
+	// is a better match.
+	Relevance *float64 `json:"relevance,omitempty" db:"-"`
+	Snippet   string   `json:"snippet,omitempty" db:"-"`
+
+	// Set on creation when the expense pushed budgets over their limit; not stored
+	ExceededBudgets []BudgetStatus `json:"exceeded_budgets,omitempty" db:"-"`
 }
 
 // CreateExpenseRequest represents the request body for creating an expense
 type CreateExpenseRequest struct {
-	Amount      string `json:"amount" binding:"required"`
-	Category    string `json:"category" binding:"required"`
-	Description string `json:"description" binding:"required"`
-	Date        string `json:"date" binding:"required"`
+	Amount      string   `json:"amount" binding:"required"`
+	Currency    string   `json:"currency"` // Optional; defaults to the configured currency
+	Category    string   `json:"category" binding:"required"`
+	Description string   `json:"description" binding:"required"`
+	Date        string   `json:"date" binding:"required"`
+	Tags        []string `json:"tags"` // Optional labels such as business or reimbursable
+
+	// Set by the recurring expense scheduler; never read from request bodies
+	RecurringID string `json:"-"`
+}
+
+// UpdateExpenseRequest represents the request body for a partial update (PATCH).
+// Fields left nil keep their current value.
+type UpdateExpenseRequest struct {
+	Amount      *string   `json:"amount"`
+	Currency    *string   `json:"currency"`
+	Category    *string   `json:"category"`
+	Description *string   `json:"description"`
+	Date        *string   `json:"date"`
+	Tags        *[]string `json:"tags"` // Replaces every tag; [] removes them all
 }
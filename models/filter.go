@@ -0,0 +1,23 @@
+package models
+
+// ExpenseFilter narrows an expense listing. Zero-valued fields match every expense
+// and all set fields are combined with AND.
+type ExpenseFilter struct {
+	Categories           []string `json:"categories,omitempty"`            // Match any of these categories
+	ExcludeCategories    []string `json:"exclude_categories,omitempty"`    // Drop these categories
+	IncludeSubcategories bool     `json:"include_subcategories,omitempty"` // Apply both category lists to subcategories too
+	Tags                 []string `json:"tags,omitempty"`                  // Match expenses carrying these tags
+	TagMode              string   `json:"tag_mode,omitempty"`              // any (default) or all of Tags
+	Currency             string   `json:"currency,omitempty"`              // ISO 4217 code
+	From                 string   `json:"from,omitempty"`                  // Inclusive start date (YYYY-MM-DD)
+	To                   string   `json:"to,omitempty"`                    // Inclusive end date (YYYY-MM-DD)
+	MinAmount            string   `json:"min_amount,omitempty"`            // Inclusive, in the expense's own currency
+	MaxAmount            string   `json:"max_amount,omitempty"`            // Inclusive, in the expense's own currency
+	Query                string   `json:"q,omitempty"`                     // Case-insensitive substring of the description
+}
+
+// Tag filter modes
+const (
+	TagModeAny = "any"
+	TagModeAll = "all"
+)
@@ -0,0 +1,16 @@
+package models
+
+// FXRate is the number of units of Currency that one unit of Base bought on Date
+type FXRate struct {
+	Base     string `json:"base" db:"base"`         // ISO 4217 code of the reference currency, e.g. EUR for ECB data
+	Currency string `json:"currency" db:"currency"` // ISO 4217 code of the quoted currency
+	Date     string `json:"date" db:"date"`         // ISO date format: YYYY-MM-DD
+	Rate     string `json:"rate" db:"rate"`         // Decimal as string for precision
+}
+
+// FXImportResult summarises an FX rate import
+type FXImportResult struct {
+	Imported int    `json:"imported"`
+	From     string `json:"from,omitempty"` // Earliest date in the file
+	To       string `json:"to,omitempty"`   // Latest date in the file
+}
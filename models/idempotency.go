@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// IdempotencyRecord stores the outcome of a request made with an Idempotency-Key header
+type IdempotencyRecord struct {
+	Key          string    `json:"key" db:"key"`
+	RequestHash  string    `json:"request_hash" db:"request_hash"`
+	StatusCode   int       `json:"status_code" db:"status_code"` // 0 while the original request is still in flight
+	ResponseBody string    `json:"response_body" db:"response_body"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// Completed reports whether the original request has finished and its response was stored
+func (r *IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
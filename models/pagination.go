@@ -0,0 +1,44 @@
+package models
+
+// SortKey orders expenses by one field
+type SortKey struct {
+	Field string `json:"field"`
+	Desc  bool   `json:"desc"`
+}
+
+// ExpenseCursor identifies a position in an expense list: the sort it was issued
+// for and the sort-key values of the last row on the previous page
+type ExpenseCursor struct {
+	Sort   string   `json:"s"`
+	Values []string `json:"v"`
+}
+
+// ExpensePage is one page of a paginated expense list
+type ExpensePage struct {
+	Items      []Expense `json:"items"`
+	NextCursor *string   `json:"next_cursor"` // Null on the last page
+
+	// Set when totals were requested; its fields are inlined in the JSON
+	*ExpenseTotals
+}
+
+// ExpenseTotals aggregates the whole filtered set, independent of pagination
+type ExpenseTotals struct {
+	Count   int               `json:"count"`
+	Total   map[string]string `json:"total"` // Exact sum per ISO 4217 currency code
+	Filters AppliedFilters    `json:"filters"`
+}
+
+// AppliedFilters echoes the normalised filters and sort a list was produced with
+type AppliedFilters struct {
+	ExpenseFilter
+	Sort string `json:"sort,omitempty"`
+}
+
+// AmountEntry is the minimal projection of an expense needed for aggregation
+type AmountEntry struct {
+	Amount   string
+	Currency string
+	Date     string
+	Category string
+}
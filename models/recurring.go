@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Recurrence frequencies
+const (
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+	FrequencyYearly  = "yearly"
+)
+
+// LastDayOfMonth as a MonthDay schedules a monthly expense on the last day of each month
+const LastDayOfMonth = -1
+
+// RecurringExpense is a template from which expenses are generated on a schedule
+type RecurringExpense struct {
+	ID          string `json:"id" db:"id"`
+	Amount      string `json:"amount" db:"amount"`
+	Currency    string `json:"currency" db:"currency"`
+	Category    string `json:"category" db:"category"`
+	Description string `json:"description" db:"description"`
+
+	// Schedule, modelled on RFC 5545 RRULE: FREQ, INTERVAL, BYMONTHDAY and BYDAY
+	Frequency     string `json:"frequency" db:"frequency"`                     // daily, weekly, monthly or yearly
+	Interval      int    `json:"interval" db:"repeat_interval"`                // Every N days, weeks, months or years
+	MonthDay      int    `json:"month_day,omitempty" db:"month_day"`           // Monthly: day 1-31 (clamped to short months) or -1 for the last day
+	Weekday       string `json:"weekday,omitempty" db:"weekday"`               // Weekly: day of the week, e.g. monday
+	StartDate     string `json:"start_date" db:"start_date"`                   // First possible occurrence (YYYY-MM-DD)
+	EndDate       string `json:"end_date,omitempty" db:"end_date"`             // Last possible occurrence; empty for no end
+	NextDate      string `json:"next_date,omitempty" db:"next_date"`           // Next occurrence to generate; empty once the schedule has ended
+	LastGenerated string `json:"last_generated,omitempty" db:"last_generated"` // Date of the last generated occurrence
+
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// RecurringExpenseRequest represents the request body for creating or replacing a
+// recurring expense
+type RecurringExpenseRequest struct {
+	Amount      string `json:"amount" binding:"required"`
+	Currency    string `json:"currency"` // Optional; defaults to the configured currency
+	Category    string `json:"category" binding:"required"`
+	Description string `json:"description" binding:"required"`
+	Frequency   string `json:"frequency" binding:"required"`
+	Interval    int    `json:"interval"`  // Optional; defaults to 1
+	MonthDay    int    `json:"month_day"` // Optional; defaults to the day of start_date
+	Weekday     string `json:"weekday"`   // Optional; defaults to the weekday of start_date
+	StartDate   string `json:"start_date" binding:"required"`
+	EndDate     string `json:"end_date"`
+}
@@ -0,0 +1,99 @@
+package models
+
+// CategorySummary is the spend per category over a filtered set of expenses
+type CategorySummary struct {
+	Count      int               `json:"count"`
+	Total      map[string]string `json:"total"` // Exact sum per ISO 4217 currency code
+	Categories []CategoryTotal   `json:"categories"`
+	Filters    ExpenseFilter     `json:"filters"`
+
+	// Set when a conversion currency was requested. ConvertedTotal is left out when
+	// any matching expense has no rate; Unconverted counts those expenses.
+	ConvertedCurrency string  `json:"converted_currency,omitempty"`
+	ConvertedTotal    *string `json:"converted_total,omitempty"`
+	Unconverted       int     `json:"unconverted,omitempty"`
+}
+
+// CategoryTotal is one category's share of a summary. Amounts in different currencies
+// are never added together, so totals and shares are keyed by currency. Count, Total
+// and Share cover the expenses filed directly under the category.
+type CategoryTotal struct {
+	Category string            `json:"category"`
+	Parent   string            `json:"parent,omitempty"` // Name of the parent category
+	Count    int               `json:"count"`
+	Total    map[string]string `json:"total"`
+	Share    map[string]string `json:"share"`            // Fraction of the summary total in that currency, 0 to 1
+	Rollup   *CategoryRollup   `json:"rollup,omitempty"` // Set when subcategories have spend in the summary
+
+	ConvertedTotal *string `json:"converted_total,omitempty"` // Total in the conversion currency
+}
+
+// CategoryRollup is the spend of a category together with all of its subcategories
+type CategoryRollup struct {
+	Count int               `json:"count"`
+	Total map[string]string `json:"total"`
+	Share map[string]string `json:"share"`
+
+	ConvertedTotal *string `json:"converted_total,omitempty"`
+}
+
+// TagSummary is the spend per tag over a filtered set of expenses. Count and Total
+// cover every matching expense once, tagged or not.
+type TagSummary struct {
+	Count   int               `json:"count"`
+	Total   map[string]string `json:"total"` // Exact sum per ISO 4217 currency code
+	Tags    []TagTotal        `json:"tags"`
+	Filters ExpenseFilter     `json:"filters"`
+
+	// Set when a conversion currency was requested. ConvertedTotal is left out when
+	// any matching expense has no rate; Unconverted counts those expenses.
+	ConvertedCurrency string  `json:"converted_currency,omitempty"`
+	ConvertedTotal    *string `json:"converted_total,omitempty"`
+	Unconverted       int     `json:"unconverted,omitempty"`
+}
+
+// TagTotal is the spend on expenses carrying one tag, keyed by currency
+type TagTotal struct {
+	Tag   string            `json:"tag"`
+	Count int               `json:"count"`
+	Total map[string]string `json:"total"`
+	Share map[string]string `json:"share"` // Fraction of the summary total in that currency, 0 to 1
+
+	ConvertedTotal *string `json:"converted_total,omitempty"` // Total in the conversion currency
+}
+
+// TimeSeries is spending per date bucket over a period, zero-filled so every bucket
+// between from and to is present
+type TimeSeries struct {
+	Interval   string           `json:"interval"`             // day, week, month or year
+	WeekStart  string           `json:"week_start,omitempty"` // First day of a week bucket
+	From       string           `json:"from,omitempty"`
+	To         string           `json:"to,omitempty"`
+	Currencies []string         `json:"currencies"` // Every currency present in the series
+	Points     []TimeBucket     `json:"points"`
+	Categories []CategorySeries `json:"categories,omitempty"` // Set when a per-category breakdown was requested
+	Filters    ExpenseFilter    `json:"filters"`
+
+	// Set when a conversion currency was requested. ConvertedTotal is left out when
+	// any matching expense has no rate; Unconverted counts those expenses.
+	ConvertedCurrency string  `json:"converted_currency,omitempty"`
+	ConvertedTotal    *string `json:"converted_total,omitempty"`
+	Unconverted       int     `json:"unconverted,omitempty"`
+}
+
+// TimeBucket is the spending within one bucket of a time series
+type TimeBucket struct {
+	Label string            `json:"label"` // e.g. 2024-01-15, 2024-W03, 2024-01 or 2024
+	Start string            `json:"start"` // First day of the bucket (YYYY-MM-DD)
+	End   string            `json:"end"`   // Last day of the bucket (YYYY-MM-DD)
+	Count int               `json:"count"`
+	Total map[string]string `json:"total"` // Exact sum per currency, zero for currencies with no spend
+
+	ConvertedTotal *string `json:"converted_total,omitempty"` // Total in the conversion currency
+}
+
+// CategorySeries is the time series of a single category
+type CategorySeries struct {
+	Category string       `json:"category"`
+	Points   []TimeBucket `json:"points"`
+}
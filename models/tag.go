@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Tag is a cross-cutting label on expenses, such as business or trip-goa-2024.
+// Names are stored in lower case and are unique.
+type Tag struct {
+	ID           string    `json:"id" db:"id"`
+	Name         string    `json:"name" db:"name"`
+	ExpenseCount int       `json:"expense_count" db:"-"` // Expenses outside the trash carrying the tag
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// RenameTagRequest represents the request body for renaming a tag
+type RenameTagRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// MergeTagsRequest represents the request body for merging tags. Expenses carrying a
+// source tag carry the target instead, and the sources are deleted.
+type MergeTagsRequest struct {
+	SourceIDs []string `json:"source_ids" binding:"required"`
+	TargetID  string   `json:"target_id" binding:"required"`
+}
+
+// TagMerge is the result of a tag merge
+type TagMerge struct {
+	Tag              *Tag `json:"tag"`
+	ExpensesRetagged int  `json:"expenses_retagged"` // Expenses that gained the target tag
+}
@@ -0,0 +1,37 @@
+package money
+
+import "strings"
+
+// Currency describes an ISO 4217 currency
+type Currency struct {
+	Code       string `json:"code"`
+	MinorUnits int32  `json:"minor_units"` // Digits after the decimal point (JPY 0, USD 2, KWD 3)
+}
+
+// currencies lists the supported ISO 4217 codes and their minor-unit scale
+var currencies = map[string]int32{
+	"AED": 2, "ARS": 2, "AUD": 2, "BDT": 2, "BGN": 2, "BHD": 3, "BRL": 2,
+	"CAD": 2, "CHF": 2, "CLP": 0, "CNY": 2, "COP": 2, "CZK": 2, "DKK": 2,
+	"EGP": 2, "EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2, "IDR": 2, "ILS": 2,
+	"INR": 2, "IQD": 3, "ISK": 0, "JOD": 3, "JPY": 0, "KES": 2, "KRW": 0,
+	"KWD": 3, "LKR": 2, "LYD": 3, "MAD": 2, "MXN": 2, "MYR": 2, "NGN": 2,
+	"NOK": 2, "NPR": 2, "NZD": 2, "OMR": 3, "PHP": 2, "PKR": 2, "PLN": 2,
+	"QAR": 2, "RON": 2, "RUB": 2, "SAR": 2, "SEK": 2, "SGD": 2, "THB": 2,
+	"TND": 3, "TRY": 2, "TWD": 2, "UAH": 2, "UGX": 0, "USD": 2, "VND": 0,
+	"XAF": 0, "XOF": 0, "ZAR": 2,
+}
+
+// NormalizeCurrencyCode trims and upper-cases a currency code
+func NormalizeCurrencyCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// LookupCurrency returns the currency for an ISO 4217 code (case-insensitive)
+func LookupCurrency(code string) (Currency, bool) {
+	code = NormalizeCurrencyCode(code)
+	minorUnits, ok := currencies[code]
+	if !ok {
+		return Currency{}, false
+	}
+	return Currency{Code: code, MinorUnits: minorUnits}, true
+}
@@ -0,0 +1,29 @@
+package money
+
+import "testing"
+
+func TestLookupCurrency(t *testing.T) {
+	tests := []struct {
+		code       string
+		wantCode   string
+		wantMinor  int32
+		wantExists bool
+	}{
+		{"INR", "INR", 2, true},
+		{"jpy", "JPY", 0, true},
+		{" kwd ", "KWD", 3, true},
+		{"XYZ", "", 0, false},
+		{"", "", 0, false},
+	}
+
+	for _, tt := range tests {
+		currency, ok := LookupCurrency(tt.code)
+		if ok != tt.wantExists {
+			t.Errorf("LookupCurrency(%q) ok = %v, want %v", tt.code, ok, tt.wantExists)
+			continue
+		}
+		if currency.Code != tt.wantCode || currency.MinorUnits != tt.wantMinor {
+			t.Errorf("LookupCurrency(%q) = %+v, want %s/%d", tt.code, currency, tt.wantCode, tt.wantMinor)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+// Package money provides exact fixed-point decimal arithmetic for monetary amounts.
+// No floating point is used anywhere: values are stored as an arbitrary-precision
+// integer coefficient and a base-10 scale.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// RoundingMode selects how a value is rounded when digits are dropped
+type RoundingMode int
+
+const (
+	// RoundHalfUp rounds ties away from zero (1.005 -> 1.01, -1.005 -> -1.01)
+	RoundHalfUp RoundingMode = iota
+	// RoundHalfEven rounds ties to the nearest even digit (banker's rounding)
+	RoundHalfEven
+	// RoundDown truncates towards zero
+	RoundDown
+)
+
+// ErrInvalidDecimal is returned when a string is not a plain decimal number
+var ErrInvalidDecimal = errors.New("invalid decimal")
+
+// ErrDivisionByZero is returned when dividing by a zero decimal
+var ErrDivisionByZero = errors.New("division by zero")
+
+// Decimal is an exact decimal number equal to coef / 10^scale.
+// The zero value is 0.
+type Decimal struct {
+	coef  *big.Int
+	scale int32
+}
+
+var (
+	bigZero = big.NewInt(0)
+	bigTen  = big.NewInt(10)
+)
+
+// Zero is the decimal 0
+var Zero = Decimal{}
+
+// NewFromInt returns the decimal value of an integer
+func NewFromInt(value int64) Decimal {
+	return Decimal{coef: big.NewInt(value)}
+}
+
+// New returns coef / 10^scale
+func New(coef int64, scale int32) Decimal {
+	if scale < 0 {
+		return NewFromInt(coef).Mul(Decimal{coef: pow10(-scale)})
+	}
+	return Decimal{coef: big.NewInt(coef), scale: scale}
+}
+
+// Parse parses a plain decimal string such as "100", "-0.50" or "1234.5678".
+// Exponents, NaN, Infinity, thousands separators and surrounding whitespace are rejected.
+func Parse(value string) (Decimal, error) {
+	s := value
+	negative := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+
+	intPart, fracPart, hasPoint := strings.Cut(s, ".")
+	if intPart == "" || (hasPoint && fracPart == "") {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, value)
+	}
+	if !isDigits(intPart) || !isDigits(fracPart) {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, value)
+	}
+
+	coef, ok := new(big.Int).SetString(intPart+fracPart, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, value)
+	}
+	if negative {
+		coef.Neg(coef)
+	}
+
+	return Decimal{coef: coef, scale: int32(len(fracPart))}, nil
+}
+
+// MustParse is like Parse but panics on invalid input. Intended for constants and tests.
+func MustParse(value string) Decimal {
+	d, err := Parse(value)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// isDigits reports whether s consists only of ASCII digits
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// pow10 returns 10^n as a big integer
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+// c returns the coefficient, treating the zero value as 0
+func (d Decimal) c() *big.Int {
+	if d.coef == nil {
+		return bigZero
+	}
+	return d.coef
+}
+
+// Scale returns the number of digits after the decimal point
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// Sign returns -1, 0 or +1
+func (d Decimal) Sign() int {
+	return d.c().Sign()
+}
+
+// IsZero reports whether d == 0
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Neg returns -d
+func (d Decimal) Neg() Decimal {
+	return Decimal{coef: new(big.Int).Neg(d.c()), scale: d.scale}
+}
+
+// Abs returns |d|
+func (d Decimal) Abs() Decimal {
+	return Decimal{coef: new(big.Int).Abs(d.c()), scale: d.scale}
+}
+
+// rescale returns the coefficient of d expressed at a larger or equal scale
+func (d Decimal) rescale(scale int32) *big.Int {
+	if scale == d.scale {
+		return new(big.Int).Set(d.c())
+	}
+	return new(big.Int).Mul(d.c(), pow10(scale-d.scale))
+}
+
+// align returns both coefficients at the larger of the two scales
+func align(a, b Decimal) (*big.Int, *big.Int, int32) {
+	scale := a.scale
+	if b.scale > scale {
+		scale = b.scale
+	}
+	return a.rescale(scale), b.rescale(scale), scale
+}
+
+// Add returns d + other
+func (d Decimal) Add(other Decimal) Decimal {
+	a, b, scale := align(d, other)
+	return Decimal{coef: a.Add(a, b), scale: scale}
+}
+
+// Sub returns d - other
+func (d Decimal) Sub(other Decimal) Decimal {
+	a, b, scale := align(d, other)
+	return Decimal{coef: a.Sub(a, b), scale: scale}
+}
+
+// Mul returns the exact product d * other
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{coef: new(big.Int).Mul(d.c(), other.c()), scale: d.scale + other.scale}
+}
+
+// MulRatio returns d * num / den rounded to the given scale
+func (d Decimal) MulRatio(num, den Decimal, scale int32, mode RoundingMode) (Decimal, error) {
+	if den.IsZero() {
+		return Decimal{}, ErrDivisionByZero
+	}
+
+	// d*num has scale d.scale+num.scale; dividing by den.coef/10^den.scale and
+	// targeting the requested scale gives:
+	//   coef = d.coef*num.coef*10^(den.scale+scale) / (den.coef*10^(d.scale+num.scale))
+	numerator := new(big.Int).Mul(d.c(), num.c())
+	denominator := new(big.Int).Set(den.c())
+
+	shift := int64(den.scale) + int64(scale) - int64(d.scale) - int64(num.scale)
+	if shift >= 0 {
+		numerator.Mul(numerator, pow10(int32(shift)))
+	} else {
+		denominator.Mul(denominator, pow10(int32(-shift)))
+	}
+
+	return Decimal{coef: divRound(numerator, denominator, mode), scale: scale}, nil
+}
+
+// Quo returns d / other rounded to the given scale
+func (d Decimal) Quo(other Decimal, scale int32, mode RoundingMode) (Decimal, error) {
+	return d.MulRatio(NewFromInt(1), other, scale, mode)
+}
+
+// Round returns d rounded to the given number of fractional digits.
+// Rounding to a larger scale pads with zeros and is always exact.
+func (d Decimal) Round(scale int32, mode RoundingMode) Decimal {
+	if scale < 0 {
+		scale = 0
+	}
+	if scale >= d.scale {
+		return Decimal{coef: d.rescale(scale), scale: scale}
+	}
+	return Decimal{coef: divRound(d.c(), pow10(d.scale-scale), mode), scale: scale}
+}
+
+// divRound divides num by den and rounds the quotient using mode
+func divRound(num, den *big.Int, mode RoundingMode) *big.Int {
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() == 0 || mode == RoundDown {
+		return quo
+	}
+
+	// Compare twice the remainder with the divisor to find which side of the half we are on
+	twiceRem := new(big.Int).Abs(rem)
+	twiceRem.Lsh(twiceRem, 1)
+	cmp := twiceRem.Cmp(new(big.Int).Abs(den))
+
+	roundAway := false
+	switch mode {
+	case RoundHalfUp:
+		roundAway = cmp >= 0
+	case RoundHalfEven:
+		roundAway = cmp > 0 || (cmp == 0 && quo.Bit(0) == 1)
+	}
+
+	if roundAway {
+		if num.Sign()*den.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+	return quo
+}
+
+// Cmp compares d and other, returning -1, 0 or +1
+func (d Decimal) Cmp(other Decimal) int {
+	a, b, _ := align(d, other)
+	return a.Cmp(b)
+}
+
+// Equal reports whether d and other have the same numeric value
+func (d Decimal) Equal(other Decimal) bool {
+	return d.Cmp(other) == 0
+}
+
+// Canonical returns d with trailing fractional zeros removed (1.500 -> 1.5, 2.00 -> 2)
+func (d Decimal) Canonical() Decimal {
+	coef := new(big.Int).Set(d.c())
+	scale := d.scale
+	rem := new(big.Int)
+	for scale > 0 {
+		quo, r := new(big.Int).QuoRem(coef, bigTen, rem)
+		if r.Sign() != 0 {
+			break
+		}
+		coef = quo
+		scale--
+	}
+	return Decimal{coef: coef, scale: scale}
+}
+
+// String formats d with exactly Scale() fractional digits
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(d.c()).String()
+	if d.scale > 0 {
+		if pad := int(d.scale) + 1 - len(digits); pad > 0 {
+			digits = strings.Repeat("0", pad) + digits
+		}
+		point := len(digits) - int(d.scale)
+		digits = digits[:point] + "." + digits[point:]
+	}
+	if d.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// StringFixed formats d rounded half-up to exactly scale fractional digits
+func (d Decimal) StringFixed(scale int32) string {
+	return d.Round(scale, RoundHalfUp).String()
+}
+
+// Sum returns the exact sum of values
+func Sum(values ...Decimal) Decimal {
+	total := Zero
+	for _, v := range values {
+		total = total.Add(v)
+	}
+	return total
+}
+
+// MarshalJSON encodes d as a JSON string to avoid float conversion by clients
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + d.String() + `"`), nil
+}
+
+// UnmarshalJSON accepts a JSON string or number literal
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
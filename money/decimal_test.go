@@ -0,0 +1,152 @@
+package money
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"integer", "100", "100", false},
+		{"two decimals", "100.50", "100.50", false},
+		{"leading zeros", "007.5", "7.5", false},
+		{"negative", "-0.25", "-0.25", false},
+		{"explicit plus", "+3", "3", false},
+		{"long fraction", "0.123456789012345678901234567890", "0.123456789012345678901234567890", false},
+		{"empty", "", "", true},
+		{"exponent", "1e5", "", true},
+		{"NaN", "NaN", "", true},
+		{"Inf", "Inf", "", true},
+		{"trailing point", "1.", "", true},
+		{"leading point", ".5", "", true},
+		{"whitespace", " 1", "", true},
+		{"thousands separator", "1,000", "", true},
+		{"sign only", "-", "", true},
+		{"double sign", "--1", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && got.String() != tt.want {
+				t.Errorf("Parse(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	a := MustParse("0.1")
+	b := MustParse("0.2")
+
+	if got := a.Add(b).String(); got != "0.3" {
+		t.Errorf("0.1 + 0.2 = %s, want 0.3", got)
+	}
+	if got := a.Sub(MustParse("1.25")).String(); got != "-1.15" {
+		t.Errorf("0.1 - 1.25 = %s, want -1.15", got)
+	}
+	if got := MustParse("1.5").Mul(MustParse("-2.25")).String(); got != "-3.375" {
+		t.Errorf("1.5 * -2.25 = %s, want -3.375", got)
+	}
+	if got := Sum(MustParse("100.50"), MustParse("50"), MustParse("75.25")).String(); got != "225.75" {
+		t.Errorf("Sum() = %s, want 225.75", got)
+	}
+	if got := Sum().String(); got != "0" {
+		t.Errorf("Sum() of nothing = %s, want 0", got)
+	}
+	if MustParse("2.50").Cmp(MustParse("2.5")) != 0 {
+		t.Errorf("2.50 and 2.5 should compare equal")
+	}
+	if MustParse("-1").Cmp(Zero) != -1 {
+		t.Errorf("-1 should compare below zero")
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		input string
+		scale int32
+		mode  RoundingMode
+		want  string
+	}{
+		{"1.005", 2, RoundHalfUp, "1.01"},
+		{"1.005", 2, RoundHalfEven, "1.00"},
+		{"1.015", 2, RoundHalfEven, "1.02"},
+		{"-1.005", 2, RoundHalfUp, "-1.01"},
+		{"-1.005", 2, RoundHalfEven, "-1.00"},
+		{"2.5", 0, RoundHalfEven, "2"},
+		{"3.5", 0, RoundHalfEven, "4"},
+		{"2.5", 0, RoundHalfUp, "3"},
+		{"1.999", 2, RoundDown, "1.99"},
+		{"-1.999", 2, RoundDown, "-1.99"},
+		{"1.5", 3, RoundHalfUp, "1.500"},
+		{"0.004", 2, RoundHalfUp, "0.00"},
+	}
+
+	for _, tt := range tests {
+		if got := MustParse(tt.input).Round(tt.scale, tt.mode).String(); got != tt.want {
+			t.Errorf("Round(%s, %d, %d) = %s, want %s", tt.input, tt.scale, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestMulRatio(t *testing.T) {
+	// Splitting 100.00 three ways
+	got, err := MustParse("100.00").MulRatio(NewFromInt(1), NewFromInt(3), 2, RoundHalfEven)
+	if err != nil || got.String() != "33.33" {
+		t.Errorf("100.00 * 1/3 = %s, %v, want 33.33", got, err)
+	}
+
+	// Share of total as a percentage
+	got, err = MustParse("25.50").MulRatio(NewFromInt(100), MustParse("102.00"), 2, RoundHalfUp)
+	if err != nil || got.String() != "25.00" {
+		t.Errorf("25.50 * 100/102.00 = %s, %v, want 25.00", got, err)
+	}
+
+	// Currency conversion with a high-precision rate
+	got, err = MustParse("10.00").MulRatio(MustParse("1.0856"), NewFromInt(1), 2, RoundHalfUp)
+	if err != nil || got.String() != "10.86" {
+		t.Errorf("10.00 * 1.0856 = %s, %v, want 10.86", got, err)
+	}
+
+	if _, err := MustParse("1").MulRatio(NewFromInt(1), Zero, 2, RoundHalfUp); err != ErrDivisionByZero {
+		t.Errorf("MulRatio() by zero error = %v, want ErrDivisionByZero", err)
+	}
+
+	got, err = MustParse("-10").Quo(NewFromInt(4), 0, RoundHalfEven)
+	if err != nil || got.String() != "-2" {
+		t.Errorf("-10 / 4 = %s, %v, want -2", got, err)
+	}
+}
+
+func TestCanonicalAndJSON(t *testing.T) {
+	if got := MustParse("1.500").Canonical().String(); got != "1.5" {
+		t.Errorf("Canonical(1.500) = %s, want 1.5", got)
+	}
+	if got := MustParse("20.00").Canonical().String(); got != "20" {
+		t.Errorf("Canonical(20.00) = %s, want 20", got)
+	}
+	if got := MustParse("0.05").String(); got != "0.05" {
+		t.Errorf("String(0.05) = %s", got)
+	}
+	if got := MustParse("1").StringFixed(2); got != "1.00" {
+		t.Errorf("StringFixed(1, 2) = %s, want 1.00", got)
+	}
+
+	data, _ := MustParse("12.30").MarshalJSON()
+	if string(data) != `"12.30"` {
+		t.Errorf("MarshalJSON() = %s, want \"12.30\"", data)
+	}
+	var d Decimal
+	if err := d.UnmarshalJSON([]byte(`"7.25"`)); err != nil || d.String() != "7.25" {
+		t.Errorf("UnmarshalJSON() = %s, %v", d, err)
+	}
+	if Zero.String() != "0" || !Zero.IsZero() {
+		t.Errorf("zero value should format as 0")
+	}
+}
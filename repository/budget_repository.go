@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fenmo-ai-assignment/models"
+)
+
+// ErrBudgetNotFound is returned when no budget matches the given ID
+var ErrBudgetNotFound = errors.New("budget not found")
+
+// ErrBudgetExists is returned when a category already has a budget for the same
+// period and currency
+var ErrBudgetExists = errors.New("a budget for this category, period and currency already exists")
+
+// budgetColumns is the column list shared by every budget SELECT
+const budgetColumns = `id, category, period, limit_amount, currency, created_at, updated_at`
+
+// BudgetRepository handles database operations for budgets
+type BudgetRepository struct {
+	db *sql.DB
+}
+
+// NewBudgetRepository creates a new budget repository
+func NewBudgetRepository(db *sql.DB) *BudgetRepository {
+	return &BudgetRepository{db: db}
+}
+
+// Create inserts a new budget
+func (r *BudgetRepository) Create(budget *models.Budget) error {
+	_, err := r.db.Exec(
+		`INSERT INTO budgets (`+budgetColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		budget.ID,
+		budget.Category,
+		budget.Period,
+		budget.Limit,
+		budget.Currency,
+		budget.CreatedAt.UTC(),
+		budget.UpdatedAt.UTC(),
+	)
+	return translateBudgetError(err)
+}
+
+// GetByID retrieves a single budget by its ID
+func (r *BudgetRepository) GetByID(id string) (*models.Budget, error) {
+	budgets, err := r.queryBudgets(`SELECT `+budgetColumns+` FROM budgets WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(budgets) == 0 {
+		return nil, ErrBudgetNotFound
+	}
+	return &budgets[0], nil
+}
+
+// List retrieves every budget ordered by category, period and currency
+func (r *BudgetRepository) List() ([]models.Budget, error) {
+	return r.queryBudgets(`SELECT ` + budgetColumns + ` FROM budgets ORDER BY category, period, currency`)
+}
+
+// ListForMonth retrieves the monthly budgets and the budgets set for one specific month
+func (r *BudgetRepository) ListForMonth(month string) ([]models.Budget, error) {
+	return r.queryBudgets(
+		`SELECT `+budgetColumns+` FROM budgets WHERE period IN (?, ?) ORDER BY category, currency, period`,
+		models.BudgetPeriodMonthly,
+		month,
+	)
+}
+
+// Update overwrites the mutable fields of an existing budget
+func (r *BudgetRepository) Update(budget *models.Budget) error {
+	result, err := r.db.Exec(
+		`UPDATE budgets SET category = ?, period = ?, limit_amount = ?, currency = ?, updated_at = ? WHERE id = ?`,
+		budget.Category,
+		budget.Period,
+		budget.Limit,
+		budget.Currency,
+		budget.UpdatedAt.UTC(),
+		budget.ID,
+	)
+	if err != nil {
+		return translateBudgetError(err)
+	}
+	return checkBudgetAffected(result)
+}
+
+// Delete removes a budget
+func (r *BudgetRepository) Delete(id string) error {
+	result, err := r.db.Exec(`DELETE FROM budgets WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	return checkBudgetAffected(result)
+}
+
+// queryBudgets executes a query and returns budgets
+func (r *BudgetRepository) queryBudgets(query string, args ...interface{}) ([]models.Budget, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var budgets []models.Budget
+	for rows.Next() {
+		var budget models.Budget
+		var createdAtStr, updatedAtStr string
+		if err := rows.Scan(
+			&budget.ID,
+			&budget.Category,
+			&budget.Period,
+			&budget.Limit,
+			&budget.Currency,
+			&createdAtStr,
+			&updatedAtStr,
+		); err != nil {
+			return nil, err
+		}
+
+		budget.CreatedAt, _ = parseTimestamp(createdAtStr)
+		budget.UpdatedAt, _ = parseTimestamp(updatedAtStr)
+
+		budgets = append(budgets, budget)
+	}
+
+	return budgets, rows.Err()
+}
+
+// checkBudgetAffected maps an update that touched no rows to ErrBudgetNotFound
+func checkBudgetAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrBudgetNotFound
+	}
+	return nil
+}
+
+// translateBudgetError maps a unique constraint violation to ErrBudgetExists
+func translateBudgetError(err error) error {
+	if isUniqueViolation(err) {
+		return ErrBudgetExists
+	}
+	return err
+}
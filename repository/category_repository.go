@@ -0,0 +1,460 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fenmo-ai-assignment/models"
+	"fmt"
+)
+
+// ErrCategoryNotFound is returned when no category matches the given ID or name
+var ErrCategoryNotFound = errors.New("category not found")
+
+// ErrCategoryExists is returned when a category with the same name, ignoring case, exists
+var ErrCategoryExists = errors.New("a category with this name already exists")
+
+// ErrCategoryRewriteConflict is returned when rewriting a category name would give two
+// budgets or envelopes the same category, period and currency
+var ErrCategoryRewriteConflict = errors.New("the categories have budgets or envelopes for the same period and currency")
+
+// ErrExpenseStoreDiverged is returned when an expense store outside the database has
+// moved its expenses to the new category name but the category change then failed to
+// commit, leaving those expenses under a name the categories no longer agree with
+var ErrExpenseStoreDiverged = errors.New("expenses were moved but the category change was not saved")
+
+// categoryColumns is the column list shared by every category SELECT
+const categoryColumns = `id, name, parent_id, colour, icon, archived, created_at`
+
+// CategoryRepository handles database operations for categories
+type CategoryRepository struct {
+	db       *sql.DB
+	expenses ExpenseStore
+}
+
+// NewCategoryRepository creates a new category repository. expenses is the store
+// holding the expenses when they live outside db. It cannot join a transaction on db,
+// so when categories are renamed, merged or deleted it is rewritten once every change
+// to db has been made, just before they are committed. With nil the expenses table in
+// db is rewritten in the same transaction as the categories.
+func NewCategoryRepository(db *sql.DB, expenses ExpenseStore) *CategoryRepository {
+	return &CategoryRepository{db: db, expenses: expenses}
+}
+
+// Create inserts a new category
+func (r *CategoryRepository) Create(category *models.Category) error {
+	_, err := r.db.Exec(
+		`INSERT INTO categories (`+categoryColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		category.ID,
+		category.Name,
+		category.ParentID,
+		category.Colour,
+		category.Icon,
+		category.Archived,
+		category.CreatedAt.UTC(),
+	)
+	if isUniqueViolation(err) {
+		return ErrCategoryExists
+	}
+	return err
+}
+
+// GetByID retrieves a single category by its ID
+func (r *CategoryRepository) GetByID(id string) (*models.Category, error) {
+	return r.getOne(`SELECT `+categoryColumns+` FROM categories WHERE id = ?`, id)
+}
+
+// GetByName retrieves a category by name, ignoring case
+func (r *CategoryRepository) GetByName(name string) (*models.Category, error) {
+	return r.getOne(`SELECT `+categoryColumns+` FROM categories WHERE name = ? COLLATE NOCASE`, name)
+}
+
+// List retrieves categories ordered by name, leaving out archived ones unless asked
+func (r *CategoryRepository) List(includeArchived bool) ([]models.Category, error) {
+	query := `SELECT ` + categoryColumns + ` FROM categories`
+	if !includeArchived {
+		query += ` WHERE archived = 0`
+	}
+	return r.queryCategories(query + ` ORDER BY name COLLATE NOCASE`)
+}
+
+// Update overwrites the mutable fields of an existing category
+func (r *CategoryRepository) Update(category *models.Category) error {
+	result, err := r.db.Exec(
+		`UPDATE categories SET name = ?, parent_id = ?, colour = ?, icon = ?, archived = ? WHERE id = ?`,
+		category.Name,
+		category.ParentID,
+		category.Colour,
+		category.Icon,
+		category.Archived,
+		category.ID,
+	)
+	if isUniqueViolation(err) {
+		return ErrCategoryExists
+	}
+	if err != nil {
+		return err
+	}
+	return checkCategoryAffected(result)
+}
+
+// Delete removes a category. Its subcategories are moved under newParentID (nil makes
+// them top-level), and when reassignTo is set its expenses, including those in the
+// trash, recurring templates, budgets and envelopes are moved to that category name.
+// All changes to db happen in one transaction.
+func (r *CategoryRepository) Delete(id string, newParentID *string, reassignTo string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var name string
+	if err := tx.QueryRow(`SELECT name FROM categories WHERE id = ?`, id).Scan(&name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCategoryNotFound
+		}
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE categories SET parent_id = ? WHERE parent_id = ?`, newParentID, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM categories WHERE id = ?`, id); err != nil {
+		return err
+	}
+	if reassignTo != "" {
+		var moved models.CategoryRowsChanged
+		if err := rewriteCategory(tx, name, reassignTo, &moved); err != nil {
+			return err
+		}
+		if err := r.moveExpenses(tx, []string{name}, reassignTo, &moved); err != nil {
+			return err
+		}
+		return r.commit(tx, []string{name}, reassignTo)
+	}
+
+	return tx.Commit()
+}
+
+// Rename changes a category's name and rewrites every expense, recurring template,
+// budget and envelope filed under the old name, recording audit in the same
+// transaction. The row counts are filled into audit.
+func (r *CategoryRepository) Rename(category *models.Category, oldName string, audit *models.CategoryAuditEntry) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE categories SET name = ? WHERE id = ?`, category.Name, category.ID)
+	if isUniqueViolation(err) {
+		return ErrCategoryExists
+	}
+	if err != nil {
+		return err
+	}
+	if err := checkCategoryAffected(result); err != nil {
+		return err
+	}
+
+	if err := rewriteCategory(tx, oldName, category.Name, &audit.RowsChanged); err != nil {
+		return err
+	}
+	if err := r.moveExpenses(tx, []string{oldName}, category.Name, &audit.RowsChanged); err != nil {
+		return err
+	}
+	if err := insertCategoryAudit(tx, audit); err != nil {
+		return err
+	}
+	return r.commit(tx, []string{oldName}, category.Name)
+}
+
+// Merge folds the source categories into the target: every expense, recurring
+// template, budget and envelope is rewritten to the target's name, subcategories move
+// under the target, and the sources are deleted. audit is recorded in the same
+// transaction and its row counts filled in.
+func (r *CategoryRepository) Merge(sources []models.Category, target *models.Category, audit *models.CategoryAuditEntry) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, source := range sources {
+		if err := rewriteCategory(tx, source.Name, target.Name, &audit.RowsChanged); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE categories SET parent_id = ? WHERE parent_id = ?`, target.ID, source.ID); err != nil {
+			return err
+		}
+		result, err := tx.Exec(`DELETE FROM categories WHERE id = ?`, source.ID)
+		if err != nil {
+			return err
+		}
+		if err := checkCategoryAffected(result); err != nil {
+			return err
+		}
+	}
+
+	names := make([]string, len(sources))
+	for i, source := range sources {
+		names[i] = source.Name
+	}
+	if err := r.moveExpenses(tx, names, target.Name, &audit.RowsChanged); err != nil {
+		return err
+	}
+	if err := insertCategoryAudit(tx, audit); err != nil {
+		return err
+	}
+	return r.commit(tx, names, target.Name)
+}
+
+// ListAudit retrieves the rename and merge history, newest first
+func (r *CategoryRepository) ListAudit() ([]models.CategoryAuditEntry, error) {
+	rows, err := r.db.Query(`
+		SELECT id, action, category_id, category_name, previous_names,
+			expenses_updated, recurring_updated, budgets_updated, envelopes_updated, created_at
+		FROM category_audit ORDER BY created_at DESC, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.CategoryAuditEntry
+	for rows.Next() {
+		var entry models.CategoryAuditEntry
+		var previousNames, createdAtStr string
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.Action,
+			&entry.CategoryID,
+			&entry.CategoryName,
+			&previousNames,
+			&entry.RowsChanged.Expenses,
+			&entry.RowsChanged.Recurring,
+			&entry.RowsChanged.Budgets,
+			&entry.RowsChanged.Envelopes,
+			&createdAtStr,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(previousNames), &entry.PreviousNames); err != nil {
+			return nil, err
+		}
+		entry.CreatedAt, _ = parseTimestamp(createdAtStr)
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// rewriteCategory moves every recurring template, budget and envelope filed under one
+// category name to another, adding the number of rows changed per table to counts.
+// Expenses are moved by moveExpenses.
+func rewriteCategory(tx *sql.Tx, from, to string, counts *models.CategoryRowsChanged) error {
+	for _, table := range []struct {
+		name  string
+		count *int
+	}{
+		{"recurring_expenses", &counts.Recurring},
+		{"budgets", &counts.Budgets},
+		{"envelopes", &counts.Envelopes},
+	} {
+		result, err := tx.Exec(`UPDATE `+table.name+` SET category = ? WHERE category = ?`, to, from)
+		if isUniqueViolation(err) {
+			return ErrCategoryRewriteConflict
+		}
+		if err != nil {
+			return err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		*table.count += int(affected)
+	}
+	return nil
+}
+
+// moveExpenses adds to counts the expenses, including those in the trash, filed under
+// the from names. Expenses in db are moved to category to within tx. Those in an
+// external store are only counted here, and are moved by commit.
+func (r *CategoryRepository) moveExpenses(tx *sql.Tx, from []string, to string, counts *models.CategoryRowsChanged) error {
+	if r.expenses != nil {
+		for _, name := range from {
+			count, err := r.expenses.CountByCategory(name)
+			if err != nil {
+				return err
+			}
+			counts.Expenses += count
+		}
+		return nil
+	}
+
+	args := append([]interface{}{to}, stringArgs(from)...)
+	result, err := tx.Exec(`UPDATE expenses SET category = ? WHERE category IN (`+placeholders(len(from))+`)`, args...)
+	if err != nil {
+		return err
+	}
+	moved, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	counts.Expenses += int(moved)
+	return nil
+}
+
+// commit commits tx, first moving the expenses in an external store from the given
+// category names to another. This is the last step, so a failure in any earlier one
+// leaves the store untouched. The store cannot be rolled back, so a commit failing
+// after it was rewritten is reported as ErrExpenseStoreDiverged.
+func (r *CategoryRepository) commit(tx *sql.Tx, from []string, to string) error {
+	if r.expenses == nil {
+		return tx.Commit()
+	}
+	if _, err := r.expenses.RewriteCategory(from, to); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", ErrExpenseStoreDiverged, err)
+	}
+	return nil
+}
+
+// insertCategoryAudit records a rename or merge
+func insertCategoryAudit(tx *sql.Tx, audit *models.CategoryAuditEntry) error {
+	previousNames, err := json.Marshal(audit.PreviousNames)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO category_audit (id, action, category_id, category_name, previous_names,
+			expenses_updated, recurring_updated, budgets_updated, envelopes_updated, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		audit.ID,
+		audit.Action,
+		audit.CategoryID,
+		audit.CategoryName,
+		string(previousNames),
+		audit.RowsChanged.Expenses,
+		audit.RowsChanged.Recurring,
+		audit.RowsChanged.Budgets,
+		audit.RowsChanged.Envelopes,
+		audit.CreatedAt.UTC(),
+	)
+	return err
+}
+
+// CountReferences counts the expenses, including those in the trash, recurring
+// templates, budgets and envelopes filed under a category name
+func (r *CategoryRepository) CountReferences(name string) (models.CategoryRowsChanged, error) {
+	var counts models.CategoryRowsChanged
+	type table struct {
+		name  string
+		count *int
+	}
+	tables := []table{
+		{"recurring_expenses", &counts.Recurring},
+		{"budgets", &counts.Budgets},
+		{"envelopes", &counts.Envelopes},
+	}
+	if r.expenses == nil {
+		tables = append(tables, table{"expenses", &counts.Expenses})
+	} else {
+		count, err := r.expenses.CountByCategory(name)
+		if err != nil {
+			return counts, err
+		}
+		counts.Expenses = count
+	}
+	for _, t := range tables {
+		if err := r.db.QueryRow(`SELECT COUNT(*) FROM `+t.name+` WHERE category = ?`, name).Scan(t.count); err != nil {
+			return counts, err
+		}
+	}
+	return counts, nil
+}
+
+// Descendants returns the given category names, as stored, together with the names of
+// all their subcategories at any depth. Names are matched exactly, as in expense filters.
+func (r *CategoryRepository) Descendants(names []string) ([]string, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	rows, err := r.db.Query(`SELECT name FROM categories WHERE name IN `+categorySet(len(names), true), stringArgs(names)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var descendants []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		descendants = append(descendants, name)
+	}
+	return descendants, rows.Err()
+}
+
+// getOne runs a query expected to match at most one category
+func (r *CategoryRepository) getOne(query string, args ...interface{}) (*models.Category, error) {
+	categories, err := r.queryCategories(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(categories) == 0 {
+		return nil, ErrCategoryNotFound
+	}
+	return &categories[0], nil
+}
+
+// queryCategories executes a query and returns categories
+func (r *CategoryRepository) queryCategories(query string, args ...interface{}) ([]models.Category, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []models.Category
+	for rows.Next() {
+		var category models.Category
+		var parentID sql.NullString
+		var createdAtStr string
+		if err := rows.Scan(
+			&category.ID,
+			&category.Name,
+			&parentID,
+			&category.Colour,
+			&category.Icon,
+			&category.Archived,
+			&createdAtStr,
+		); err != nil {
+			return nil, err
+		}
+		if parentID.Valid {
+			category.ParentID = &parentID.String
+		}
+		category.CreatedAt, _ = parseTimestamp(createdAtStr)
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+// checkCategoryAffected maps a statement that touched no rows to ErrCategoryNotFound
+func checkCategoryAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrCategoryNotFound
+	}
+	return nil
+}
@@ -0,0 +1,315 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fenmo-ai-assignment/models"
+)
+
+// ErrEnvelopeNotFound is returned when no envelope matches the given ID
+var ErrEnvelopeNotFound = errors.New("envelope not found")
+
+// ErrEnvelopeExists is returned when a category already has an envelope in the same currency
+var ErrEnvelopeExists = errors.New("an envelope for this category and currency already exists")
+
+// envelopeColumns is the column list shared by every envelope SELECT
+const envelopeColumns = `id, category, currency, allocation, rollover, created_at`
+
+// transferColumns is the column list shared by every transfer SELECT
+const transferColumns = `id, from_envelope_id, to_envelope_id, month, amount, currency, note, created_at`
+
+// EnvelopeRepository handles database operations for envelopes, their allocations,
+// monthly incomes and transfers
+type EnvelopeRepository struct {
+	db *sql.DB
+	// reader serves the reads: db, or the transaction of a CreateTransfer check
+	reader envelopeReader
+}
+
+// envelopeReader is the part of *sql.DB and *sql.Tx the envelope reads use
+type envelopeReader interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// NewEnvelopeRepository creates a new envelope repository
+func NewEnvelopeRepository(db *sql.DB) *EnvelopeRepository {
+	return &EnvelopeRepository{db: db, reader: db}
+}
+
+// Create inserts a new envelope
+func (r *EnvelopeRepository) Create(envelope *models.Envelope) error {
+	_, err := r.db.Exec(
+		`INSERT INTO envelopes (`+envelopeColumns+`) VALUES (?, ?, ?, ?, ?, ?)`,
+		envelope.ID,
+		envelope.Category,
+		envelope.Currency,
+		envelope.Allocation,
+		envelope.Rollover,
+		envelope.CreatedAt.UTC(),
+	)
+	if isUniqueViolation(err) {
+		return ErrEnvelopeExists
+	}
+	return err
+}
+
+// GetByID retrieves a single envelope by its ID
+func (r *EnvelopeRepository) GetByID(id string) (*models.Envelope, error) {
+	envelopes, err := r.queryEnvelopes(`SELECT `+envelopeColumns+` FROM envelopes WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(envelopes) == 0 {
+		return nil, ErrEnvelopeNotFound
+	}
+	return &envelopes[0], nil
+}
+
+// List retrieves every envelope ordered by category and currency
+func (r *EnvelopeRepository) List() ([]models.Envelope, error) {
+	return r.queryEnvelopes(`SELECT ` + envelopeColumns + ` FROM envelopes ORDER BY category, currency`)
+}
+
+// Update overwrites the mutable fields of an existing envelope
+func (r *EnvelopeRepository) Update(envelope *models.Envelope) error {
+	result, err := r.db.Exec(
+		`UPDATE envelopes SET category = ?, currency = ?, allocation = ?, rollover = ? WHERE id = ?`,
+		envelope.Category,
+		envelope.Currency,
+		envelope.Allocation,
+		envelope.Rollover,
+		envelope.ID,
+	)
+	if isUniqueViolation(err) {
+		return ErrEnvelopeExists
+	}
+	if err != nil {
+		return err
+	}
+	return checkEnvelopeAffected(result)
+}
+
+// Delete removes an envelope and its allocations. Transfers are an audit trail and
+// are kept.
+func (r *EnvelopeRepository) Delete(id string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM envelopes WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if err := checkEnvelopeAffected(result); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM envelope_allocations WHERE envelope_id = ?`, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SetAllocation stores the allocation of an envelope for a month, replacing any existing one
+func (r *EnvelopeRepository) SetAllocation(allocation models.EnvelopeAllocation) error {
+	_, err := r.db.Exec(upsertAllocationSQL, allocation.EnvelopeID, allocation.Month, allocation.Amount)
+	return err
+}
+
+// upsertAllocationSQL inserts or replaces one month's allocation
+const upsertAllocationSQL = `
+	INSERT INTO envelope_allocations (envelope_id, month, amount)
+	VALUES (?, ?, ?)
+	ON CONFLICT (envelope_id, month) DO UPDATE SET amount = excluded.amount
+`
+
+// GetAllocationsUntil retrieves every explicit allocation up to and including a month,
+// ordered by month
+func (r *EnvelopeRepository) GetAllocationsUntil(month string) ([]models.EnvelopeAllocation, error) {
+	rows, err := r.reader.Query(
+		`SELECT envelope_id, month, amount FROM envelope_allocations WHERE month <= ? ORDER BY month`,
+		month,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var allocations []models.EnvelopeAllocation
+	for rows.Next() {
+		var allocation models.EnvelopeAllocation
+		if err := rows.Scan(&allocation.EnvelopeID, &allocation.Month, &allocation.Amount); err != nil {
+			return nil, err
+		}
+		allocations = append(allocations, allocation)
+	}
+
+	return allocations, rows.Err()
+}
+
+// SetIncome stores a month's income and the allocations it was split into in a single
+// transaction, replacing any existing income and allocations for that month
+func (r *EnvelopeRepository) SetIncome(income models.Income, allocations []models.EnvelopeAllocation) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO envelope_incomes (month, currency, amount)
+		VALUES (?, ?, ?)
+		ON CONFLICT (month, currency) DO UPDATE SET amount = excluded.amount
+	`, income.Month, income.Currency, income.Amount); err != nil {
+		return err
+	}
+
+	for _, allocation := range allocations {
+		if _, err := tx.Exec(upsertAllocationSQL, allocation.EnvelopeID, allocation.Month, allocation.Amount); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetIncomes retrieves the incomes recorded between two months, inclusive
+func (r *EnvelopeRepository) GetIncomes(from, to string) ([]models.Income, error) {
+	rows, err := r.reader.Query(
+		`SELECT month, currency, amount FROM envelope_incomes WHERE month >= ? AND month <= ? ORDER BY month, currency`,
+		from,
+		to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var incomes []models.Income
+	for rows.Next() {
+		var income models.Income
+		if err := rows.Scan(&income.Month, &income.Currency, &income.Amount); err != nil {
+			return nil, err
+		}
+		incomes = append(incomes, income)
+	}
+
+	return incomes, rows.Err()
+}
+
+// CreateTransfer records money moved between two envelopes. check runs after the
+// insert, in the same transaction, with a repository reading that transaction; the
+// transfer is rolled back when it returns an error. The insert takes the write lock,
+// so concurrent transfers are checked one after another.
+func (r *EnvelopeRepository) CreateTransfer(transfer *models.EnvelopeTransfer, check func(*EnvelopeRepository) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`INSERT INTO envelope_transfers (`+transferColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		transfer.ID,
+		transfer.FromEnvelopeID,
+		transfer.ToEnvelopeID,
+		transfer.Month,
+		transfer.Amount,
+		transfer.Currency,
+		transfer.Note,
+		transfer.CreatedAt.UTC(),
+	); err != nil {
+		return err
+	}
+
+	if err := check(&EnvelopeRepository{db: r.db, reader: tx}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetTransfers retrieves transfers between two months, inclusive, oldest first.
+// An empty from or to leaves that end of the range open.
+func (r *EnvelopeRepository) GetTransfers(from, to string) ([]models.EnvelopeTransfer, error) {
+	query := `SELECT ` + transferColumns + ` FROM envelope_transfers WHERE 1 = 1`
+	var args []interface{}
+	if from != "" {
+		query += ` AND month >= ?`
+		args = append(args, from)
+	}
+	if to != "" {
+		query += ` AND month <= ?`
+		args = append(args, to)
+	}
+
+	rows, err := r.reader.Query(query+` ORDER BY month, created_at`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transfers []models.EnvelopeTransfer
+	for rows.Next() {
+		var transfer models.EnvelopeTransfer
+		var createdAtStr string
+		if err := rows.Scan(
+			&transfer.ID,
+			&transfer.FromEnvelopeID,
+			&transfer.ToEnvelopeID,
+			&transfer.Month,
+			&transfer.Amount,
+			&transfer.Currency,
+			&transfer.Note,
+			&createdAtStr,
+		); err != nil {
+			return nil, err
+		}
+		transfer.CreatedAt, _ = parseTimestamp(createdAtStr)
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers, rows.Err()
+}
+
+// queryEnvelopes executes a query and returns envelopes
+func (r *EnvelopeRepository) queryEnvelopes(query string, args ...interface{}) ([]models.Envelope, error) {
+	rows, err := r.reader.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var envelopes []models.Envelope
+	for rows.Next() {
+		var envelope models.Envelope
+		var createdAtStr string
+		if err := rows.Scan(
+			&envelope.ID,
+			&envelope.Category,
+			&envelope.Currency,
+			&envelope.Allocation,
+			&envelope.Rollover,
+			&createdAtStr,
+		); err != nil {
+			return nil, err
+		}
+		envelope.CreatedAt, _ = parseTimestamp(createdAtStr)
+		envelopes = append(envelopes, envelope)
+	}
+
+	return envelopes, rows.Err()
+}
+
+// checkEnvelopeAffected maps a statement that touched no rows to ErrEnvelopeNotFound
+func checkEnvelopeAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrEnvelopeNotFound
+	}
+	return nil
+}
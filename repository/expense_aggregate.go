@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"fenmo-ai-assignment/models"
+	"fenmo-ai-assignment/money"
+	"fmt"
+	"strings"
+)
+
+// AmountGroup is the row count and exact total of one group of expenses in one currency.
+// Only the fields the expenses were grouped by are set.
+type AmountGroup struct {
+	Date     string
+	Category string
+	Tag      string
+	Currency string
+	Count    int
+	Total    money.Decimal
+}
+
+// SumByCategory totals the expenses matching the filter per category and currency,
+// ordered by category
+func (r *ExpenseRepository) SumByCategory(filter models.ExpenseFilter) ([]AmountGroup, error) {
+	return r.sumBy(filter, "category")
+}
+
+// SumByDate totals the expenses matching the filter per day and currency, ordered by
+// date. With byCategory each day is further split per category.
+func (r *ExpenseRepository) SumByDate(filter models.ExpenseFilter, byCategory bool) ([]AmountGroup, error) {
+	if byCategory {
+		return r.sumBy(filter, "date", "category")
+	}
+	return r.sumBy(filter, "date")
+}
+
+// SumByTag totals the expenses matching the filter per tag and currency, ordered by
+// tag. An expense with several tags counts towards each of them; untagged expenses
+// are left out.
+func (r *ExpenseRepository) SumByTag(filter models.ExpenseFilter) ([]AmountGroup, error) {
+	q := newExpenseQuery(filter, r.fullText)
+	rows, err := r.db.Query(`
+		SELECT t.name, e.currency, e.amount
+		FROM (SELECT id, currency, amount`+q.from()+`) e
+		JOIN expense_tags et ON et.expense_id = e.id
+		JOIN tags t ON t.id = et.tag_id
+		ORDER BY t.name, e.currency`,
+		q.args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []AmountGroup
+	for rows.Next() {
+		var group AmountGroup
+		var amountStr string
+		if err := rows.Scan(&group.Tag, &group.Currency, &amountStr); err != nil {
+			return nil, err
+		}
+		if groups, err = addToGroups(groups, group, amountStr); err != nil {
+			return nil, err
+		}
+	}
+
+	return groups, rows.Err()
+}
+
+// sumBy groups the filtered expenses by the given columns and currency. SQLite can only
+// SUM the TEXT amount column as a float, so rows are streamed in group order and added
+// up as exact decimals.
+func (r *ExpenseRepository) sumBy(filter models.ExpenseFilter, fields ...string) ([]AmountGroup, error) {
+	for _, field := range fields {
+		if field != "date" && field != "category" {
+			return nil, fmt.Errorf("unsupported group field %q", field)
+		}
+	}
+
+	columns := strings.Join(append(fields, "currency"), ", ")
+	q := newExpenseQuery(filter, r.fullText)
+	query := `SELECT ` + columns + `, amount` + q.from() + ` ORDER BY ` + columns
+	rows, err := r.db.Query(query, q.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []AmountGroup
+	for rows.Next() {
+		var group AmountGroup
+		var amountStr string
+		dest := make([]interface{}, 0, len(fields)+2)
+		for _, field := range fields {
+			if field == "date" {
+				dest = append(dest, &group.Date)
+			} else {
+				dest = append(dest, &group.Category)
+			}
+		}
+		if err := rows.Scan(append(dest, &group.Currency, &amountStr)...); err != nil {
+			return nil, err
+		}
+		if groups, err = addToGroups(groups, group, amountStr); err != nil {
+			return nil, err
+		}
+	}
+
+	return groups, rows.Err()
+}
+
+// addToGroups adds one stored amount to the last group when the row belongs to it, or
+// starts a new group. Rows must arrive ordered by their group.
+func addToGroups(groups []AmountGroup, group AmountGroup, amountStr string) ([]AmountGroup, error) {
+	amount, err := money.Parse(strings.TrimSpace(amountStr))
+	if err != nil {
+		return nil, fmt.Errorf("stored amount %q: %w", amountStr, err)
+	}
+
+	if n := len(groups); n > 0 {
+		last := &groups[n-1]
+		if last.Date == group.Date && last.Category == group.Category && last.Tag == group.Tag && last.Currency == group.Currency {
+			last.Count++
+			last.Total = last.Total.Add(amount)
+			return groups, nil
+		}
+	}
+	group.Count = 1
+	group.Total = amount
+	return append(groups, group), nil
+}
@@ -0,0 +1,632 @@
+package repository
+
+import (
+	"fenmo-ai-assignment/models"
+	"fenmo-ai-assignment/money"
+	"fenmo-ai-assignment/utils"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryExpenseStore keeps expenses in memory, for demo mode and for tests that do
+// not need a database. It is safe for concurrent use. Description queries are
+// case-insensitive substring matches, as in SQLite builds without FTS5.
+type MemoryExpenseStore struct {
+	mu       sync.RWMutex
+	expenses []*models.Expense // In insertion order
+	byID     map[string]*models.Expense
+	tags     map[string]*models.Tag // By name
+	tree     CategoryTree
+}
+
+// NewMemoryExpenseStore creates an empty in-memory expense store. categories resolves
+// subcategories for filters that include them; with nil only the named categories match.
+func NewMemoryExpenseStore(categories CategoryTree) *MemoryExpenseStore {
+	return &MemoryExpenseStore{
+		byID: make(map[string]*models.Expense),
+		tags: make(map[string]*models.Tag),
+		tree: categories,
+	}
+}
+
+// FullTextSearch reports false: the store has no full-text index
+func (s *MemoryExpenseStore) FullTextSearch() bool {
+	return false
+}
+
+// Create stores a copy of a new expense
+func (s *MemoryExpenseStore) Create(expense *models.Expense) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.byID[expense.ID]; ok {
+		return fmt.Errorf("expense %s already exists", expense.ID)
+	}
+	// Like the unique index in SQLite, this covers expenses in the trash too
+	if expense.RecurringID != nil {
+		for _, existing := range s.expenses {
+			if existing.RecurringID != nil && *existing.RecurringID == *expense.RecurringID && existing.Date == expense.Date {
+				return ErrOccurrenceExists
+			}
+		}
+	}
+
+	// Only the stored fields are kept, as in the database
+	stored := copyExpense(models.Expense{
+		ID:          expense.ID,
+		Amount:      expense.Amount,
+		Currency:    expense.Currency,
+		Category:    expense.Category,
+		Description: expense.Description,
+		Date:        expense.Date,
+		CreatedAt:   expense.CreatedAt.UTC(),
+		RecurringID: expense.RecurringID,
+		Tags:        expense.Tags,
+	})
+	s.expenses = append(s.expenses, &stored)
+	s.byID[stored.ID] = &stored
+	s.addTags(stored.Tags)
+	return nil
+}
+
+// GetByID retrieves a copy of a single non-deleted expense
+func (s *MemoryExpenseStore) GetByID(id string) (*models.Expense, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored, ok := s.byID[id]
+	if !ok || stored.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+	expense := copyExpense(*stored)
+	return &expense, nil
+}
+
+// Update overwrites the mutable fields and tags of an existing, non-deleted expense
+func (s *MemoryExpenseStore) Update(expense *models.Expense) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.byID[expense.ID]
+	if !ok || stored.DeletedAt != nil {
+		return ErrNotFound
+	}
+	stored.Amount = expense.Amount
+	stored.Currency = expense.Currency
+	stored.Category = expense.Category
+	stored.Description = expense.Description
+	stored.Date = expense.Date
+	stored.Tags = sortedTags(expense.Tags)
+	s.addTags(stored.Tags)
+	return nil
+}
+
+// Delete soft-deletes an expense by stamping DeletedAt
+func (s *MemoryExpenseStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.byID[id]
+	if !ok || stored.DeletedAt != nil {
+		return ErrNotFound
+	}
+	now := time.Now().UTC()
+	stored.DeletedAt = &now
+	return nil
+}
+
+// GetDeleted retrieves soft-deleted expenses, most recently deleted first
+func (s *MemoryExpenseStore) GetDeleted() ([]models.Expense, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var deleted []models.Expense
+	for _, stored := range s.expenses {
+		if stored.DeletedAt != nil {
+			deleted = append(deleted, copyExpense(*stored))
+		}
+	}
+	sort.SliceStable(deleted, func(i, j int) bool { return deleted[i].DeletedAt.After(*deleted[j].DeletedAt) })
+	return deleted, nil
+}
+
+// Restore clears DeletedAt on a soft-deleted expense
+func (s *MemoryExpenseStore) Restore(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.byID[id]
+	if !ok || stored.DeletedAt == nil {
+		return ErrNotFound
+	}
+	stored.DeletedAt = nil
+	return nil
+}
+
+// PurgeDeletedBefore permanently removes expenses soft-deleted before the cutoff and
+// returns the number removed
+func (s *MemoryExpenseStore) PurgeDeletedBefore(cutoff time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	kept := s.expenses[:0]
+	for _, stored := range s.expenses {
+		if stored.DeletedAt != nil && stored.DeletedAt.Before(cutoff) {
+			delete(s.byID, stored.ID)
+			purged++
+			continue
+		}
+		kept = append(kept, stored)
+	}
+	s.expenses = kept
+	return purged, nil
+}
+
+// List retrieves the expenses matching the filter in the given order. With no sort
+// keys they are returned in insertion order.
+func (s *MemoryExpenseStore) List(filter models.ExpenseFilter, sortKeys []models.SortKey) ([]models.Expense, error) {
+	return s.GetPage(filter, sortKeys, nil, -1)
+}
+
+// GetPage retrieves up to limit expenses matching the filter in the given order,
+// starting after the row whose CursorValues are given (nil for the first page). A
+// negative limit returns every remaining expense.
+func (s *MemoryExpenseStore) GetPage(filter models.ExpenseFilter, sortKeys []models.SortKey, after []string, limit int) ([]models.Expense, error) {
+	for _, key := range sortKeys {
+		if _, ok := sortExpressions[key.Field]; !ok || key.Field == "relevance" {
+			return nil, fmt.Errorf("unsupported sort field %q", key.Field)
+		}
+	}
+	if after != nil && len(after) != len(sortKeys) {
+		return nil, fmt.Errorf("cursor has %d values for %d sort keys", len(after), len(sortKeys))
+	}
+
+	matched, err := s.match(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rows are ordered and compared by their cursor values, which render every sort
+	// field as text ordered the way SQLite orders the column
+	type row struct {
+		expense models.Expense
+		cursor  []string
+	}
+	rows := make([]row, len(matched))
+	for i, expense := range matched {
+		rows[i] = row{expense, CursorValues(expense, sortKeys)}
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return compareCursor(rows[i].cursor, rows[j].cursor, sortKeys) < 0 })
+
+	var page []models.Expense
+	for _, r := range rows {
+		if limit >= 0 && len(page) >= limit {
+			break
+		}
+		if after != nil && compareCursor(r.cursor, after, sortKeys) <= 0 {
+			continue
+		}
+		page = append(page, r.expense)
+	}
+	return page, nil
+}
+
+// ForEachAmount calls fn with the amount, currency, date and category of every
+// expense matching the filter
+func (s *MemoryExpenseStore) ForEachAmount(filter models.ExpenseFilter, fn func(models.AmountEntry) error) error {
+	matched, err := s.match(filter)
+	if err != nil {
+		return err
+	}
+	for _, expense := range matched {
+		entry := models.AmountEntry{Amount: expense.Amount, Currency: expense.Currency, Date: expense.Date, Category: expense.Category}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SumByCategory totals the expenses matching the filter per category and currency,
+// ordered by category
+func (s *MemoryExpenseStore) SumByCategory(filter models.ExpenseFilter) ([]AmountGroup, error) {
+	return s.sumBy(filter, func(e models.Expense) []AmountGroup {
+		return []AmountGroup{{Category: e.Category, Currency: e.Currency}}
+	})
+}
+
+// SumByDate totals the expenses matching the filter per day and currency, ordered by
+// date. With byCategory each day is further split per category.
+func (s *MemoryExpenseStore) SumByDate(filter models.ExpenseFilter, byCategory bool) ([]AmountGroup, error) {
+	return s.sumBy(filter, func(e models.Expense) []AmountGroup {
+		group := AmountGroup{Date: e.Date, Currency: e.Currency}
+		if byCategory {
+			group.Category = e.Category
+		}
+		return []AmountGroup{group}
+	})
+}
+
+// SumByTag totals the expenses matching the filter per tag and currency, ordered by
+// tag. An expense with several tags counts towards each of them; untagged expenses
+// are left out.
+func (s *MemoryExpenseStore) SumByTag(filter models.ExpenseFilter) ([]AmountGroup, error) {
+	return s.sumBy(filter, func(e models.Expense) []AmountGroup {
+		groups := make([]AmountGroup, len(e.Tags))
+		for i, tag := range e.Tags {
+			groups[i] = AmountGroup{Tag: tag, Currency: e.Currency}
+		}
+		return groups
+	})
+}
+
+// sumBy totals the filtered expenses per group, where groupsOf returns the groups an
+// expense counts towards with only their key fields set
+func (s *MemoryExpenseStore) sumBy(filter models.ExpenseFilter, groupsOf func(models.Expense) []AmountGroup) ([]AmountGroup, error) {
+	matched, err := s.match(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	type row struct {
+		group  AmountGroup
+		amount string
+	}
+	var rows []row
+	for _, expense := range matched {
+		for _, group := range groupsOf(expense) {
+			rows = append(rows, row{group, expense.Amount})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i].group, rows[j].group
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		if a.Category != b.Category {
+			return a.Category < b.Category
+		}
+		if a.Tag != b.Tag {
+			return a.Tag < b.Tag
+		}
+		return a.Currency < b.Currency
+	})
+
+	var groups []AmountGroup
+	for _, r := range rows {
+		if groups, err = addToGroups(groups, r.group, r.amount); err != nil {
+			return nil, err
+		}
+	}
+	return groups, nil
+}
+
+// RewriteCategory moves every expense, including those in the trash, from the given
+// category names to another and returns the number moved
+func (s *MemoryExpenseStore) RewriteCategory(from []string, to string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make(map[string]bool, len(from))
+	for _, name := range from {
+		names[name] = true
+	}
+	moved := 0
+	for _, stored := range s.expenses {
+		if names[stored.Category] {
+			stored.Category = to
+			moved++
+		}
+	}
+	return moved, nil
+}
+
+// CountByCategory counts the expenses, including those in the trash, filed under a
+// category name
+func (s *MemoryExpenseStore) CountByCategory(name string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, stored := range s.expenses {
+		if stored.Category == name {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// ListTags retrieves every tag ordered by name, each with its expense count
+func (s *MemoryExpenseStore) ListTags() ([]models.Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tags := make([]models.Tag, 0, len(s.tags))
+	for _, tag := range s.tags {
+		tags = append(tags, s.countedTag(tag))
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags, nil
+}
+
+// GetTag retrieves a single tag by its ID
+func (s *MemoryExpenseStore) GetTag(id string) (*models.Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored := s.tagByID(id)
+	if stored == nil {
+		return nil, ErrTagNotFound
+	}
+	tag := s.countedTag(stored)
+	return &tag, nil
+}
+
+// RenameTag changes the name of a tag on every expense carrying it
+func (s *MemoryExpenseStore) RenameTag(id, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tag := s.tagByID(id)
+	if tag == nil {
+		return ErrTagNotFound
+	}
+	if tag.Name == name {
+		return nil
+	}
+	if _, ok := s.tags[name]; ok {
+		return ErrTagExists
+	}
+
+	s.retag(map[string]bool{tag.Name: true}, name)
+	delete(s.tags, tag.Name)
+	tag.Name = name
+	s.tags[name] = tag
+	return nil
+}
+
+// MergeTags moves the source tags onto the target and deletes the sources. It returns
+// the number of expenses that gained the target tag; expenses that already carried it
+// are not counted.
+func (s *MemoryExpenseStore) MergeTags(sourceIDs []string, targetID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	target := s.tagByID(targetID)
+	if target == nil {
+		return 0, ErrTagNotFound
+	}
+	// Every source is looked up before any expense changes, so a merge naming an
+	// unknown tag changes nothing
+	sources := make(map[string]bool, len(sourceIDs))
+	for _, id := range sourceIDs {
+		source := s.tagByID(id)
+		if source == nil {
+			return 0, ErrTagNotFound
+		}
+		sources[source.Name] = true
+	}
+
+	retagged := s.retag(sources, target.Name)
+	for name := range sources {
+		delete(s.tags, name)
+	}
+	return retagged, nil
+}
+
+// DeleteTag removes a tag from every expense and deletes it
+func (s *MemoryExpenseStore) DeleteTag(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tag := s.tagByID(id)
+	if tag == nil {
+		return ErrTagNotFound
+	}
+	s.retag(map[string]bool{tag.Name: true}, "")
+	delete(s.tags, tag.Name)
+	return nil
+}
+
+// addTags creates the tags an expense uses that do not exist yet. The caller must
+// hold the write lock.
+func (s *MemoryExpenseStore) addTags(names []string) {
+	now := time.Now().UTC()
+	for _, name := range names {
+		if _, ok := s.tags[name]; !ok {
+			s.tags[name] = &models.Tag{ID: utils.GenerateUUID(), Name: name, CreatedAt: now}
+		}
+	}
+}
+
+// tagByID returns the stored tag with an ID, or nil. The caller must hold the lock.
+func (s *MemoryExpenseStore) tagByID(id string) *models.Tag {
+	for _, tag := range s.tags {
+		if tag.ID == id {
+			return tag
+		}
+	}
+	return nil
+}
+
+// countedTag returns a copy of a tag with the number of expenses outside the trash
+// carrying it. The caller must hold the lock.
+func (s *MemoryExpenseStore) countedTag(stored *models.Tag) models.Tag {
+	tag := *stored
+	tag.ExpenseCount = 0
+	for _, expense := range s.expenses {
+		if expense.DeletedAt == nil && hasTags(expense.Tags, []string{tag.Name}, false) {
+			tag.ExpenseCount++
+		}
+	}
+	return tag
+}
+
+// retag replaces the tags named in from with the tag to on every expense, including
+// those in the trash, or drops them when to is empty. It returns the number of
+// expenses that gained to. The caller must hold the write lock.
+func (s *MemoryExpenseStore) retag(from map[string]bool, to string) int {
+	gained := 0
+	for _, expense := range s.expenses {
+		var kept []string
+		carried, hasTarget := false, false
+		for _, tag := range expense.Tags {
+			if from[tag] {
+				carried = true
+				continue
+			}
+			hasTarget = hasTarget || tag == to
+			kept = append(kept, tag)
+		}
+		if !carried {
+			continue
+		}
+		if to != "" && !hasTarget {
+			kept = append(kept, to)
+			gained++
+		}
+		expense.Tags = sortedTags(kept)
+	}
+	return gained
+}
+
+// match returns copies of the non-deleted expenses matching the filter, in insertion order
+func (s *MemoryExpenseStore) match(filter models.ExpenseFilter) ([]models.Expense, error) {
+	categories, err := s.categorySet(filter.Categories, filter.IncludeSubcategories)
+	if err != nil {
+		return nil, err
+	}
+	excluded, err := s.categorySet(filter.ExcludeCategories, filter.IncludeSubcategories)
+	if err != nil {
+		return nil, err
+	}
+
+	var minKey, maxKey string
+	if minAmount, err := money.Parse(filter.MinAmount); filter.MinAmount != "" && err == nil {
+		minKey = AmountKey(minAmount)
+	}
+	if maxAmount, err := money.Parse(filter.MaxAmount); filter.MaxAmount != "" && err == nil {
+		maxKey = AmountKey(maxAmount)
+	}
+	query := strings.ToLower(filter.Query)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matched []models.Expense
+	for _, stored := range s.expenses {
+		if stored.DeletedAt != nil {
+			continue
+		}
+		if len(filter.Categories) > 0 && !categories[stored.Category] {
+			continue
+		}
+		if excluded[stored.Category] {
+			continue
+		}
+		if len(filter.Tags) > 0 && !hasTags(stored.Tags, filter.Tags, filter.TagMode == models.TagModeAll) {
+			continue
+		}
+		if filter.Currency != "" && stored.Currency != filter.Currency {
+			continue
+		}
+		if (filter.From != "" && stored.Date < filter.From) || (filter.To != "" && stored.Date > filter.To) {
+			continue
+		}
+		if minKey != "" || maxKey != "" {
+			amount, err := money.Parse(strings.TrimSpace(stored.Amount))
+			if err != nil {
+				return nil, fmt.Errorf("stored amount %q: %w", stored.Amount, err)
+			}
+			key := AmountKey(amount)
+			if (minKey != "" && key < minKey) || (maxKey != "" && key > maxKey) {
+				continue
+			}
+		}
+		if query != "" && !strings.Contains(strings.ToLower(stored.Description), query) {
+			continue
+		}
+		matched = append(matched, copyExpense(*stored))
+	}
+	return matched, nil
+}
+
+// categorySet returns the category names an expense filter list matches. With
+// subcategories and a category tree, the names of their descendants are added.
+func (s *MemoryExpenseStore) categorySet(names []string, subcategories bool) (map[string]bool, error) {
+	if subcategories && s.tree != nil && len(names) > 0 {
+		descendants, err := s.tree.Descendants(names)
+		if err != nil {
+			return nil, err
+		}
+		names = descendants
+	}
+
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set, nil
+}
+
+// hasTags reports whether an expense carrying tags matches any, or with all every
+// one, of the wanted tags
+func hasTags(tags, wanted []string, all bool) bool {
+	carried := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		carried[tag] = true
+	}
+	for _, tag := range wanted {
+		if carried[tag] != all {
+			return !all
+		}
+	}
+	return all
+}
+
+// compareCursor compares two rows by their cursor values, honouring each key's direction
+func compareCursor(a, b []string, sortKeys []models.SortKey) int {
+	for i, key := range sortKeys {
+		if c := strings.Compare(a[i], b[i]); c != 0 {
+			if key.Desc {
+				return -c
+			}
+			return c
+		}
+	}
+	return 0
+}
+
+// copyExpense returns a copy of an expense sharing no pointers or slices with it
+func copyExpense(expense models.Expense) models.Expense {
+	if expense.DeletedAt != nil {
+		deletedAt := *expense.DeletedAt
+		expense.DeletedAt = &deletedAt
+	}
+	if expense.RecurringID != nil {
+		recurringID := *expense.RecurringID
+		expense.RecurringID = &recurringID
+	}
+	if expense.ConvertedAmount != nil {
+		converted := *expense.ConvertedAmount
+		expense.ConvertedAmount = &converted
+	}
+	if expense.Relevance != nil {
+		relevance := *expense.Relevance
+		expense.Relevance = &relevance
+	}
+	expense.Tags = sortedTags(expense.Tags)
+	expense.ExceededBudgets = append([]models.BudgetStatus(nil), expense.ExceededBudgets...)
+	return expense
+}
+
+// sortedTags returns a sorted copy of tags, never nil
+func sortedTags(tags []string) []string {
+	sorted := append([]string{}, tags...)
+	sort.Strings(sorted)
+	return sorted
+}
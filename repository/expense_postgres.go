@@ -0,0 +1,725 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fenmo-ai-assignment/models"
+	"fenmo-ai-assignment/money"
+	"fenmo-ai-assignment/utils"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// pgExpenseColumns is the column list shared by every expense SELECT, rendering amounts
+// and dates in the same text form the SQLite schema stores them in
+const pgExpenseColumns = `id, amount::text, currency, category, description, to_char(date, 'YYYY-MM-DD'), created_at, deleted_at, recurring_id`
+
+// pgSortExpressions maps sortable fields onto the SQL used to order them. Text sorts
+// byte-wise, as in SQLite, whatever the database collation.
+var pgSortExpressions = map[string]string{
+	"date":       "date",
+	"amount":     "amount",
+	"created_at": "created_at",
+	"category":   `category COLLATE "C"`,
+	"id":         `id COLLATE "C"`,
+}
+
+// pgSortCasts converts cursor values, bound as text, to the type of their column
+var pgSortCasts = map[string]string{
+	"date":       "::date",
+	"amount":     "::numeric",
+	"created_at": "::timestamptz",
+}
+
+// PostgresExpenseRepository stores expenses in PostgreSQL, with NUMERIC amounts and
+// native DATE and TIMESTAMPTZ columns. Description queries are case-insensitive
+// substring matches.
+type PostgresExpenseRepository struct {
+	db   *sql.DB
+	tree CategoryTree
+}
+
+// NewPostgresExpenseRepository creates a PostgreSQL expense repository. categories
+// resolves subcategories for filters that include them, since categories are not
+// stored in PostgreSQL; with nil only the named categories match.
+func NewPostgresExpenseRepository(db *sql.DB, categories CategoryTree) *PostgresExpenseRepository {
+	return &PostgresExpenseRepository{db: db, tree: categories}
+}
+
+// FullTextSearch reports false: description queries are substring matches
+func (r *PostgresExpenseRepository) FullTextSearch() bool {
+	return false
+}
+
+// Create creates a new expense together with its tags
+func (r *PostgresExpenseRepository) Create(expense *models.Expense) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO expenses (id, amount, currency, category, description, date, created_at, recurring_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		expense.ID,
+		expense.Amount,
+		expense.Currency,
+		expense.Category,
+		expense.Description,
+		expense.Date,
+		expense.CreatedAt,
+		expense.RecurringID,
+	)
+	if isPgUniqueViolation(err) {
+		return ErrOccurrenceExists
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := setPgExpenseTags(tx, expense.ID, expense.Tags); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetByID retrieves a single non-deleted expense by its ID
+func (r *PostgresExpenseRepository) GetByID(id string) (*models.Expense, error) {
+	expenses, err := r.queryExpenses(`SELECT `+pgExpenseColumns+` FROM expenses WHERE id = $1 AND deleted_at IS NULL`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(expenses) == 0 {
+		return nil, ErrNotFound
+	}
+	return &expenses[0], nil
+}
+
+// Update overwrites the mutable fields and tags of an existing, non-deleted expense
+func (r *PostgresExpenseRepository) Update(expense *models.Expense) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE expenses
+		SET amount = $1, currency = $2, category = $3, description = $4, date = $5
+		WHERE id = $6 AND deleted_at IS NULL`,
+		expense.Amount,
+		expense.Currency,
+		expense.Category,
+		expense.Description,
+		expense.Date,
+		expense.ID,
+	)
+	if err != nil {
+		return err
+	}
+	if err := checkRowsAffected(result); err != nil {
+		return err
+	}
+
+	if err := setPgExpenseTags(tx, expense.ID, expense.Tags); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Delete soft-deletes an expense by stamping deleted_at
+func (r *PostgresExpenseRepository) Delete(id string) error {
+	result, err := r.db.Exec(
+		`UPDATE expenses SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL`,
+		time.Now().UTC(),
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return checkRowsAffected(result)
+}
+
+// GetDeleted retrieves soft-deleted expenses, most recently deleted first
+func (r *PostgresExpenseRepository) GetDeleted() ([]models.Expense, error) {
+	return r.queryExpenses(`SELECT ` + pgExpenseColumns + ` FROM expenses WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC`)
+}
+
+// Restore clears deleted_at on a soft-deleted expense
+func (r *PostgresExpenseRepository) Restore(id string) error {
+	result, err := r.db.Exec(`UPDATE expenses SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return err
+	}
+
+	return checkRowsAffected(result)
+}
+
+// PurgeDeletedBefore permanently removes expenses soft-deleted before the cutoff and
+// returns the number removed. Their tag links are removed by the foreign key cascade.
+func (r *PostgresExpenseRepository) PurgeDeletedBefore(cutoff time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM expenses WHERE deleted_at IS NOT NULL AND deleted_at < $1`, cutoff.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// List retrieves the expenses matching the filter in the given order.
+// With no sort keys the order is unspecified.
+func (r *PostgresExpenseRepository) List(filter models.ExpenseFilter, sort []models.SortKey) ([]models.Expense, error) {
+	return r.GetPage(filter, sort, nil, -1)
+}
+
+// GetPage retrieves up to limit expenses matching the filter in the given order,
+// starting after the row whose CursorValues are given (nil for the first page). A
+// negative limit returns every remaining expense.
+func (r *PostgresExpenseRepository) GetPage(filter models.ExpenseFilter, sort []models.SortKey, after []string, limit int) ([]models.Expense, error) {
+	orderBy, err := pgOrderByClause(sort)
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := r.newQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+	if after != nil {
+		if err := q.after(sort, after); err != nil {
+			return nil, err
+		}
+	}
+
+	query := `SELECT ` + pgExpenseColumns + ` FROM expenses` + q.whereClause() + orderBy
+	if limit >= 0 {
+		query += ` LIMIT ` + q.arg(limit)
+	}
+	return r.queryExpenses(query, q.args...)
+}
+
+// ForEachAmount streams the amount, currency, date and category of every expense
+// matching the filter, so aggregates can be computed without loading whole rows
+func (r *PostgresExpenseRepository) ForEachAmount(filter models.ExpenseFilter, fn func(models.AmountEntry) error) error {
+	q, err := r.newQuery(filter)
+	if err != nil {
+		return err
+	}
+	rows, err := r.db.Query(`SELECT amount::text, currency, to_char(date, 'YYYY-MM-DD'), category FROM expenses`+q.whereClause(), q.args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry models.AmountEntry
+		if err := rows.Scan(&entry.Amount, &entry.Currency, &entry.Date, &entry.Category); err != nil {
+			return err
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// SumByCategory totals the expenses matching the filter per category and currency,
+// ordered by category
+func (r *PostgresExpenseRepository) SumByCategory(filter models.ExpenseFilter) ([]AmountGroup, error) {
+	return r.sumBy(filter, "category")
+}
+
+// SumByDate totals the expenses matching the filter per day and currency, ordered by
+// date. With byCategory each day is further split per category.
+func (r *PostgresExpenseRepository) SumByDate(filter models.ExpenseFilter, byCategory bool) ([]AmountGroup, error) {
+	if byCategory {
+		return r.sumBy(filter, "date", "category")
+	}
+	return r.sumBy(filter, "date")
+}
+
+// SumByTag totals the expenses matching the filter per tag and currency, ordered by
+// tag. An expense with several tags counts towards each of them; untagged expenses
+// are left out.
+func (r *PostgresExpenseRepository) SumByTag(filter models.ExpenseFilter) ([]AmountGroup, error) {
+	q, err := r.newQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(`
+		SELECT t.name, e.currency, COUNT(*), SUM(e.amount)::text
+		FROM (SELECT id, currency, amount FROM expenses`+q.whereClause()+`) e
+		JOIN expense_tags et ON et.expense_id = e.id
+		JOIN tags t ON t.id = et.tag_id
+		GROUP BY t.name, e.currency
+		ORDER BY t.name COLLATE "C", e.currency COLLATE "C"`,
+		q.args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []AmountGroup
+	for rows.Next() {
+		var group AmountGroup
+		var total string
+		if err := rows.Scan(&group.Tag, &group.Currency, &group.Count, &total); err != nil {
+			return nil, err
+		}
+		if group.Total, err = money.Parse(total); err != nil {
+			return nil, fmt.Errorf("summed amount %q: %w", total, err)
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, rows.Err()
+}
+
+// sumBy groups the filtered expenses by the given columns and currency. NUMERIC
+// amounts sum exactly in the database.
+func (r *PostgresExpenseRepository) sumBy(filter models.ExpenseFilter, fields ...string) ([]AmountGroup, error) {
+	var columns, order []string
+	for _, field := range fields {
+		switch field {
+		case "date":
+			columns = append(columns, `to_char(date, 'YYYY-MM-DD')`)
+			order = append(order, `date`)
+		case "category":
+			columns = append(columns, `category`)
+			order = append(order, `category COLLATE "C"`)
+		default:
+			return nil, fmt.Errorf("unsupported group field %q", field)
+		}
+	}
+	columns = append(columns, `currency`)
+	order = append(order, `currency COLLATE "C"`)
+
+	q, err := r.newQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+	group := strings.Join(append(fields, "currency"), ", ")
+	query := `SELECT ` + strings.Join(columns, ", ") + `, COUNT(*), SUM(amount)::text FROM expenses` +
+		q.whereClause() + ` GROUP BY ` + group + ` ORDER BY ` + strings.Join(order, ", ")
+	rows, err := r.db.Query(query, q.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []AmountGroup
+	for rows.Next() {
+		var group AmountGroup
+		var total string
+		dest := make([]interface{}, 0, len(fields)+3)
+		for _, field := range fields {
+			if field == "date" {
+				dest = append(dest, &group.Date)
+			} else {
+				dest = append(dest, &group.Category)
+			}
+		}
+		if err := rows.Scan(append(dest, &group.Currency, &group.Count, &total)...); err != nil {
+			return nil, err
+		}
+		if group.Total, err = money.Parse(total); err != nil {
+			return nil, fmt.Errorf("summed amount %q: %w", total, err)
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, rows.Err()
+}
+
+// queryExpenses executes a query selecting pgExpenseColumns and returns the expenses
+// with their tags
+func (r *PostgresExpenseRepository) queryExpenses(query string, args ...interface{}) ([]models.Expense, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var expenses []models.Expense
+	for rows.Next() {
+		var expense models.Expense
+		var deletedAt sql.NullTime
+		var recurringID sql.NullString
+		if err := rows.Scan(
+			&expense.ID,
+			&expense.Amount,
+			&expense.Currency,
+			&expense.Category,
+			&expense.Description,
+			&expense.Date,
+			&expense.CreatedAt,
+			&deletedAt,
+			&recurringID,
+		); err != nil {
+			return nil, err
+		}
+		if deletedAt.Valid {
+			expense.DeletedAt = &deletedAt.Time
+		}
+		if recurringID.Valid {
+			expense.RecurringID = &recurringID.String
+		}
+		expenses = append(expenses, expense)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadTags(expenses); err != nil {
+		return nil, err
+	}
+	return expenses, nil
+}
+
+// RewriteCategory moves every expense, including those in the trash, from the given
+// category names to another and returns the number moved
+func (r *PostgresExpenseRepository) RewriteCategory(from []string, to string) (int, error) {
+	result, err := r.db.Exec(`UPDATE expenses SET category = $1 WHERE category = ANY($2)`, to, pq.Array(from))
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+// CountByCategory counts the expenses, including those in the trash, filed under a
+// category name
+func (r *PostgresExpenseRepository) CountByCategory(name string) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM expenses WHERE category = $1`, name).Scan(&count)
+	return count, err
+}
+
+// ListTags retrieves every tag ordered by name, each with its expense count
+func (r *PostgresExpenseRepository) ListTags() ([]models.Tag, error) {
+	return r.queryTags(``)
+}
+
+// GetTag retrieves a single tag by its ID
+func (r *PostgresExpenseRepository) GetTag(id string) (*models.Tag, error) {
+	tags, err := r.queryTags(`WHERE t.id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) == 0 {
+		return nil, ErrTagNotFound
+	}
+	return &tags[0], nil
+}
+
+// RenameTag changes the name of a tag
+func (r *PostgresExpenseRepository) RenameTag(id, name string) error {
+	result, err := r.db.Exec(`UPDATE tags SET name = $1 WHERE id = $2`, name, id)
+	if isPgUniqueViolation(err) {
+		return ErrTagExists
+	}
+	if err != nil {
+		return err
+	}
+	return checkTagAffected(result)
+}
+
+// MergeTags moves the source tags onto the target in one transaction and deletes the
+// sources. It returns the number of expenses that gained the target tag; expenses
+// that already carried it are not counted.
+func (r *PostgresExpenseRepository) MergeTags(sourceIDs []string, targetID string) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	retagged := 0
+	for _, id := range sourceIDs {
+		result, err := tx.Exec(`
+			INSERT INTO expense_tags (expense_id, tag_id)
+			SELECT expense_id, $1 FROM expense_tags WHERE tag_id = $2
+			ON CONFLICT DO NOTHING`,
+			targetID, id,
+		)
+		if err != nil {
+			return 0, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		retagged += int(affected)
+
+		if err := deletePgTag(tx, id); err != nil {
+			return 0, err
+		}
+	}
+
+	return retagged, tx.Commit()
+}
+
+// DeleteTag removes a tag from every expense and deletes it
+func (r *PostgresExpenseRepository) DeleteTag(id string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deletePgTag(tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// deletePgTag removes a tag and its links to expenses
+func deletePgTag(tx *sql.Tx, id string) error {
+	if _, err := tx.Exec(`DELETE FROM expense_tags WHERE tag_id = $1`, id); err != nil {
+		return err
+	}
+	result, err := tx.Exec(`DELETE FROM tags WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return checkTagAffected(result)
+}
+
+// queryTags retrieves tags, with the number of expenses outside the trash carrying
+// each, restricted by an optional WHERE clause on the tags table aliased t
+func (r *PostgresExpenseRepository) queryTags(where string, args ...interface{}) ([]models.Tag, error) {
+	rows, err := r.db.Query(`
+		SELECT t.id, t.name, t.created_at,
+			(SELECT COUNT(*) FROM expense_tags et JOIN expenses e ON e.id = et.expense_id
+			 WHERE et.tag_id = t.id AND e.deleted_at IS NULL)
+		FROM tags t `+where+` ORDER BY t.name COLLATE "C"`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []models.Tag
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.CreatedAt, &tag.ExpenseCount); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// loadTags fills in the tags of each expense, sorted by name. Expenses without tags
+// get an empty list.
+func (r *PostgresExpenseRepository) loadTags(expenses []models.Expense) error {
+	if len(expenses) == 0 {
+		return nil
+	}
+	index := make(map[string]*models.Expense, len(expenses))
+	ids := make([]string, len(expenses))
+	for i := range expenses {
+		expenses[i].Tags = []string{}
+		index[expenses[i].ID] = &expenses[i]
+		ids[i] = expenses[i].ID
+	}
+
+	rows, err := r.db.Query(`
+		SELECT et.expense_id, t.name FROM expense_tags et
+		JOIN tags t ON t.id = et.tag_id
+		WHERE et.expense_id = ANY($1)
+		ORDER BY t.name COLLATE "C"`,
+		pq.Array(ids),
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var expenseID, name string
+		if err := rows.Scan(&expenseID, &name); err != nil {
+			return err
+		}
+		if expense := index[expenseID]; expense != nil {
+			expense.Tags = append(expense.Tags, name)
+		}
+	}
+	return rows.Err()
+}
+
+// setPgExpenseTags replaces the tags of an expense, creating tags that do not exist
+// yet. Tag names must already be normalised.
+func setPgExpenseTags(tx *sql.Tx, expenseID string, tags []string) error {
+	if _, err := tx.Exec(`DELETE FROM expense_tags WHERE expense_id = $1`, expenseID); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for _, name := range tags {
+		if _, err := tx.Exec(
+			`INSERT INTO tags (id, name, created_at) VALUES ($1, $2, $3) ON CONFLICT (name) DO NOTHING`,
+			utils.GenerateUUID(), name, now,
+		); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(
+			`INSERT INTO expense_tags (expense_id, tag_id) SELECT $1, id FROM tags WHERE name = $2`,
+			expenseID, name,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isPgUniqueViolation reports whether err is a PostgreSQL unique_violation
+func isPgUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// pgQuery accumulates WHERE conditions and their numbered bind arguments
+type pgQuery struct {
+	conditions []string
+	args       []interface{}
+}
+
+// newQuery builds the conditions for a filter over non-deleted expenses
+func (r *PostgresExpenseRepository) newQuery(filter models.ExpenseFilter) (*pgQuery, error) {
+	q := &pgQuery{conditions: []string{`deleted_at IS NULL`}}
+
+	if len(filter.Categories) > 0 {
+		categories, err := r.categorySet(filter.Categories, filter.IncludeSubcategories)
+		if err != nil {
+			return nil, err
+		}
+		q.where(`category = ANY(` + q.arg(pq.Array(categories)) + `)`)
+	}
+	if len(filter.ExcludeCategories) > 0 {
+		excluded, err := r.categorySet(filter.ExcludeCategories, filter.IncludeSubcategories)
+		if err != nil {
+			return nil, err
+		}
+		q.where(`category <> ALL(` + q.arg(pq.Array(excluded)) + `)`)
+	}
+	if len(filter.Tags) > 0 {
+		tagged := `SELECT et.expense_id FROM expense_tags et JOIN tags t ON t.id = et.tag_id
+			WHERE t.name = ANY(` + q.arg(pq.Array(filter.Tags)) + `)`
+		if filter.TagMode == models.TagModeAll {
+			tagged += ` GROUP BY et.expense_id HAVING COUNT(*) = ` + q.arg(len(filter.Tags))
+		}
+		q.where(`id IN (` + tagged + `)`)
+	}
+	if filter.Currency != "" {
+		q.where(`currency = ` + q.arg(filter.Currency))
+	}
+	if filter.From != "" {
+		q.where(`date >= ` + q.arg(filter.From) + `::date`)
+	}
+	if filter.To != "" {
+		q.where(`date <= ` + q.arg(filter.To) + `::date`)
+	}
+	if filter.MinAmount != "" {
+		if minAmount, err := money.Parse(filter.MinAmount); err == nil {
+			q.where(`amount >= ` + q.arg(minAmount.String()) + `::numeric`)
+		}
+	}
+	if filter.MaxAmount != "" {
+		if maxAmount, err := money.Parse(filter.MaxAmount); err == nil {
+			q.where(`amount <= ` + q.arg(maxAmount.String()) + `::numeric`)
+		}
+	}
+	if filter.Query != "" {
+		q.where(`description ILIKE ` + q.arg("%"+escapeLike(filter.Query)+"%") + ` ESCAPE '\'`)
+	}
+
+	return q, nil
+}
+
+// categorySet returns the category names a filter list matches. With subcategories
+// and a category tree, the names of their descendants are added.
+func (r *PostgresExpenseRepository) categorySet(names []string, subcategories bool) ([]string, error) {
+	if !subcategories || r.tree == nil {
+		return names, nil
+	}
+	descendants, err := r.tree.Descendants(names)
+	if err != nil {
+		return nil, err
+	}
+	if descendants == nil {
+		descendants = []string{}
+	}
+	return descendants, nil
+}
+
+// arg binds a value and returns its placeholder
+func (q *pgQuery) arg(value interface{}) string {
+	q.args = append(q.args, value)
+	return fmt.Sprintf("$%d", len(q.args))
+}
+
+// where adds a condition joined to the others with AND
+func (q *pgQuery) where(condition string) {
+	q.conditions = append(q.conditions, condition)
+}
+
+// whereClause renders the accumulated conditions
+func (q *pgQuery) whereClause() string {
+	return ` WHERE ` + strings.Join(q.conditions, ` AND `)
+}
+
+// after adds the keyset predicate matching rows that sort strictly after the given
+// CursorValues, expanded like afterCondition
+func (q *pgQuery) after(sort []models.SortKey, values []string) error {
+	if len(values) != len(sort) {
+		return fmt.Errorf("cursor has %d values for %d sort keys", len(values), len(sort))
+	}
+
+	var alternatives []string
+	for i, key := range sort {
+		var terms []string
+		for j := 0; j < i; j++ {
+			terms = append(terms, pgSortExpressions[sort[j].Field]+` = `+q.arg(values[j])+pgSortCasts[sort[j].Field])
+		}
+
+		op := ` > `
+		if key.Desc {
+			op = ` < `
+		}
+		terms = append(terms, pgSortExpressions[key.Field]+op+q.arg(values[i])+pgSortCasts[key.Field])
+
+		alternatives = append(alternatives, `(`+strings.Join(terms, ` AND `)+`)`)
+	}
+
+	q.where(`(` + strings.Join(alternatives, ` OR `) + `)`)
+	return nil
+}
+
+// pgOrderByClause renders an ORDER BY clause, rejecting unknown fields
+func pgOrderByClause(sort []models.SortKey) (string, error) {
+	if len(sort) == 0 {
+		return "", nil
+	}
+
+	terms := make([]string, len(sort))
+	for i, key := range sort {
+		expr, ok := pgSortExpressions[key.Field]
+		if !ok {
+			return "", fmt.Errorf("unsupported sort field %q", key.Field)
+		}
+		terms[i] = expr + " ASC"
+		if key.Desc {
+			terms[i] = expr + " DESC"
+		}
+	}
+	return ` ORDER BY ` + strings.Join(terms, `, `), nil
+}
@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"fenmo-ai-assignment/models"
+	"fenmo-ai-assignment/money"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// amountKeyIntegerDigits and amountKeyFractionDigits define the fixed-width text form
+// used to compare TEXT amounts numerically. Three fraction digits cover every supported currency.
+const (
+	amountKeyIntegerDigits  = 20
+	amountKeyFractionDigits = 3
+)
+
+// amountKey is a SQL expression rendering the TEXT amount column as a zero-padded,
+// fixed-width string ("100.5" -> "00000000000000000100.500"). Comparing these strings
+// orders amounts exactly, without converting them to floating point.
+const amountKey = `(substr('00000000000000000000' || ` +
+	`CASE WHEN instr(amount, '.') > 0 THEN substr(amount, 1, instr(amount, '.') - 1) ELSE amount END, -20)` +
+	` || '.' || substr(` +
+	`CASE WHEN instr(amount, '.') > 0 THEN substr(amount, instr(amount, '.') + 1) ELSE '' END || '000', 1, 3))`
+
+// AmountKey renders a non-negative decimal in the same fixed-width form as the amountKey expression
+func AmountKey(value money.Decimal) string {
+	digits := value.Round(amountKeyFractionDigits, money.RoundDown).String()
+	intPart, fracPart, _ := strings.Cut(digits, ".")
+	if pad := amountKeyIntegerDigits - len(intPart); pad > 0 {
+		intPart = strings.Repeat("0", pad) + intPart
+	}
+	return intPart + "." + fracPart
+}
+
+// expenseQuery accumulates WHERE conditions and their bind arguments
+type expenseQuery struct {
+	search     bool // Joined to the full-text index as fts, exposing relevance and snippet
+	conditions []string
+	args       []interface{}
+}
+
+// newExpenseQuery builds the conditions for a filter over non-deleted expenses. With
+// fullText the description query runs against the FTS5 index; otherwise it is a
+// substring match.
+func newExpenseQuery(filter models.ExpenseFilter, fullText bool) *expenseQuery {
+	q := &expenseQuery{}
+
+	// The join precedes the WHERE clause, so its argument is bound first
+	if filter.Query != "" && fullText {
+		q.search = true
+		q.args = append(q.args, matchExpression(filter.Query))
+	}
+	q.where(`deleted_at IS NULL`)
+
+	if len(filter.Categories) > 0 {
+		q.where(`category IN `+categorySet(len(filter.Categories), filter.IncludeSubcategories), stringArgs(filter.Categories)...)
+	}
+	if len(filter.ExcludeCategories) > 0 {
+		q.where(`category NOT IN `+categorySet(len(filter.ExcludeCategories), filter.IncludeSubcategories), stringArgs(filter.ExcludeCategories)...)
+	}
+	if len(filter.Tags) > 0 {
+		tagged := `SELECT et.expense_id FROM expense_tags et JOIN tags t ON t.id = et.tag_id
+			WHERE t.name IN (` + placeholders(len(filter.Tags)) + `)`
+		args := stringArgs(filter.Tags)
+		if filter.TagMode == models.TagModeAll {
+			tagged += ` GROUP BY et.expense_id HAVING COUNT(*) = ?`
+			args = append(args, len(filter.Tags))
+		}
+		q.where(`id IN (`+tagged+`)`, args...)
+	}
+	if filter.Currency != "" {
+		q.where(`currency = ?`, filter.Currency)
+	}
+	if filter.From != "" {
+		q.where(`date >= ?`, filter.From)
+	}
+	if filter.To != "" {
+		q.where(`date <= ?`, filter.To)
+	}
+	if filter.MinAmount != "" {
+		if minAmount, err := money.Parse(filter.MinAmount); err == nil {
+			q.where(amountKey+` >= ?`, AmountKey(minAmount))
+		}
+	}
+	if filter.MaxAmount != "" {
+		if maxAmount, err := money.Parse(filter.MaxAmount); err == nil {
+			q.where(amountKey+` <= ?`, AmountKey(maxAmount))
+		}
+	}
+	if filter.Query != "" && !fullText {
+		q.where(`description LIKE ? ESCAPE '\'`, "%"+escapeLike(filter.Query)+"%")
+	}
+
+	return q
+}
+
+// categorySet renders the set of n bound category names for an IN condition. With
+// subcategories the set also holds every descendant of those categories, found by
+// walking the category tree.
+func categorySet(n int, subcategories bool) string {
+	if !subcategories {
+		return `(` + placeholders(n) + `)`
+	}
+	return `(WITH RECURSIVE tree(id, name) AS (
+		SELECT id, name FROM categories WHERE name IN (` + placeholders(n) + `)
+		UNION
+		SELECT c.id, c.name FROM categories c JOIN tree t ON c.parent_id = t.id
+	) SELECT name FROM tree)`
+}
+
+// where adds a condition joined to the others with AND
+func (q *expenseQuery) where(condition string, args ...interface{}) {
+	q.conditions = append(q.conditions, condition)
+	q.args = append(q.args, args...)
+}
+
+// from renders the FROM clause with the full-text join, if any, and the conditions
+func (q *expenseQuery) from() string {
+	if !q.search {
+		return ` FROM expenses` + q.whereClause()
+	}
+	return ` FROM expenses JOIN (
+		SELECT expense_id, -bm25(expenses_fts) AS relevance,
+			snippet(expenses_fts, 1, '<mark>', '</mark>', '…', 16) AS snippet
+		FROM expenses_fts WHERE expenses_fts MATCH ?
+	) fts ON fts.expense_id = expenses.id` + q.whereClause()
+}
+
+// searchColumns renders the relevance and snippet columns of a full-text search, or
+// NULLs when the query does not search
+func (q *expenseQuery) searchColumns() string {
+	if !q.search {
+		return `, NULL, NULL`
+	}
+	return `, fts.relevance, fts.snippet`
+}
+
+// whereClause renders the accumulated conditions
+func (q *expenseQuery) whereClause() string {
+	if len(q.conditions) == 0 {
+		return ""
+	}
+	return ` WHERE ` + strings.Join(q.conditions, ` AND `)
+}
+
+// placeholders returns n comma-separated bind placeholders
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// stringArgs converts strings to bind arguments
+func stringArgs(values []string) []interface{} {
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	return args
+}
+
+// escapeLike escapes LIKE wildcards so the value matches literally
+func escapeLike(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(value)
+}
+
+// sortExpressions maps sortable fields onto the SQL used to order and compare them
+var sortExpressions = map[string]string{
+	"date":       "date",
+	"amount":     amountKey,
+	"created_at": "created_at",
+	"category":   "category",
+	"id":         "id",
+	"relevance":  "fts.relevance",
+}
+
+// sortPlaceholders overrides the bind placeholder for cursor values of fields that do
+// not compare as text. Cursor values are bound as strings, and SQLite orders any REAL
+// before any TEXT.
+var sortPlaceholders = map[string]string{
+	"relevance": "CAST(? AS REAL)",
+}
+
+// sortPlaceholder returns the bind placeholder for a cursor value of field
+func sortPlaceholder(field string) string {
+	if placeholder, ok := sortPlaceholders[field]; ok {
+		return placeholder
+	}
+	return "?"
+}
+
+// orderByClause renders an ORDER BY clause, rejecting unknown fields
+func orderByClause(sort []models.SortKey) (string, error) {
+	if len(sort) == 0 {
+		return "", nil
+	}
+
+	terms := make([]string, len(sort))
+	for i, key := range sort {
+		expr, ok := sortExpressions[key.Field]
+		if !ok {
+			return "", fmt.Errorf("unsupported sort field %q", key.Field)
+		}
+		terms[i] = expr + " ASC"
+		if key.Desc {
+			terms[i] = expr + " DESC"
+		}
+	}
+	return ` ORDER BY ` + strings.Join(terms, `, `), nil
+}
+
+// afterCondition builds the keyset predicate matching rows that sort strictly after
+// the given values. Mixed directions rule out a row-value comparison, so it expands to
+// (k1 > v1) OR (k1 = v1 AND k2 < v2) OR ...
+func afterCondition(sort []models.SortKey, values []string) (string, []interface{}, error) {
+	if len(values) != len(sort) {
+		return "", nil, fmt.Errorf("cursor has %d values for %d sort keys", len(values), len(sort))
+	}
+
+	var alternatives []string
+	var args []interface{}
+	for i, key := range sort {
+		var terms []string
+		for j := 0; j < i; j++ {
+			terms = append(terms, sortExpressions[sort[j].Field]+` = `+sortPlaceholder(sort[j].Field))
+			args = append(args, values[j])
+		}
+
+		op := ` > `
+		if key.Desc {
+			op = ` < `
+		}
+		terms = append(terms, sortExpressions[key.Field]+op+sortPlaceholder(key.Field))
+		args = append(args, values[i])
+
+		alternatives = append(alternatives, `(`+strings.Join(terms, ` AND `)+`)`)
+	}
+
+	return `(` + strings.Join(alternatives, ` OR `) + `)`, args, nil
+}
+
+// CursorValues returns the sort-key values of an expense, in the form afterCondition
+// compares them against
+func CursorValues(expense models.Expense, sort []models.SortKey) []string {
+	values := make([]string, len(sort))
+	for i, key := range sort {
+		switch key.Field {
+		case "date":
+			values[i] = expense.Date
+		case "amount":
+			if amount, err := money.Parse(expense.Amount); err == nil {
+				values[i] = AmountKey(amount)
+			}
+		case "created_at":
+			values[i] = formatTimestamp(expense.CreatedAt)
+		case "category":
+			values[i] = expense.Category
+		case "id":
+			values[i] = expense.ID
+		case "relevance":
+			if expense.Relevance != nil {
+				values[i] = strconv.FormatFloat(*expense.Relevance, 'g', -1, 64)
+			}
+		}
+	}
+	return values
+}
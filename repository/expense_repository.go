@@ -2,67 +2,298 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fenmo-ai-assignment/models"
 	"time"
+
+	"github.com/mattn/go-sqlite3"
 )
 
+// ErrNotFound is returned when no expense matches the given ID
+var ErrNotFound = errors.New("expense not found")
+
+// ErrOccurrenceExists is returned when a recurring expense already generated an
+// expense for the same date
+var ErrOccurrenceExists = errors.New("this occurrence of the recurring expense already exists")
+
+// expenseColumns is the column list shared by every expense SELECT
+const expenseColumns = `id, amount, currency, category, description, date, created_at, deleted_at, recurring_id`
+
+// expenseColumnCount is the number of columns in expenseColumns
+const expenseColumnCount = 9
+
 // ExpenseRepository handles database operations for expenses
 type ExpenseRepository struct {
-	db *sql.DB
+	db       *sql.DB
+	fullText bool // The expenses_fts index exists, so description queries use FTS5
 }
 
-// NewExpenseRepository creates a new expense repository
+// NewExpenseRepository creates a new expense repository. Description queries use the
+// FTS5 index when it exists and this SQLite build can read it, and fall back to
+// substring matching otherwise.
 func NewExpenseRepository(db *sql.DB) *ExpenseRepository {
-	return &ExpenseRepository{db: db}
+	rows, err := db.Query(`SELECT expense_id FROM expenses_fts LIMIT 0`)
+	if err == nil {
+		rows.Close()
+	}
+	return &ExpenseRepository{db: db, fullText: err == nil}
+}
+
+// FullTextSearch reports whether description queries run against the FTS5 index and
+// can be ranked by relevance
+func (r *ExpenseRepository) FullTextSearch() bool {
+	return r.fullText
 }
 
-// Create creates a new expense in the database
+// Create creates a new expense in the database together with its tags
 func (r *ExpenseRepository) Create(expense *models.Expense) error {
 	query := `
-		INSERT INTO expenses (id, amount, category, description, date, created_at)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO expenses (id, amount, currency, category, description, date, created_at, recurring_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := r.db.Exec(
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
 		query,
 		expense.ID,
 		expense.Amount,
+		expense.Currency,
 		expense.Category,
 		expense.Description,
 		expense.Date,
 		expense.CreatedAt,
+		expense.RecurringID,
 	)
+	if isUniqueViolation(err) {
+		return ErrOccurrenceExists
+	}
+	if err != nil {
+		return err
+	}
 
-	return err
+	if err := setExpenseTags(tx, expense.ID, expense.Tags); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-// GetAll retrieves all expenses from the database
-func (r *ExpenseRepository) GetAll() ([]models.Expense, error) {
-	query := `SELECT id, amount, category, description, date, created_at FROM expenses`
-	return r.queryExpenses(query)
+// GetByID retrieves a single non-deleted expense by its ID
+func (r *ExpenseRepository) GetByID(id string) (*models.Expense, error) {
+	query := `SELECT ` + expenseColumns + ` FROM expenses WHERE id = ? AND deleted_at IS NULL`
+	expenses, err := r.queryExpenses(query, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(expenses) == 0 {
+		return nil, ErrNotFound
+	}
+	return &expenses[0], nil
+}
+
+// Update overwrites the mutable fields and tags of an existing, non-deleted expense
+func (r *ExpenseRepository) Update(expense *models.Expense) error {
+	query := `
+		UPDATE expenses
+		SET amount = ?, currency = ?, category = ?, description = ?, date = ?
+		WHERE id = ? AND deleted_at IS NULL
+	`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		query,
+		expense.Amount,
+		expense.Currency,
+		expense.Category,
+		expense.Description,
+		expense.Date,
+		expense.ID,
+	)
+	if err != nil {
+		return err
+	}
+	if err := checkRowsAffected(result); err != nil {
+		return err
+	}
+
+	if err := setExpenseTags(tx, expense.ID, expense.Tags); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-// GetByCategory retrieves expenses filtered by category
-func (r *ExpenseRepository) GetByCategory(category string) ([]models.Expense, error) {
-	query := `SELECT id, amount, category, description, date, created_at FROM expenses WHERE category = ?`
-	return r.queryExpenses(query, category)
+// Delete soft-deletes an expense by stamping deleted_at
+func (r *ExpenseRepository) Delete(id string) error {
+	result, err := r.db.Exec(
+		`UPDATE expenses SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`,
+		time.Now().UTC(),
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return checkRowsAffected(result)
 }
 
-// GetAllSortedByDateDesc retrieves all expenses sorted by date descending
-func (r *ExpenseRepository) GetAllSortedByDateDesc() ([]models.Expense, error) {
-	query := `SELECT id, amount, category, description, date, created_at 
+// GetDeleted retrieves soft-deleted expenses, most recently deleted first
+func (r *ExpenseRepository) GetDeleted() ([]models.Expense, error) {
+	query := `SELECT ` + expenseColumns + ` 
 			  FROM expenses 
-			  ORDER BY date DESC, created_at DESC`
+			  WHERE deleted_at IS NOT NULL 
+			  ORDER BY deleted_at DESC`
 	return r.queryExpenses(query)
 }
 
-// GetByCategorySortedByDateDesc retrieves expenses filtered by category and sorted by date descending
-func (r *ExpenseRepository) GetByCategorySortedByDateDesc(category string) ([]models.Expense, error) {
-	query := `SELECT id, amount, category, description, date, created_at 
-			  FROM expenses 
-			  WHERE category = ? 
-			  ORDER BY date DESC, created_at DESC`
-	return r.queryExpenses(query, category)
+// Restore clears deleted_at on a soft-deleted expense
+func (r *ExpenseRepository) Restore(id string) error {
+	result, err := r.db.Exec(
+		`UPDATE expenses SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL`,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return checkRowsAffected(result)
+}
+
+// PurgeDeletedBefore permanently removes expenses soft-deleted before the cutoff,
+// with their tag links, and returns the number of expenses removed
+func (r *ExpenseRepository) PurgeDeletedBefore(cutoff time.Time) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`DELETE FROM expense_tags WHERE expense_id IN (SELECT id FROM expenses WHERE deleted_at IS NOT NULL AND deleted_at < ?)`,
+		cutoff.UTC(),
+	); err != nil {
+		return 0, err
+	}
+	result, err := tx.Exec(
+		`DELETE FROM expenses WHERE deleted_at IS NOT NULL AND deleted_at < ?`,
+		cutoff.UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, tx.Commit()
+}
+
+// RewriteCategory moves every expense, including those in the trash, from the given
+// category names to another and returns the number moved
+func (r *ExpenseRepository) RewriteCategory(from []string, to string) (int, error) {
+	if len(from) == 0 {
+		return 0, nil
+	}
+	args := append([]interface{}{to}, stringArgs(from)...)
+	result, err := r.db.Exec(`UPDATE expenses SET category = ? WHERE category IN (`+placeholders(len(from))+`)`, args...)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+// CountByCategory counts the expenses, including those in the trash, filed under a
+// category name
+func (r *ExpenseRepository) CountByCategory(name string) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM expenses WHERE category = ?`, name).Scan(&count)
+	return count, err
+}
+
+// checkRowsAffected returns ErrNotFound when a write statement matched no rows
+func checkRowsAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// isUniqueViolation reports whether err is a UNIQUE constraint failure
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
+// List retrieves the expenses matching the filter in the given order.
+// With no sort keys the order is unspecified.
+func (r *ExpenseRepository) List(filter models.ExpenseFilter, sort []models.SortKey) ([]models.Expense, error) {
+	orderBy, err := orderByClause(sort)
+	if err != nil {
+		return nil, err
+	}
+
+	q := newExpenseQuery(filter, r.fullText)
+	query := `SELECT ` + expenseColumns + q.searchColumns() + q.from() + orderBy
+	return r.queryExpenses(query, q.args...)
+}
+
+// GetPage retrieves up to limit expenses matching the filter in the given order,
+// starting after the row whose sort-key values are given (nil for the first page).
+// The sort must end in a unique key such as id for pages to be stable.
+func (r *ExpenseRepository) GetPage(filter models.ExpenseFilter, sort []models.SortKey, after []string, limit int) ([]models.Expense, error) {
+	orderBy, err := orderByClause(sort)
+	if err != nil {
+		return nil, err
+	}
+
+	q := newExpenseQuery(filter, r.fullText)
+	if after != nil {
+		condition, args, err := afterCondition(sort, after)
+		if err != nil {
+			return nil, err
+		}
+		q.where(condition, args...)
+	}
+
+	query := `SELECT ` + expenseColumns + q.searchColumns() + q.from() + orderBy + ` LIMIT ?`
+	return r.queryExpenses(query, append(q.args, limit)...)
+}
+
+// ForEachAmount streams the amount, currency, date and category of every expense
+// matching the filter, so aggregates can be computed without loading whole rows
+func (r *ExpenseRepository) ForEachAmount(filter models.ExpenseFilter, fn func(models.AmountEntry) error) error {
+	q := newExpenseQuery(filter, r.fullText)
+	rows, err := r.db.Query(`SELECT amount, currency, date, category`+q.from(), q.args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry models.AmountEntry
+		if err := rows.Scan(&entry.Amount, &entry.Currency, &entry.Date, &entry.Category); err != nil {
+			return err
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
 }
 
 // queryExpenses executes a query and returns expenses
@@ -73,42 +304,58 @@ func (r *ExpenseRepository) queryExpenses(query string, args ...interface{}) ([]
 	}
 	defer rows.Close()
 
+	// Listings also select the relevance and snippet of a full-text search
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	withSearch := len(columns) > expenseColumnCount
+
 	var expenses []models.Expense
 	for rows.Next() {
 		var expense models.Expense
 		var createdAtStr string
+		var deletedAtStr sql.NullString
+		var recurringID sql.NullString
+		var relevance sql.NullFloat64
+		var snippet sql.NullString
 
-		err := rows.Scan(
+		dest := []interface{}{
 			&expense.ID,
 			&expense.Amount,
+			&expense.Currency,
 			&expense.Category,
 			&expense.Description,
 			&expense.Date,
 			&createdAtStr,
-		)
-		if err != nil {
+			&deletedAtStr,
+			&recurringID,
+		}
+		if withSearch {
+			dest = append(dest, &relevance, &snippet)
+		}
+		if err := rows.Scan(dest...); err != nil {
 			return nil, err
 		}
+		if relevance.Valid {
+			expense.Relevance = &relevance.Float64
+			expense.Snippet = snippet.String
+		}
 
-		// Parse created_at timestamp - SQLite DATETIME format
-		// Try multiple formats
-		formats := []string{
-			"2006-01-02 15:04:05",
-			"2006-01-02T15:04:05Z",
-			"2006-01-02T15:04:05Z07:00",
-			time.RFC3339,
+		if parsed, ok := parseTimestamp(createdAtStr); ok {
+			expense.CreatedAt = parsed
+		} else {
+			expense.CreatedAt = time.Now()
 		}
-		
-		var parsed bool
-		for _, format := range formats {
-			if expense.CreatedAt, err = time.Parse(format, createdAtStr); err == nil {
-				parsed = true
-				break
+
+		if deletedAtStr.Valid {
+			if parsed, ok := parseTimestamp(deletedAtStr.String); ok {
+				expense.DeletedAt = &parsed
 			}
 		}
-		
-		if !parsed {
-			expense.CreatedAt = time.Now()
+
+		if recurringID.Valid {
+			expense.RecurringID = &recurringID.String
 		}
 
 		expenses = append(expenses, expense)
@@ -118,5 +365,35 @@ func (r *ExpenseRepository) queryExpenses(query string, args ...interface{}) ([]
 		return nil, err
 	}
 
+	if err := r.loadTags(expenses); err != nil {
+		return nil, err
+	}
 	return expenses, nil
 }
+
+// sqliteTimestampFormat is the layout go-sqlite3 uses when storing time.Time values
+const sqliteTimestampFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+// formatTimestamp renders a time exactly as go-sqlite3 stored it, so it can be
+// compared against DATETIME columns as text
+func formatTimestamp(t time.Time) string {
+	return t.Format(sqliteTimestampFormat)
+}
+
+// parseTimestamp parses a SQLite DATETIME value, trying multiple formats
+func parseTimestamp(value string) (time.Time, bool) {
+	formats := []string{
+		"2006-01-02 15:04:05",
+		"2006-01-02T15:04:05Z",
+		"2006-01-02T15:04:05Z07:00",
+		time.RFC3339,
+	}
+
+	for _, format := range formats {
+		if parsed, err := time.Parse(format, value); err == nil {
+			return parsed, true
+		}
+	}
+
+	return time.Time{}, false
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"strings"
+	"unicode"
+)
+
+// matchExpression turns a search box query into an FTS5 MATCH expression. All terms
+// must be present. Words match any token they start, so amaz matches Amazon as the
+// user types; "double quoted text" is matched as an exact phrase, or as a prefix with
+// a trailing *. Every term is quoted, so FTS5 operators and punctuation in the query
+// are searched for literally instead of causing syntax errors.
+func matchExpression(query string) string {
+	var terms []string
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		var text string
+		phrase := runes[i] == '"'
+		if phrase {
+			// A phrase runs to the closing quote, or to the end of the query
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			text = string(runes[i+1 : end])
+			i = min(end+1, len(runes))
+		} else {
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && runes[end] != '"' {
+				end++
+			}
+			text = string(runes[i:end])
+			i = end
+		}
+
+		text = strings.TrimSpace(text)
+		prefix := !phrase || strings.HasSuffix(text, "*")
+		if i < len(runes) && runes[i] == '*' {
+			prefix = true
+			i++
+		}
+		text = strings.TrimSpace(strings.TrimRight(text, "*"))
+		if text == "" {
+			continue
+		}
+
+		term := `"` + strings.ReplaceAll(text, `"`, `""`) + `"`
+		if prefix {
+			term += "*"
+		}
+		terms = append(terms, term)
+	}
+
+	if len(terms) == 0 {
+		// Nothing searchable, such as a lone quote: match no expense
+		return `""`
+	}
+	return strings.Join(terms, " AND ")
+}
@@ -0,0 +1,29 @@
+package repository
+
+import "testing"
+
+func TestMatchExpression(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"word matches as prefix", "amaz", `"amaz"*`},
+		{"words are all required", "amazon order", `"amazon"* AND "order"*`},
+		{"explicit prefix", "amaz*", `"amaz"*`},
+		{"phrase is exact", `"amazon order" march`, `"amazon order" AND "march"*`},
+		{"prefix phrase", `"amazon ord"*`, `"amazon ord"*`},
+		{"unterminated phrase", `"amazon order`, `"amazon order"`},
+		{"operators are literal", "tea OR coffee", `"tea"* AND "OR"* AND "coffee"*`},
+		{"quotes inside words", `o"brien`, `"o"* AND "brien"`},
+		{"nothing searchable", `" * "`, `""`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchExpression(tt.query); got != tt.want {
+				t.Errorf("matchExpression(%q) = %s, want %s", tt.query, got, tt.want)
+			}
+		})
+	}
+}
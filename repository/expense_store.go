@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"fenmo-ai-assignment/models"
+	"time"
+)
+
+// ExpenseStore is the storage backend for expenses. Every implementation must pass
+// the conformance tests in expense_store_test.go.
+type ExpenseStore interface {
+	// FullTextSearch reports whether description queries are full-text searches that
+	// can be ranked by relevance, rather than substring matches
+	FullTextSearch() bool
+
+	// Create stores a new expense with its tags. It returns ErrOccurrenceExists when
+	// the expense's recurring template already has an expense on the same date.
+	Create(expense *models.Expense) error
+	// GetByID retrieves a non-deleted expense, or returns ErrNotFound
+	GetByID(id string) (*models.Expense, error)
+	// Update overwrites the mutable fields and tags of a non-deleted expense
+	Update(expense *models.Expense) error
+	// Delete moves an expense to the trash
+	Delete(id string) error
+	// GetDeleted lists the trash, most recently deleted first
+	GetDeleted() ([]models.Expense, error)
+	// Restore takes an expense out of the trash
+	Restore(id string) error
+	// PurgeDeletedBefore permanently removes expenses deleted before the cutoff and
+	// returns how many were removed
+	PurgeDeletedBefore(cutoff time.Time) (int64, error)
+
+	// List retrieves the non-deleted expenses matching the filter in the given order.
+	// With no sort keys the order is unspecified.
+	List(filter models.ExpenseFilter, sort []models.SortKey) ([]models.Expense, error)
+	// GetPage retrieves up to limit expenses after the row with the given CursorValues
+	// (nil for the first page)
+	GetPage(filter models.ExpenseFilter, sort []models.SortKey, after []string, limit int) ([]models.Expense, error)
+	// ForEachAmount streams the amount projection of every matching expense
+	ForEachAmount(filter models.ExpenseFilter, fn func(models.AmountEntry) error) error
+
+	// SumByCategory totals the matching expenses per category and currency
+	SumByCategory(filter models.ExpenseFilter) ([]AmountGroup, error)
+	// SumByDate totals the matching expenses per day and currency, and with
+	// byCategory per category within each day
+	SumByDate(filter models.ExpenseFilter, byCategory bool) ([]AmountGroup, error)
+	// SumByTag totals the matching expenses per tag and currency
+	SumByTag(filter models.ExpenseFilter) ([]AmountGroup, error)
+
+	// RewriteCategory files every expense under one of the from categories, including
+	// those in the trash, under category to instead, and returns how many were changed
+	RewriteCategory(from []string, to string) (int, error)
+	// CountByCategory counts the expenses, including those in the trash, filed under
+	// a category name
+	CountByCategory(name string) (int, error)
+
+	TagStore
+}
+
+// TagStore holds the tags of an expense store. Tags are created implicitly when an
+// expense first uses them.
+type TagStore interface {
+	// ListTags retrieves every tag ordered by name, each with the number of expenses
+	// outside the trash carrying it
+	ListTags() ([]models.Tag, error)
+	// GetTag retrieves a single tag with its expense count, or returns ErrTagNotFound
+	GetTag(id string) (*models.Tag, error)
+	// RenameTag changes the name of a tag. It returns ErrTagExists when another tag
+	// has the name.
+	RenameTag(id, name string) error
+	// MergeTags moves the source tags onto the target atomically and deletes the
+	// sources. It returns the number of expenses, including those in the trash, that
+	// gained the target tag.
+	MergeTags(sourceIDs []string, targetID string) (int, error)
+	// DeleteTag removes a tag from every expense and deletes it
+	DeleteTag(id string) error
+}
+
+// CategoryTree resolves category names to the names of their subcategories, for
+// stores that cannot walk the categories table themselves
+type CategoryTree interface {
+	// Descendants returns the given category names together with the names of all
+	// their subcategories, at any depth
+	Descendants(names []string) ([]string, error)
+}
+
+var (
+	_ ExpenseStore = (*ExpenseRepository)(nil)
+	_ ExpenseStore = (*MemoryExpenseStore)(nil)
+	_ ExpenseStore = (*PostgresExpenseRepository)(nil)
+	_ CategoryTree = (*CategoryRepository)(nil)
+)
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"database/sql"
+	"fenmo-ai-assignment/models"
+	"fenmo-ai-assignment/utils"
+	"time"
+)
+
+// tagBatchSize bounds the number of expense IDs bound into one tag lookup, keeping
+// well below SQLite's limit on bind parameters
+const tagBatchSize = 500
+
+// setExpenseTags replaces the tags of an expense, creating tags that do not exist yet.
+// Tag names must already be normalised.
+func setExpenseTags(tx *sql.Tx, expenseID string, tags []string) error {
+	if _, err := tx.Exec(`DELETE FROM expense_tags WHERE expense_id = ?`, expenseID); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for _, name := range tags {
+		if _, err := tx.Exec(
+			`INSERT INTO tags (id, name, created_at) VALUES (?, ?, ?) ON CONFLICT (name) DO NOTHING`,
+			utils.GenerateUUID(), name, now,
+		); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(
+			`INSERT INTO expense_tags (expense_id, tag_id) SELECT ?, id FROM tags WHERE name = ?`,
+			expenseID, name,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListTags retrieves every tag ordered by name, each with its expense count
+func (r *ExpenseRepository) ListTags() ([]models.Tag, error) {
+	return NewTagRepository(r.db).List()
+}
+
+// GetTag retrieves a single tag by its ID
+func (r *ExpenseRepository) GetTag(id string) (*models.Tag, error) {
+	return NewTagRepository(r.db).GetByID(id)
+}
+
+// RenameTag changes the name of a tag
+func (r *ExpenseRepository) RenameTag(id, name string) error {
+	return NewTagRepository(r.db).Rename(id, name)
+}
+
+// MergeTags moves the source tags onto the target in one transaction and deletes the
+// sources, returning the number of expenses that gained the target tag
+func (r *ExpenseRepository) MergeTags(sourceIDs []string, targetID string) (int, error) {
+	return NewTagRepository(r.db).Merge(sourceIDs, targetID)
+}
+
+// DeleteTag removes a tag from every expense and deletes it
+func (r *ExpenseRepository) DeleteTag(id string) error {
+	return NewTagRepository(r.db).Delete(id)
+}
+
+// loadTags fills in the tags of each expense, sorted by name. Expenses without tags
+// get an empty list.
+func (r *ExpenseRepository) loadTags(expenses []models.Expense) error {
+	index := make(map[string]*models.Expense, len(expenses))
+	for i := range expenses {
+		expenses[i].Tags = []string{}
+		index[expenses[i].ID] = &expenses[i]
+	}
+
+	for start := 0; start < len(expenses); start += tagBatchSize {
+		end := min(start+tagBatchSize, len(expenses))
+		ids := make([]string, 0, end-start)
+		for _, expense := range expenses[start:end] {
+			ids = append(ids, expense.ID)
+		}
+
+		rows, err := r.db.Query(`
+			SELECT et.expense_id, t.name FROM expense_tags et
+			JOIN tags t ON t.id = et.tag_id
+			WHERE et.expense_id IN (`+placeholders(len(ids))+`)
+			ORDER BY t.name`,
+			stringArgs(ids)...,
+		)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var expenseID, name string
+			if err := rows.Scan(&expenseID, &name); err != nil {
+				rows.Close()
+				return err
+			}
+			if expense := index[expenseID]; expense != nil {
+				expense.Tags = append(expense.Tags, name)
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"database/sql"
+	"fenmo-ai-assignment/models"
+)
+
+// FXRateRepository handles database operations for exchange rates
+type FXRateRepository struct {
+	db *sql.DB
+}
+
+// NewFXRateRepository creates a new FX rate repository
+func NewFXRateRepository(db *sql.DB) *FXRateRepository {
+	return &FXRateRepository{db: db}
+}
+
+// Upsert stores rates in a single transaction, replacing any existing rate
+// for the same base, currency and date
+func (r *FXRateRepository) Upsert(rates []models.FXRate) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO fx_rates (base, currency, date, rate)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (base, currency, date) DO UPDATE SET rate = excluded.rate
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, rate := range rates {
+		if _, err := stmt.Exec(rate.Base, rate.Currency, rate.Date, rate.Rate); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetBases returns the distinct base currencies that have rates
+func (r *FXRateRepository) GetBases() ([]string, error) {
+	rows, err := r.db.Query(`SELECT DISTINCT base FROM fx_rates ORDER BY base`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bases []string
+	for rows.Next() {
+		var base string
+		if err := rows.Scan(&base); err != nil {
+			return nil, err
+		}
+		bases = append(bases, base)
+	}
+
+	return bases, rows.Err()
+}
+
+// GetEffective returns the rate for a currency on the given date, or the
+// nearest earlier rate when none was published that day
+func (r *FXRateRepository) GetEffective(base, currency, date string) (*models.FXRate, error) {
+	var rate models.FXRate
+
+	err := r.db.QueryRow(`
+		SELECT base, currency, date, rate
+		FROM fx_rates
+		WHERE base = ? AND currency = ? AND date <= ?
+		ORDER BY date DESC
+		LIMIT 1
+	`, base, currency, date).Scan(&rate.Base, &rate.Currency, &rate.Date, &rate.Rate)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &rate, nil
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"database/sql"
+	"fenmo-ai-assignment/models"
+	"time"
+)
+
+// IdempotencyRepository handles database operations for idempotency keys
+type IdempotencyRepository struct {
+	db *sql.DB
+}
+
+// NewIdempotencyRepository creates a new idempotency repository
+func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Reserve inserts a pending record for the key. It returns false without error
+// when a record for the key already exists.
+func (r *IdempotencyRepository) Reserve(key, requestHash string, createdAt time.Time) (bool, error) {
+	result, err := r.db.Exec(
+		`INSERT OR IGNORE INTO idempotency_keys (key, request_hash, created_at) VALUES (?, ?, ?)`,
+		key,
+		requestHash,
+		createdAt.UTC(),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// Get retrieves the record stored for a key
+func (r *IdempotencyRepository) Get(key string) (*models.IdempotencyRecord, error) {
+	var record models.IdempotencyRecord
+	var createdAtStr string
+
+	err := r.db.QueryRow(
+		`SELECT key, request_hash, status_code, response_body, created_at FROM idempotency_keys WHERE key = ?`,
+		key,
+	).Scan(&record.Key, &record.RequestHash, &record.StatusCode, &record.ResponseBody, &createdAtStr)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	record.CreatedAt, _ = parseTimestamp(createdAtStr)
+	return &record, nil
+}
+
+// Complete stores the final response for a reserved key
+func (r *IdempotencyRepository) Complete(key string, statusCode int, responseBody string) error {
+	result, err := r.db.Exec(
+		`UPDATE idempotency_keys SET status_code = ?, response_body = ? WHERE key = ?`,
+		statusCode,
+		responseBody,
+		key,
+	)
+	if err != nil {
+		return err
+	}
+
+	return checkRowsAffected(result)
+}
+
+// Delete removes the record for a key
+func (r *IdempotencyRepository) Delete(key string) error {
+	_, err := r.db.Exec(`DELETE FROM idempotency_keys WHERE key = ?`, key)
+	return err
+}
+
+// DeleteCreatedBefore removes all records created before the cutoff
+func (r *IdempotencyRepository) DeleteCreatedBefore(cutoff time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM idempotency_keys WHERE created_at < ?`, cutoff.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fenmo-ai-assignment/models"
+)
+
+// ErrRecurringNotFound is returned when no recurring expense matches the given ID
+var ErrRecurringNotFound = errors.New("recurring expense not found")
+
+// recurringColumns is the column list shared by every recurring expense SELECT
+const recurringColumns = `id, amount, currency, category, description, frequency, repeat_interval, month_day,
+	weekday, start_date, end_date, next_date, last_generated, created_at, updated_at`
+
+// RecurringRepository handles database operations for recurring expense templates
+type RecurringRepository struct {
+	db *sql.DB
+}
+
+// NewRecurringRepository creates a new recurring expense repository
+func NewRecurringRepository(db *sql.DB) *RecurringRepository {
+	return &RecurringRepository{db: db}
+}
+
+// Create inserts a new recurring expense
+func (r *RecurringRepository) Create(recurring *models.RecurringExpense) error {
+	_, err := r.db.Exec(
+		`INSERT INTO recurring_expenses (`+recurringColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		recurring.ID,
+		recurring.Amount,
+		recurring.Currency,
+		recurring.Category,
+		recurring.Description,
+		recurring.Frequency,
+		recurring.Interval,
+		recurring.MonthDay,
+		recurring.Weekday,
+		recurring.StartDate,
+		recurring.EndDate,
+		recurring.NextDate,
+		recurring.LastGenerated,
+		recurring.CreatedAt.UTC(),
+		recurring.UpdatedAt.UTC(),
+	)
+	return err
+}
+
+// GetByID retrieves a single recurring expense by its ID
+func (r *RecurringRepository) GetByID(id string) (*models.RecurringExpense, error) {
+	templates, err := r.queryRecurring(`SELECT `+recurringColumns+` FROM recurring_expenses WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(templates) == 0 {
+		return nil, ErrRecurringNotFound
+	}
+	return &templates[0], nil
+}
+
+// List retrieves every recurring expense ordered by next occurrence
+func (r *RecurringRepository) List() ([]models.RecurringExpense, error) {
+	return r.queryRecurring(`SELECT ` + recurringColumns + ` FROM recurring_expenses ORDER BY next_date = '', next_date, created_at`)
+}
+
+// ListDue retrieves the recurring expenses with an occurrence on or before a date
+func (r *RecurringRepository) ListDue(date string) ([]models.RecurringExpense, error) {
+	return r.queryRecurring(
+		`SELECT `+recurringColumns+` FROM recurring_expenses WHERE next_date != '' AND next_date <= ? ORDER BY next_date`,
+		date,
+	)
+}
+
+// Update overwrites the template and schedule of an existing recurring expense
+func (r *RecurringRepository) Update(recurring *models.RecurringExpense) error {
+	result, err := r.db.Exec(`
+		UPDATE recurring_expenses
+		SET amount = ?, currency = ?, category = ?, description = ?, frequency = ?, repeat_interval = ?,
+			month_day = ?, weekday = ?, start_date = ?, end_date = ?, next_date = ?, updated_at = ?
+		WHERE id = ?
+	`,
+		recurring.Amount,
+		recurring.Currency,
+		recurring.Category,
+		recurring.Description,
+		recurring.Frequency,
+		recurring.Interval,
+		recurring.MonthDay,
+		recurring.Weekday,
+		recurring.StartDate,
+		recurring.EndDate,
+		recurring.NextDate,
+		recurring.UpdatedAt.UTC(),
+		recurring.ID,
+	)
+	if err != nil {
+		return err
+	}
+	return checkRecurringAffected(result)
+}
+
+// Advance records that occurrences up to lastGenerated were generated and moves the
+// schedule on to nextDate
+func (r *RecurringRepository) Advance(id, nextDate, lastGenerated string) error {
+	result, err := r.db.Exec(
+		`UPDATE recurring_expenses SET next_date = ?, last_generated = ? WHERE id = ?`,
+		nextDate,
+		lastGenerated,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+	return checkRecurringAffected(result)
+}
+
+// Delete removes a recurring expense. Expenses already generated from it are kept.
+func (r *RecurringRepository) Delete(id string) error {
+	result, err := r.db.Exec(`DELETE FROM recurring_expenses WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	return checkRecurringAffected(result)
+}
+
+// queryRecurring executes a query and returns recurring expenses
+func (r *RecurringRepository) queryRecurring(query string, args ...interface{}) ([]models.RecurringExpense, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []models.RecurringExpense
+	for rows.Next() {
+		var recurring models.RecurringExpense
+		var createdAtStr, updatedAtStr string
+		if err := rows.Scan(
+			&recurring.ID,
+			&recurring.Amount,
+			&recurring.Currency,
+			&recurring.Category,
+			&recurring.Description,
+			&recurring.Frequency,
+			&recurring.Interval,
+			&recurring.MonthDay,
+			&recurring.Weekday,
+			&recurring.StartDate,
+			&recurring.EndDate,
+			&recurring.NextDate,
+			&recurring.LastGenerated,
+			&createdAtStr,
+			&updatedAtStr,
+		); err != nil {
+			return nil, err
+		}
+		recurring.CreatedAt, _ = parseTimestamp(createdAtStr)
+		recurring.UpdatedAt, _ = parseTimestamp(updatedAtStr)
+		templates = append(templates, recurring)
+	}
+
+	return templates, rows.Err()
+}
+
+// checkRecurringAffected maps a statement that touched no rows to ErrRecurringNotFound
+func checkRecurringAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrRecurringNotFound
+	}
+	return nil
+}
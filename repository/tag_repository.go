@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fenmo-ai-assignment/models"
+)
+
+// ErrTagNotFound is returned when no tag matches the given ID
+var ErrTagNotFound = errors.New("tag not found")
+
+// ErrTagExists is returned when a tag with the same name already exists
+var ErrTagExists = errors.New("a tag with this name already exists")
+
+// TagRepository handles database operations for tags. Tags are created implicitly
+// when an expense first uses them.
+type TagRepository struct {
+	db *sql.DB
+}
+
+// NewTagRepository creates a new tag repository
+func NewTagRepository(db *sql.DB) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+// GetByID retrieves a single tag by its ID
+func (r *TagRepository) GetByID(id string) (*models.Tag, error) {
+	tags, err := r.queryTags(`WHERE t.id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) == 0 {
+		return nil, ErrTagNotFound
+	}
+	return &tags[0], nil
+}
+
+// List retrieves every tag ordered by name
+func (r *TagRepository) List() ([]models.Tag, error) {
+	return r.queryTags(``)
+}
+
+// Rename changes the name of a tag
+func (r *TagRepository) Rename(id, name string) error {
+	result, err := r.db.Exec(`UPDATE tags SET name = ? WHERE id = ?`, name, id)
+	if isUniqueViolation(err) {
+		return ErrTagExists
+	}
+	if err != nil {
+		return err
+	}
+	return checkTagAffected(result)
+}
+
+// Merge moves the source tags onto the target in one transaction and deletes the
+// sources. It returns the number of expenses that gained the target tag; expenses
+// that already carried it are not counted.
+func (r *TagRepository) Merge(sourceIDs []string, targetID string) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	retagged := 0
+	for _, id := range sourceIDs {
+		result, err := tx.Exec(`
+			INSERT OR IGNORE INTO expense_tags (expense_id, tag_id)
+			SELECT expense_id, ? FROM expense_tags WHERE tag_id = ?`,
+			targetID, id,
+		)
+		if err != nil {
+			return 0, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		retagged += int(affected)
+
+		if err := deleteTag(tx, id); err != nil {
+			return 0, err
+		}
+	}
+
+	return retagged, tx.Commit()
+}
+
+// Delete removes a tag from every expense and deletes it
+func (r *TagRepository) Delete(id string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteTag(tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// deleteTag removes a tag and its links to expenses
+func deleteTag(tx *sql.Tx, id string) error {
+	if _, err := tx.Exec(`DELETE FROM expense_tags WHERE tag_id = ?`, id); err != nil {
+		return err
+	}
+	result, err := tx.Exec(`DELETE FROM tags WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	return checkTagAffected(result)
+}
+
+// queryTags retrieves tags, with the number of expenses outside the trash carrying
+// each, restricted by an optional WHERE clause on the tags table aliased t
+func (r *TagRepository) queryTags(where string, args ...interface{}) ([]models.Tag, error) {
+	rows, err := r.db.Query(`
+		SELECT t.id, t.name, t.created_at,
+			(SELECT COUNT(*) FROM expense_tags et JOIN expenses e ON e.id = et.expense_id
+			 WHERE et.tag_id = t.id AND e.deleted_at IS NULL)
+		FROM tags t `+where+` ORDER BY t.name`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []models.Tag
+	for rows.Next() {
+		var tag models.Tag
+		var createdAtStr string
+		if err := rows.Scan(&tag.ID, &tag.Name, &createdAtStr, &tag.ExpenseCount); err != nil {
+			return nil, err
+		}
+		tag.CreatedAt, _ = parseTimestamp(createdAtStr)
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// checkTagAffected maps a statement that touched no rows to ErrTagNotFound
+func checkTagAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrTagNotFound
+	}
+	return nil
+}
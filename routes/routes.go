@@ -1,26 +1,26 @@
 package routes
 
 import (
-	"fenmo-ai-assignment/database"
 	"fenmo-ai-assignment/handler"
 	"fenmo-ai-assignment/middleware"
-	"fenmo-ai-assignment/repository"
-	"fenmo-ai-assignment/service"
 
 	"github.com/gin-gonic/gin"
 )
 
-// SetupRoutes configures all routes
-func SetupRoutes() *gin.Engine {
-	// Create repository
-	expenseRepo := repository.NewExpenseRepository(database.DB)
-
-	// Create service
-	expenseService := service.NewExpenseService(expenseRepo)
-
-	// Create handler
-	expenseHandler := handler.NewExpenseHandler(expenseService)
+// Handlers are the HTTP handlers the routes dispatch to
+type Handlers struct {
+	Expense   *handler.ExpenseHandler
+	FX        *handler.FXHandler
+	Summary   *handler.SummaryHandler
+	Budget    *handler.BudgetHandler
+	Envelope  *handler.EnvelopeHandler
+	Recurring *handler.RecurringHandler
+	Category  *handler.CategoryHandler
+	Tag       *handler.TagHandler
+}
 
+// SetupRoutes configures all routes on a new router
+func SetupRoutes(h Handlers) *gin.Engine {
 	// Setup router
 	router := gin.Default()
 
@@ -32,8 +32,52 @@ func SetupRoutes() *gin.Engine {
 	// API routes
 	api := router.Group("/api")
 	{
-		api.POST("/expenses", expenseHandler.CreateExpense)
-		api.GET("/expenses", expenseHandler.GetExpenses)
+		api.POST("/expenses", h.Expense.CreateExpense)
+		api.GET("/expenses", h.Expense.GetExpenses)
+		api.GET("/expenses/:id", h.Expense.GetExpense)
+		api.PUT("/expenses/:id", h.Expense.UpdateExpense)
+		api.PATCH("/expenses/:id", h.Expense.PatchExpense)
+		api.DELETE("/expenses/:id", h.Expense.DeleteExpense)
+		api.POST("/expenses/:id/restore", h.Expense.RestoreExpense)
+		api.GET("/trash", h.Expense.GetTrash)
+		api.POST("/fx-rates/import", h.FX.ImportRates)
+		api.GET("/summary/categories", h.Summary.GetCategorySummary)
+		api.GET("/summary/tags", h.Summary.GetTagSummary)
+		api.GET("/summary/timeseries", h.Summary.GetTimeSeries)
+		api.POST("/budgets", h.Budget.CreateBudget)
+		api.GET("/budgets", h.Budget.GetBudgets)
+		api.GET("/budgets/status", h.Budget.GetStatus)
+		api.GET("/budgets/:id", h.Budget.GetBudget)
+		api.PUT("/budgets/:id", h.Budget.UpdateBudget)
+		api.DELETE("/budgets/:id", h.Budget.DeleteBudget)
+		api.POST("/envelopes", h.Envelope.CreateEnvelope)
+		api.GET("/envelopes", h.Envelope.GetEnvelopes)
+		api.GET("/envelopes/status", h.Envelope.GetStatus)
+		api.PUT("/envelopes/income", h.Envelope.SetIncome)
+		api.POST("/envelopes/transfers", h.Envelope.CreateTransfer)
+		api.GET("/envelopes/transfers", h.Envelope.GetTransfers)
+		api.GET("/envelopes/:id", h.Envelope.GetEnvelope)
+		api.PUT("/envelopes/:id", h.Envelope.UpdateEnvelope)
+		api.DELETE("/envelopes/:id", h.Envelope.DeleteEnvelope)
+		api.PUT("/envelopes/:id/allocations/:month", h.Envelope.SetAllocation)
+		api.POST("/recurring-expenses", h.Recurring.CreateRecurring)
+		api.GET("/recurring-expenses", h.Recurring.GetRecurringExpenses)
+		api.GET("/recurring-expenses/:id", h.Recurring.GetRecurring)
+		api.PUT("/recurring-expenses/:id", h.Recurring.UpdateRecurring)
+		api.DELETE("/recurring-expenses/:id", h.Recurring.DeleteRecurring)
+		api.POST("/categories", h.Category.CreateCategory)
+		api.GET("/categories", h.Category.GetCategories)
+		api.POST("/categories/merge", h.Category.MergeCategories)
+		api.GET("/categories/audit", h.Category.GetAudit)
+		api.GET("/categories/:id", h.Category.GetCategory)
+		api.PATCH("/categories/:id", h.Category.UpdateCategory)
+		api.DELETE("/categories/:id", h.Category.DeleteCategory)
+		api.POST("/categories/:id/rename", h.Category.RenameCategory)
+		api.GET("/tags", h.Tag.GetTags)
+		api.POST("/tags/merge", h.Tag.MergeTags)
+		api.GET("/tags/:id", h.Tag.GetTag)
+		api.DELETE("/tags/:id", h.Tag.DeleteTag)
+		api.POST("/tags/:id/rename", h.Tag.RenameTag)
 	}
 
 	// Serve frontend
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"fenmo-ai-assignment/models"
+	"fenmo-ai-assignment/money"
+	"fenmo-ai-assignment/repository"
+	"fenmo-ai-assignment/utils"
+	"strings"
+	"time"
+)
+
+// monthLayout is the format of budget months (YYYY-MM)
+const monthLayout = "2006-01"
+
+// percentageScale is the number of decimal places budget utilisation is reported with
+const percentageScale = 2
+
+// BudgetService handles business logic for budgets
+type BudgetService struct {
+	repo            *repository.BudgetRepository
+	expenses        repository.ExpenseStore
+	defaultCurrency string
+}
+
+// NewBudgetService creates a new budget service. Spend is read from the expense
+// repository; defaultCurrency is applied to budgets created without a currency.
+func NewBudgetService(repo *repository.BudgetRepository, expenses repository.ExpenseStore, defaultCurrency string) *BudgetService {
+	return &BudgetService{repo: repo, expenses: expenses, defaultCurrency: defaultCurrency}
+}
+
+// CreateBudget creates a new budget with validation
+func (s *BudgetService) CreateBudget(req models.BudgetRequest) (*models.Budget, error) {
+	now := time.Now()
+	budget := &models.Budget{
+		ID:        utils.GenerateUUID(),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.applyRequest(budget, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(budget); err != nil {
+		return nil, translateRepoError(err)
+	}
+	return budget, nil
+}
+
+// GetBudget retrieves a single budget by ID
+func (s *BudgetService) GetBudget(id string) (*models.Budget, error) {
+	budget, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	return budget, nil
+}
+
+// GetBudgets retrieves every budget
+func (s *BudgetService) GetBudgets() ([]models.Budget, error) {
+	return s.repo.List()
+}
+
+// UpdateBudget replaces all editable fields of an existing budget (PUT)
+func (s *BudgetService) UpdateBudget(id string, req models.BudgetRequest) (*models.Budget, error) {
+	budget, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	if err := s.applyRequest(budget, req); err != nil {
+		return nil, err
+	}
+	budget.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(budget); err != nil {
+		return nil, translateRepoError(err)
+	}
+	return budget, nil
+}
+
+// DeleteBudget removes a budget
+func (s *BudgetService) DeleteBudget(id string) error {
+	return translateRepoError(s.repo.Delete(id))
+}
+
+// GetStatus compares every budget applying to a month (YYYY-MM, default the current
+// month) with the spend recorded in that month
+func (s *BudgetService) GetStatus(month string) (*models.BudgetReport, error) {
+	month = strings.TrimSpace(month)
+	if month == "" {
+		month = time.Now().Format(monthLayout)
+	}
+	from, to, err := monthRange(month)
+	if err != nil {
+		return nil, err
+	}
+
+	budgets, err := s.budgetsForMonth(month)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := s.expenses.SumByCategory(models.ExpenseFilter{From: from, To: to})
+	if err != nil {
+		return nil, err
+	}
+	spent := make(map[[2]string]money.Decimal, len(groups))
+	for _, group := range groups {
+		spent[[2]string{group.Category, group.Currency}] = group.Total
+	}
+
+	report := &models.BudgetReport{Month: month, Budgets: []models.BudgetStatus{}}
+	for _, budget := range budgets {
+		report.Budgets = append(report.Budgets, budgetStatus(budget, month, spent[[2]string{budget.Category, budget.Currency}]))
+	}
+	return report, nil
+}
+
+// ExceededBy reports the budgets a newly saved expense pushed over their limit: those
+// of its category, currency and month that were within the limit before it was added
+func (s *BudgetService) ExceededBy(expense *models.Expense) ([]models.BudgetStatus, error) {
+	month := strings.TrimSpace(expense.Date)[:len(monthLayout)]
+	from, to, err := monthRange(month)
+	if err != nil {
+		return nil, err
+	}
+
+	budgets, err := s.budgetsForMonth(month)
+	if err != nil {
+		return nil, err
+	}
+
+	var exceeded []models.BudgetStatus
+	for _, budget := range budgets {
+		if budget.Category != expense.Category || budget.Currency != expense.Currency {
+			continue
+		}
+
+		groups, err := s.expenses.SumByCategory(models.ExpenseFilter{
+			Categories: []string{budget.Category},
+			Currency:   budget.Currency,
+			From:       from,
+			To:         to,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(groups) == 0 {
+			continue
+		}
+
+		spent := groups[0].Total
+		limit := money.MustParse(budget.Limit)
+		before := spent.Sub(money.MustParse(expense.Amount))
+		if spent.Cmp(limit) > 0 && before.Cmp(limit) <= 0 {
+			exceeded = append(exceeded, budgetStatus(budget, month, spent))
+		}
+	}
+	return exceeded, nil
+}
+
+// budgetsForMonth returns the budgets applying to a month. A budget set for that
+// specific month replaces the monthly budget of the same category and currency.
+func (s *BudgetService) budgetsForMonth(month string) ([]models.Budget, error) {
+	budgets, err := s.repo.ListForMonth(month)
+	if err != nil {
+		return nil, err
+	}
+
+	specific := make(map[[2]string]bool)
+	for _, budget := range budgets {
+		if budget.Period == month {
+			specific[[2]string{budget.Category, budget.Currency}] = true
+		}
+	}
+
+	applicable := []models.Budget{}
+	for _, budget := range budgets {
+		if budget.Period == models.BudgetPeriodMonthly && specific[[2]string{budget.Category, budget.Currency}] {
+			continue
+		}
+		applicable = append(applicable, budget)
+	}
+	return applicable, nil
+}
+
+// applyRequest validates a budget request and copies it onto the budget in canonical form
+func (s *BudgetService) applyRequest(budget *models.Budget, req models.BudgetRequest) error {
+	budget.Category = strings.TrimSpace(req.Category)
+	if budget.Category == "" {
+		return &ValidationError{Message: "category is required"}
+	}
+
+	budget.Period = strings.ToLower(strings.TrimSpace(req.Period))
+	if budget.Period == "" {
+		budget.Period = models.BudgetPeriodMonthly
+	}
+	if budget.Period != models.BudgetPeriodMonthly {
+		if _, _, err := monthRange(budget.Period); err != nil {
+			return &ValidationError{Message: "period must be monthly or a month (YYYY-MM)"}
+		}
+	}
+
+	code := strings.TrimSpace(req.Currency)
+	if code == "" {
+		code = s.defaultCurrency
+	}
+	currency, ok := money.LookupCurrency(code)
+	if !ok {
+		return &ValidationError{Message: "unsupported currency: " + code}
+	}
+	budget.Currency = currency.Code
+
+	limit, err := utils.ParseAmountWithScale(req.Limit, currency.MinorUnits)
+	if err != nil {
+		return &ValidationError{Message: "limit: " + err.Error()}
+	}
+	if limit.Sign() == 0 {
+		return &ValidationError{Message: "limit must be greater than zero"}
+	}
+	budget.Limit = limit.StringFixed(currency.MinorUnits)
+
+	return nil
+}
+
+// budgetStatus compares a budget with the spend of a month
+func budgetStatus(budget models.Budget, month string, spent money.Decimal) models.BudgetStatus {
+	limit := money.MustParse(budget.Limit)
+	percentage, err := spent.MulRatio(money.NewFromInt(100), limit, percentageScale, money.RoundHalfEven)
+	if err != nil {
+		percentage = money.Zero
+	}
+
+	return models.BudgetStatus{
+		Budget:     budget,
+		Month:      month,
+		Spent:      formatInCurrency(spent, budget.Currency),
+		Remaining:  formatInCurrency(limit.Sub(spent), budget.Currency),
+		Percentage: percentage.StringFixed(percentageScale),
+		Over:       spent.Cmp(limit) > 0,
+	}
+}
+
+// monthRange validates a month (YYYY-MM) and returns its first and last day
+func monthRange(month string) (string, string, error) {
+	start, err := time.Parse(monthLayout, month)
+	if err != nil {
+		return "", "", &ValidationError{Message: "month must be in YYYY-MM format"}
+	}
+	return start.Format(dateLayout), start.AddDate(0, 1, -1).Format(dateLayout), nil
+}
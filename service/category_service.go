@@ -0,0 +1,326 @@
+package service
+
+import (
+	"errors"
+	"fenmo-ai-assignment/models"
+	"fenmo-ai-assignment/repository"
+	"fenmo-ai-assignment/utils"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Limits on category fields
+const (
+	MaxCategoryNameLength = 100
+	MaxCategoryIconLength = 32
+)
+
+// colourPattern matches #RGB and #RRGGBB hex colours
+var colourPattern = regexp.MustCompile(`^#([0-9a-f]{3}|[0-9a-f]{6})$`)
+
+// CategoryService handles business logic for categories
+type CategoryService struct {
+	repo       *repository.CategoryRepository
+	autoCreate bool
+}
+
+// NewCategoryService creates a new category service. With autoCreate, expenses may use
+// a category that does not exist yet and it is created on first use.
+func NewCategoryService(repo *repository.CategoryRepository, autoCreate bool) *CategoryService {
+	return &CategoryService{repo: repo, autoCreate: autoCreate}
+}
+
+// CreateCategory creates a new category with validation
+func (s *CategoryService) CreateCategory(req models.CreateCategoryRequest) (*models.Category, error) {
+	category := &models.Category{
+		ID:        utils.GenerateUUID(),
+		Name:      strings.TrimSpace(req.Name),
+		CreatedAt: time.Now(),
+	}
+	if err := validateCategoryName(category.Name); err != nil {
+		return nil, err
+	}
+	if err := applyCategoryStyle(category, &req.Colour, &req.Icon); err != nil {
+		return nil, err
+	}
+	if parentID := strings.TrimSpace(req.ParentID); parentID != "" {
+		if err := s.validateParent(category.ID, parentID, "parent_id"); err != nil {
+			return nil, err
+		}
+		category.ParentID = &parentID
+	}
+
+	if err := s.repo.Create(category); err != nil {
+		return nil, translateRepoError(err)
+	}
+	return category, nil
+}
+
+// GetCategory retrieves a single category by ID
+func (s *CategoryService) GetCategory(id string) (*models.Category, error) {
+	category, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	return category, nil
+}
+
+// GetCategories retrieves categories by name, including archived ones when asked
+func (s *CategoryService) GetCategories(includeArchived bool) ([]models.Category, error) {
+	return s.repo.List(includeArchived)
+}
+
+// UpdateCategory changes the parent, colour, icon or archived flag of a category (PATCH)
+func (s *CategoryService) UpdateCategory(id string, req models.UpdateCategoryRequest) (*models.Category, error) {
+	category, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+
+	if err := applyCategoryStyle(category, req.Colour, req.Icon); err != nil {
+		return nil, err
+	}
+	if req.ParentID != nil {
+		category.ParentID = nil
+		if parentID := strings.TrimSpace(*req.ParentID); parentID != "" {
+			if err := s.validateParent(id, parentID, "parent_id"); err != nil {
+				return nil, err
+			}
+			category.ParentID = &parentID
+		}
+	}
+	if req.Archived != nil {
+		category.Archived = *req.Archived
+	}
+
+	if err := s.repo.Update(category); err != nil {
+		return nil, translateRepoError(err)
+	}
+	return category, nil
+}
+
+// DeleteCategory removes a category. With reassignTo, the ID of another category, its
+// expenses and subcategories move to that category. Otherwise its subcategories move
+// up to its own parent, and a category still used by expenses cannot be deleted; it
+// can be archived instead.
+func (s *CategoryService) DeleteCategory(id, reassignTo string) error {
+	category, err := s.repo.GetByID(id)
+	if err != nil {
+		return translateRepoError(err)
+	}
+
+	if reassignTo = strings.TrimSpace(reassignTo); reassignTo != "" {
+		if err := s.validateParent(id, reassignTo, "reassign_to"); err != nil {
+			return err
+		}
+		target, err := s.repo.GetByID(reassignTo)
+		if err != nil {
+			return translateRepoError(err)
+		}
+		return translateRepoError(s.repo.Delete(id, &target.ID, target.Name))
+	}
+
+	refs, err := s.repo.CountReferences(category.Name)
+	if err != nil {
+		return err
+	}
+	var users []string
+	for _, use := range []struct {
+		count int
+		name  string
+	}{
+		{refs.Expenses, "expenses"},
+		{refs.Recurring, "recurring expenses"},
+		{refs.Budgets, "budgets"},
+		{refs.Envelopes, "envelopes"},
+	} {
+		if use.count > 0 {
+			users = append(users, use.name)
+		}
+	}
+	if len(users) > 0 {
+		return &ConflictError{Message: "category is used by " + strings.Join(users, ", ") + "; reassign them or archive the category instead"}
+	}
+
+	return translateRepoError(s.repo.Delete(id, category.ParentID, ""))
+}
+
+// validateParent checks that parentID names an existing category that may become the
+// parent of category id: neither the category itself nor one of its descendants,
+// either of which would make the tree a cycle. field names the request field in errors.
+func (s *CategoryService) validateParent(id, parentID, field string) error {
+	all, err := s.repo.List(true)
+	if err != nil {
+		return err
+	}
+	parents := make(map[string]*string, len(all))
+	for _, c := range all {
+		parents[c.ID] = c.ParentID
+	}
+
+	if _, ok := parents[parentID]; !ok {
+		return &ValidationError{Message: field + ": category not found: " + parentID}
+	}
+	// Walk up from the new parent; reaching the category itself means a cycle. The
+	// step bound guards against a tree that is already corrupt.
+	for current, steps := &parentID, 0; current != nil && steps <= len(all); current, steps = parents[*current], steps+1 {
+		if *current == id {
+			return &ValidationError{Message: field + ": a category cannot be moved under itself or one of its subcategories"}
+		}
+	}
+	return nil
+}
+
+// RenameCategory renames a category and every expense, recurring template, budget and
+// envelope filed under it, in one transaction with an audit entry. Renaming onto the
+// name of another category is a conflict; merge the categories instead.
+func (s *CategoryService) RenameCategory(id string, req models.RenameCategoryRequest) (*models.CategoryChange, error) {
+	category, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if err := validateCategoryName(name); err != nil {
+		return nil, err
+	}
+	if name == category.Name {
+		return nil, &ValidationError{Message: "category already has this name"}
+	}
+
+	oldName := category.Name
+	category.Name = name
+	audit := newCategoryAudit(models.CategoryActionRename, category, []string{oldName})
+	if err := s.repo.Rename(category, oldName, &audit); err != nil {
+		return nil, translateRepoError(err)
+	}
+	return &models.CategoryChange{Category: category, Audit: audit}, nil
+}
+
+// MergeCategories folds the source categories into the target in one transaction:
+// everything filed under a source moves to the target, the sources' subcategories
+// move under the target and the sources are deleted. An audit entry records the merge.
+func (s *CategoryService) MergeCategories(req models.MergeCategoriesRequest) (*models.CategoryChange, error) {
+	target, err := s.repo.GetByID(strings.TrimSpace(req.TargetID))
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	if target.Archived {
+		return nil, &ValidationError{Message: "cannot merge into an archived category: " + target.Name}
+	}
+
+	ids := cleanValues(req.SourceIDs)
+	if len(ids) == 0 {
+		return nil, &ValidationError{Message: "source_ids must name at least one category"}
+	}
+	var sources []models.Category
+	var names []string
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		if id == target.ID {
+			return nil, &ValidationError{Message: "source_ids must not include the target"}
+		}
+		// The target inherits the sources' subcategories, so it must not be one of them
+		if err := s.validateParent(id, target.ID, "target_id"); err != nil {
+			return nil, err
+		}
+		source, err := s.repo.GetByID(id)
+		if err != nil {
+			return nil, translateRepoError(err)
+		}
+		sources = append(sources, *source)
+		names = append(names, source.Name)
+	}
+
+	audit := newCategoryAudit(models.CategoryActionMerge, target, names)
+	if err := s.repo.Merge(sources, target, &audit); err != nil {
+		return nil, translateRepoError(err)
+	}
+	return &models.CategoryChange{Category: target, Audit: audit}, nil
+}
+
+// GetAudit retrieves the rename and merge history, newest first
+func (s *CategoryService) GetAudit() ([]models.CategoryAuditEntry, error) {
+	return s.repo.ListAudit()
+}
+
+// newCategoryAudit starts an audit entry for a change leaving category under its
+// current name
+func newCategoryAudit(action string, category *models.Category, previousNames []string) models.CategoryAuditEntry {
+	return models.CategoryAuditEntry{
+		ID:            utils.GenerateUUID(),
+		Action:        action,
+		CategoryID:    category.ID,
+		CategoryName:  category.Name,
+		PreviousNames: previousNames,
+		CreatedAt:     time.Now(),
+	}
+}
+
+// Resolve maps a category name given on an expense onto the stored category, ignoring
+// case and surrounding spaces, and returns its canonical name. Unknown names are
+// created when auto-creation is enabled and rejected otherwise; archived categories
+// are rejected.
+func (s *CategoryService) Resolve(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if err := validateCategoryName(name); err != nil {
+		return "", err
+	}
+
+	category, err := s.repo.GetByName(name)
+	if errors.Is(err, repository.ErrCategoryNotFound) {
+		if !s.autoCreate {
+			return "", &ValidationError{Message: "unknown category: " + name}
+		}
+		category, err = s.CreateCategory(models.CreateCategoryRequest{Name: name})
+		if _, ok := err.(*ConflictError); ok {
+			// Created concurrently by another request
+			category, err = s.repo.GetByName(name)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if category.Archived {
+		return "", &ValidationError{Message: "category is archived: " + category.Name}
+	}
+	return category.Name, nil
+}
+
+// validateCategoryName checks a trimmed category name
+func validateCategoryName(name string) error {
+	if name == "" {
+		return &ValidationError{Message: "category is required"}
+	}
+	if utf8.RuneCountInString(name) > MaxCategoryNameLength {
+		return &ValidationError{Message: "category name is too long"}
+	}
+	return nil
+}
+
+// applyCategoryStyle validates and sets the colour and icon that were provided
+func applyCategoryStyle(category *models.Category, colour, icon *string) error {
+	if colour != nil {
+		value := strings.ToLower(strings.TrimSpace(*colour))
+		if value != "" && !colourPattern.MatchString(value) {
+			return &ValidationError{Message: "colour must be a hex colour such as #4caf50"}
+		}
+		category.Colour = value
+	}
+	if icon != nil {
+		value := strings.TrimSpace(*icon)
+		if utf8.RuneCountInString(value) > MaxCategoryIconLength {
+			return &ValidationError{Message: "icon is too long"}
+		}
+		category.Icon = value
+	}
+	return nil
+}
@@ -0,0 +1,497 @@
+package service
+
+import (
+	"fenmo-ai-assignment/models"
+	"fenmo-ai-assignment/money"
+	"fenmo-ai-assignment/repository"
+	"fenmo-ai-assignment/utils"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxEnvelopeReportMonths bounds the number of periods in one envelope report
+const MaxEnvelopeReportMonths = 120
+
+// EnvelopeService handles zero-based envelope budgeting: money is allocated to
+// category envelopes each month, spending in a category is drawn from its envelope,
+// and balances can roll over from one month to the next
+type EnvelopeService struct {
+	repo            *repository.EnvelopeRepository
+	expenses        repository.ExpenseStore
+	defaultCurrency string
+}
+
+// NewEnvelopeService creates a new envelope service. Spend is read from the expense
+// repository; defaultCurrency applies to envelopes and incomes given without a currency.
+func NewEnvelopeService(repo *repository.EnvelopeRepository, expenses repository.ExpenseStore, defaultCurrency string) *EnvelopeService {
+	return &EnvelopeService{repo: repo, expenses: expenses, defaultCurrency: defaultCurrency}
+}
+
+// CreateEnvelope creates a new envelope with validation
+func (s *EnvelopeService) CreateEnvelope(req models.EnvelopeRequest) (*models.Envelope, error) {
+	envelope := &models.Envelope{ID: utils.GenerateUUID(), CreatedAt: time.Now()}
+	if err := s.applyRequest(envelope, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(envelope); err != nil {
+		return nil, translateRepoError(err)
+	}
+	return envelope, nil
+}
+
+// GetEnvelope retrieves a single envelope by ID
+func (s *EnvelopeService) GetEnvelope(id string) (*models.Envelope, error) {
+	envelope, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	return envelope, nil
+}
+
+// GetEnvelopes retrieves every envelope
+func (s *EnvelopeService) GetEnvelopes() ([]models.Envelope, error) {
+	return s.repo.List()
+}
+
+// UpdateEnvelope replaces all editable fields of an existing envelope (PUT)
+func (s *EnvelopeService) UpdateEnvelope(id string, req models.EnvelopeRequest) (*models.Envelope, error) {
+	envelope, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	if err := s.applyRequest(envelope, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(envelope); err != nil {
+		return nil, translateRepoError(err)
+	}
+	return envelope, nil
+}
+
+// DeleteEnvelope removes an envelope and its allocations
+func (s *EnvelopeService) DeleteEnvelope(id string) error {
+	return translateRepoError(s.repo.Delete(id))
+}
+
+// SetAllocation sets the amount allocated to an envelope for one month, overriding
+// the envelope's default allocation
+func (s *EnvelopeService) SetAllocation(id, month string, req models.AllocationRequest) (*models.EnvelopeAllocation, error) {
+	envelope, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	if _, _, err := monthRange(month); err != nil {
+		return nil, err
+	}
+
+	amount, _, err := parseAmountIn("amount", req.Amount, envelope.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	allocation := &models.EnvelopeAllocation{EnvelopeID: id, Month: month, Amount: amount}
+	if err := s.repo.SetAllocation(*allocation); err != nil {
+		return nil, err
+	}
+	return allocation, nil
+}
+
+// SetIncome records the income of a month and allocates it across envelopes as given
+// by the split. The split may leave income unassigned but may not exceed it.
+// It returns the resulting status of that month.
+func (s *EnvelopeService) SetIncome(req models.IncomeRequest) (*models.EnvelopePeriod, error) {
+	month := strings.TrimSpace(req.Month)
+	if _, _, err := monthRange(month); err != nil {
+		return nil, err
+	}
+
+	code := strings.TrimSpace(req.Currency)
+	if code == "" {
+		code = s.defaultCurrency
+	}
+	currency, ok := money.LookupCurrency(code)
+	if !ok {
+		return nil, &ValidationError{Message: "unsupported currency: " + code}
+	}
+
+	amount, total, err := parseAmountIn("amount", req.Amount, currency.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	allocated := money.Zero
+	seen := make(map[string]bool)
+	allocations := make([]models.EnvelopeAllocation, 0, len(req.Split))
+	for _, split := range req.Split {
+		envelope, err := s.repo.GetByID(split.EnvelopeID)
+		if err != nil {
+			return nil, translateRepoError(err)
+		}
+		if envelope.Currency != currency.Code {
+			return nil, &ValidationError{Message: fmt.Sprintf("envelope %s is in %s, not %s", envelope.Category, envelope.Currency, currency.Code)}
+		}
+		if seen[envelope.ID] {
+			return nil, &ValidationError{Message: "envelope " + envelope.Category + " appears more than once in the split"}
+		}
+		seen[envelope.ID] = true
+
+		share, value, err := parseAmountIn("split amount", split.Amount, currency.Code)
+		if err != nil {
+			return nil, err
+		}
+		allocated = allocated.Add(value)
+		allocations = append(allocations, models.EnvelopeAllocation{EnvelopeID: envelope.ID, Month: month, Amount: share})
+	}
+
+	if over := allocated.Sub(total); over.Sign() > 0 {
+		return nil, &ValidationError{Message: "split exceeds income by " + formatInCurrency(over, currency.Code)}
+	}
+
+	income := models.Income{Month: month, Currency: currency.Code, Amount: amount}
+	if err := s.repo.SetIncome(income, allocations); err != nil {
+		return nil, err
+	}
+
+	report, err := s.GetStatus(month, month)
+	if err != nil {
+		return nil, err
+	}
+	return &report.Periods[0], nil
+}
+
+// Transfer moves money between two envelopes of the same currency within a month and
+// records the transfer. The source envelope must hold at least the amount moved.
+func (s *EnvelopeService) Transfer(req models.TransferRequest) (*models.EnvelopeTransfer, error) {
+	month := strings.TrimSpace(req.Month)
+	if _, _, err := monthRange(month); err != nil {
+		return nil, err
+	}
+	if req.FromEnvelopeID == req.ToEnvelopeID {
+		return nil, &ValidationError{Message: "cannot transfer an envelope to itself"}
+	}
+
+	from, err := s.repo.GetByID(req.FromEnvelopeID)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	to, err := s.repo.GetByID(req.ToEnvelopeID)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	if from.Currency != to.Currency {
+		return nil, &ValidationError{Message: "envelopes must share a currency"}
+	}
+
+	amount, value, err := parseAmountIn("amount", req.Amount, from.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if value.Sign() == 0 {
+		return nil, &ValidationError{Message: "amount must be greater than zero"}
+	}
+
+	transfer := &models.EnvelopeTransfer{
+		ID:             utils.GenerateUUID(),
+		FromEnvelopeID: from.ID,
+		ToEnvelopeID:   to.ID,
+		Month:          month,
+		Amount:         amount,
+		Currency:       from.Currency,
+		Note:           strings.TrimSpace(req.Note),
+		CreatedAt:      time.Now(),
+	}
+	// Envelopes, allocations and transfers are read in the transaction recording the
+	// transfer, so they include it and no concurrent transfer can move the same money.
+	// Spend comes from the expense store outside that transaction, so an expense
+	// recorded meanwhile can still overdraw the envelope
+	err = s.repo.CreateTransfer(transfer, func(repo *repository.EnvelopeRepository) error {
+		checked := &EnvelopeService{repo: repo, expenses: s.expenses, defaultCurrency: s.defaultCurrency}
+		report, err := checked.GetStatus(month, month)
+		if err != nil {
+			return err
+		}
+		// An envelope missing from the report holds nothing
+		closing := value.Neg()
+		for _, balance := range report.Periods[0].Envelopes {
+			if balance.EnvelopeID == from.ID {
+				if closing, err = money.Parse(balance.Closing); err != nil {
+					return fmt.Errorf("closing balance %q: %w", balance.Closing, err)
+				}
+			}
+		}
+		if closing.Sign() < 0 {
+			held := formatInCurrency(closing.Add(value), from.Currency)
+			return &ConflictError{Message: fmt.Sprintf("envelope %s holds only %s %s", from.Category, held, from.Currency)}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// GetTransfers retrieves the transfers made in a month (YYYY-MM), or every transfer
+// when month is empty
+func (s *EnvelopeService) GetTransfers(month string) ([]models.EnvelopeTransfer, error) {
+	if month == "" {
+		return s.repo.GetTransfers("", "")
+	}
+	if _, _, err := monthRange(month); err != nil {
+		return nil, err
+	}
+	return s.repo.GetTransfers(month, month)
+}
+
+// GetStatus reports the opening balance, allocation, transfers, spend and closing
+// balance of every envelope for each month from one month (YYYY-MM) to another,
+// inclusive. Both default to the current month.
+//
+// Balances are replayed from the first month an envelope was active: its creation
+// month, or an earlier month it received an allocation or transfer in. Rollover
+// envelopes open each month with the previous closing balance; others open at zero.
+func (s *EnvelopeService) GetStatus(from, to string) (*models.EnvelopeReport, error) {
+	current := time.Now().Format(monthLayout)
+	if from == "" {
+		from = current
+	}
+	if to == "" {
+		to = from
+	}
+	if _, _, err := monthRange(from); err != nil {
+		return nil, err
+	}
+	_, toEnd, err := monthRange(to)
+	if err != nil {
+		return nil, err
+	}
+	if from > to {
+		return nil, &ValidationError{Message: "from must not be after to"}
+	}
+	if len(monthsBetween(from, to)) > MaxEnvelopeReportMonths {
+		return nil, &ValidationError{Message: fmt.Sprintf("a report covers at most %d months", MaxEnvelopeReportMonths)}
+	}
+
+	envelopes, err := s.repo.List()
+	if err != nil {
+		return nil, err
+	}
+
+	// First active month per envelope, and the first month any envelope was active
+	start := make(map[string]string, len(envelopes))
+	for _, envelope := range envelopes {
+		start[envelope.ID] = envelope.CreatedAt.UTC().Format(monthLayout)
+	}
+	activate := func(id, month string) {
+		if first, ok := start[id]; ok && month < first {
+			start[id] = month
+		}
+	}
+
+	allocations, err := s.repo.GetAllocationsUntil(to)
+	if err != nil {
+		return nil, err
+	}
+	allocated := make(map[[2]string]money.Decimal, len(allocations))
+	for _, allocation := range allocations {
+		amount, err := parseStoredAmount(allocation.Amount)
+		if err != nil {
+			return nil, err
+		}
+		allocated[[2]string{allocation.EnvelopeID, allocation.Month}] = amount
+		activate(allocation.EnvelopeID, allocation.Month)
+	}
+
+	transfers, err := s.repo.GetTransfers("", to)
+	if err != nil {
+		return nil, err
+	}
+	transfersIn := make(map[[2]string]money.Decimal)
+	transfersOut := make(map[[2]string]money.Decimal)
+	for _, transfer := range transfers {
+		amount, err := parseStoredAmount(transfer.Amount)
+		if err != nil {
+			return nil, err
+		}
+		in := [2]string{transfer.ToEnvelopeID, transfer.Month}
+		out := [2]string{transfer.FromEnvelopeID, transfer.Month}
+		transfersIn[in] = transfersIn[in].Add(amount)
+		transfersOut[out] = transfersOut[out].Add(amount)
+		activate(transfer.ToEnvelopeID, transfer.Month)
+		activate(transfer.FromEnvelopeID, transfer.Month)
+	}
+
+	first := from
+	for _, month := range start {
+		if month < first {
+			first = month
+		}
+	}
+	firstStart, _, _ := monthRange(first)
+
+	// Spend per category, currency and month over the whole replayed range
+	spent := make(map[[3]string]money.Decimal)
+	if len(envelopes) > 0 {
+		groups, err := s.expenses.SumByDate(models.ExpenseFilter{From: firstStart, To: toEnd}, true)
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range groups {
+			key := [3]string{group.Category, group.Currency, group.Date[:len(monthLayout)]}
+			spent[key] = spent[key].Add(group.Total)
+		}
+	}
+
+	incomes, err := s.repo.GetIncomes(from, to)
+	if err != nil {
+		return nil, err
+	}
+	income := make(map[string]map[string]money.Decimal)
+	for _, entry := range incomes {
+		if income[entry.Month] == nil {
+			income[entry.Month] = make(map[string]money.Decimal)
+		}
+		amount, err := parseStoredAmount(entry.Amount)
+		if err != nil {
+			return nil, err
+		}
+		income[entry.Month][entry.Currency] = amount
+	}
+
+	report := &models.EnvelopeReport{From: from, To: to, Periods: []models.EnvelopePeriod{}}
+	closing := make(map[string]money.Decimal, len(envelopes))
+	for _, month := range monthsBetween(first, to) {
+		period := models.EnvelopePeriod{
+			Month:      month,
+			Income:     make(map[string]string),
+			Allocated:  make(map[string]string),
+			Unassigned: make(map[string]string),
+			Envelopes:  []models.EnvelopeBalance{},
+		}
+		allocatedTotal := make(map[string]money.Decimal)
+
+		for _, envelope := range envelopes {
+			if month < start[envelope.ID] {
+				continue
+			}
+			key := [2]string{envelope.ID, month}
+
+			opening := money.Zero
+			if envelope.Rollover {
+				opening = closing[envelope.ID]
+			}
+			allocation, ok := allocated[key]
+			if !ok {
+				if allocation, err = parseStoredAmount(envelope.Allocation); err != nil {
+					return nil, err
+				}
+			}
+			spend := spent[[3]string{envelope.Category, envelope.Currency, month}]
+			closing[envelope.ID] = opening.Add(allocation).Add(transfersIn[key]).Sub(transfersOut[key]).Sub(spend)
+			allocatedTotal[envelope.Currency] = allocatedTotal[envelope.Currency].Add(allocation)
+
+			code := envelope.Currency
+			period.Envelopes = append(period.Envelopes, models.EnvelopeBalance{
+				EnvelopeID:   envelope.ID,
+				Category:     envelope.Category,
+				Currency:     code,
+				Opening:      formatInCurrency(opening, code),
+				Allocated:    formatInCurrency(allocation, code),
+				TransfersIn:  formatInCurrency(transfersIn[key], code),
+				TransfersOut: formatInCurrency(transfersOut[key], code),
+				Spent:        formatInCurrency(spend, code),
+				Closing:      formatInCurrency(closing[envelope.ID], code),
+			})
+		}
+
+		if month < from {
+			continue
+		}
+
+		currencies := make(map[string]bool)
+		for code := range income[month] {
+			currencies[code] = true
+		}
+		for code := range allocatedTotal {
+			currencies[code] = true
+		}
+		for code := range currencies {
+			period.Income[code] = formatInCurrency(income[month][code], code)
+			period.Allocated[code] = formatInCurrency(allocatedTotal[code], code)
+			period.Unassigned[code] = formatInCurrency(income[month][code].Sub(allocatedTotal[code]), code)
+		}
+		report.Periods = append(report.Periods, period)
+	}
+
+	return report, nil
+}
+
+// applyRequest validates an envelope request and copies it onto the envelope in canonical form
+func (s *EnvelopeService) applyRequest(envelope *models.Envelope, req models.EnvelopeRequest) error {
+	envelope.Category = strings.TrimSpace(req.Category)
+	if envelope.Category == "" {
+		return &ValidationError{Message: "category is required"}
+	}
+
+	code := strings.TrimSpace(req.Currency)
+	if code == "" {
+		code = s.defaultCurrency
+	}
+	currency, ok := money.LookupCurrency(code)
+	if !ok {
+		return &ValidationError{Message: "unsupported currency: " + code}
+	}
+	envelope.Currency = currency.Code
+
+	allocation := req.Allocation
+	if strings.TrimSpace(allocation) == "" {
+		allocation = "0"
+	}
+	canonical, _, err := parseAmountIn("allocation", allocation, currency.Code)
+	if err != nil {
+		return err
+	}
+	envelope.Allocation = canonical
+	envelope.Rollover = req.Rollover
+
+	return nil
+}
+
+// parseAmountIn validates a non-negative amount against a currency's minor units and
+// returns it in canonical form together with its value
+func parseAmountIn(field, amount, code string) (string, money.Decimal, error) {
+	currency, ok := money.LookupCurrency(code)
+	if !ok {
+		return "", money.Decimal{}, &ValidationError{Message: "unsupported currency: " + code}
+	}
+	value, err := utils.ParseAmountWithScale(amount, currency.MinorUnits)
+	if err != nil {
+		return "", money.Decimal{}, &ValidationError{Message: field + ": " + err.Error()}
+	}
+	return value.StringFixed(currency.MinorUnits), value, nil
+}
+
+// parseStoredAmount parses an amount read back from the database
+func parseStoredAmount(amount string) (money.Decimal, error) {
+	value, err := money.Parse(amount)
+	if err != nil {
+		return money.Decimal{}, fmt.Errorf("stored amount %q: %w", amount, err)
+	}
+	return value, nil
+}
+
+// monthsBetween lists the months from one month to another, inclusive.
+// Both must be valid months (YYYY-MM).
+func monthsBetween(from, to string) []string {
+	start, _ := time.Parse(monthLayout, from)
+	end, _ := time.Parse(monthLayout, to)
+
+	var months []string
+	for month := start; !month.After(end); month = month.AddDate(0, 1, 0) {
+		months = append(months, month.Format(monthLayout))
+	}
+	return months
+}
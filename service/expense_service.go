@@ -1,81 +1,375 @@
 package service
 
 import (
+	"errors"
 	"fenmo-ai-assignment/models"
+	"fenmo-ai-assignment/money"
 	"fenmo-ai-assignment/repository"
 	"fenmo-ai-assignment/utils"
+	"fmt"
+	"log"
+	"strings"
 	"time"
 )
 
 // ExpenseService handles business logic for expenses
 type ExpenseService struct {
-	repo *repository.ExpenseRepository
+	repo            repository.ExpenseStore
+	categories      *CategoryService
+	budgets         *BudgetService
+	defaultCurrency string
 }
 
-// NewExpenseService creates a new expense service
-func NewExpenseService(repo *repository.ExpenseRepository) *ExpenseService {
-	return &ExpenseService{repo: repo}
+// NewExpenseService creates a new expense service.
+// categories may be nil to accept any category name, and budgets nil to skip budget
+// checks on creation; defaultCurrency is applied to expenses created without a currency.
+func NewExpenseService(repo repository.ExpenseStore, categories *CategoryService, budgets *BudgetService, defaultCurrency string) *ExpenseService {
+	return &ExpenseService{repo: repo, categories: categories, budgets: budgets, defaultCurrency: defaultCurrency}
 }
 
 // CreateExpense creates a new expense with validation
 func (s *ExpenseService) CreateExpense(req models.CreateExpenseRequest) (*models.Expense, error) {
-	// Validate amount
-	if err := utils.ValidateAmount(req.Amount); err != nil {
-		return nil, err
-	}
-
-	// Validate date
-	if err := utils.ValidateDate(req.Date); err != nil {
-		return nil, err
-	}
-
-	// Validate category and description are not empty
-	if req.Category == "" {
-		return nil, &ValidationError{Message: "category is required"}
-	}
-	if req.Description == "" {
-		return nil, &ValidationError{Message: "description is required"}
-	}
-
 	// Create expense model
 	expense := &models.Expense{
 		ID:          utils.GenerateUUID(),
 		Amount:      req.Amount,
+		Currency:    s.currencyOrDefault(req.Currency),
 		Category:    req.Category,
 		Description: req.Description,
 		Date:        req.Date,
 		CreatedAt:   time.Now(),
 	}
+	if req.RecurringID != "" {
+		expense.RecurringID = &req.RecurringID
+	}
+
+	if err := validateExpense(expense); err != nil {
+		return nil, err
+	}
+	if err := s.resolveCategory(expense); err != nil {
+		return nil, err
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+	expense.Tags = tags
 
 	// Save to database
 	if err := s.repo.Create(expense); err != nil {
+		return nil, translateRepoError(err)
+	}
+
+	// Report budgets this expense pushed over their limit. The expense is already
+	// saved, so a failed check is logged rather than failing the request.
+	if s.budgets != nil {
+		exceeded, err := s.budgets.ExceededBy(expense)
+		if err != nil {
+			log.Printf("Budget check for expense %s failed: %v", expense.ID, err)
+		}
+		expense.ExceededBudgets = exceeded
+	}
+
+	return expense, nil
+}
+
+// GetExpense retrieves a single expense by ID
+func (s *ExpenseService) GetExpense(id string) (*models.Expense, error) {
+	expense, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	return expense, nil
+}
+
+// UpdateExpense replaces all editable fields of an existing expense (PUT)
+func (s *ExpenseService) UpdateExpense(id string, req models.CreateExpenseRequest) (*models.Expense, error) {
+	currency := s.currencyOrDefault(req.Currency)
+	return s.PatchExpense(id, models.UpdateExpenseRequest{
+		Amount:      &req.Amount,
+		Currency:    &currency,
+		Category:    &req.Category,
+		Description: &req.Description,
+		Date:        &req.Date,
+		Tags:        &req.Tags,
+	})
+}
+
+// PatchExpense applies a partial update to an existing expense (PATCH)
+func (s *ExpenseService) PatchExpense(id string, req models.UpdateExpenseRequest) (*models.Expense, error) {
+	expense, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+
+	// Apply only the fields that were provided
+	if req.Amount != nil {
+		expense.Amount = *req.Amount
+	}
+	if req.Currency != nil {
+		expense.Currency = *req.Currency
+	}
+	if req.Category != nil {
+		expense.Category = *req.Category
+	}
+	if req.Description != nil {
+		expense.Description = *req.Description
+	}
+	if req.Date != nil {
+		expense.Date = *req.Date
+	}
+
+	if err := validateExpense(expense); err != nil {
 		return nil, err
 	}
+	if req.Category != nil {
+		if err := s.resolveCategory(expense); err != nil {
+			return nil, err
+		}
+	}
+	if req.Tags != nil {
+		if expense.Tags, err = normalizeTags(*req.Tags); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.Update(expense); err != nil {
+		return nil, translateRepoError(err)
+	}
 
 	return expense, nil
 }
 
-// GetExpenses retrieves expenses with optional filtering and sorting
-func (s *ExpenseService) GetExpenses(category string, sort string) ([]models.Expense, error) {
-	var expenses []models.Expense
-	var err error
+// DeleteExpense moves an expense to the trash
+func (s *ExpenseService) DeleteExpense(id string) error {
+	return translateRepoError(s.repo.Delete(id))
+}
 
-	// Determine which repository method to call based on filters
-	if category != "" && sort == "date_desc" {
-		expenses, err = s.repo.GetByCategorySortedByDateDesc(category)
-	} else if category != "" {
-		expenses, err = s.repo.GetByCategory(category)
-	} else if sort == "date_desc" {
-		expenses, err = s.repo.GetAllSortedByDateDesc()
-	} else {
-		expenses, err = s.repo.GetAll()
+// GetTrash retrieves soft-deleted expenses
+func (s *ExpenseService) GetTrash() ([]models.Expense, error) {
+	return s.repo.GetDeleted()
+}
+
+// RestoreExpense moves an expense out of the trash
+func (s *ExpenseService) RestoreExpense(id string) (*models.Expense, error) {
+	if err := s.repo.Restore(id); err != nil {
+		return nil, translateRepoError(err)
 	}
+	return s.GetExpense(id)
+}
 
+// GetExpenses retrieves expenses matching the filter in the order given by sort
+// (see ParseSort). With no sort, searches are ranked by relevance and the order of
+// other listings is unspecified.
+func (s *ExpenseService) GetExpenses(filter models.ExpenseFilter, sort string) ([]models.Expense, error) {
+	if err := normalizeFilter(&filter); err != nil {
+		return nil, err
+	}
+
+	keys, err := ParseSort(sort)
 	if err != nil {
 		return nil, err
 	}
+	if keys, err = rankSearch(keys, filter, s.repo.FullTextSearch()); err != nil {
+		return nil, err
+	}
+	if len(keys) > 0 {
+		keys = withTiebreakers(keys)
+	}
 
-	return expenses, nil
+	return s.repo.List(filter, keys)
+}
+
+// GetExpensesPage retrieves one page of expenses matching the filter. Results are
+// ordered by sort (by relevance for searches and newest first otherwise when empty),
+// then by creation time and ID so the order is stable across pages. Pass an empty
+// cursor for the first page.
+func (s *ExpenseService) GetExpensesPage(filter models.ExpenseFilter, sort, cursor string, limit int) (*models.ExpensePage, error) {
+	if limit == 0 {
+		limit = DefaultPageSize
+	}
+	if limit < 0 || limit > MaxPageSize {
+		return nil, &ValidationError{Message: fmt.Sprintf("limit must be between 1 and %d", MaxPageSize)}
+	}
+
+	if err := normalizeFilter(&filter); err != nil {
+		return nil, err
+	}
+
+	keys, err := ParseSort(sort)
+	if err != nil {
+		return nil, err
+	}
+	if keys, err = rankSearch(keys, filter, s.repo.FullTextSearch()); err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		keys = defaultPageSort
+	}
+	keys = withTiebreakers(keys)
+	sortSpec := FormatSort(keys)
+
+	var after []string
+	if cursor != "" {
+		decoded, err := DecodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		if decoded.Sort != sortSpec || len(decoded.Values) != len(keys) {
+			return nil, &ValidationError{Message: "cursor was issued for a different sort order"}
+		}
+		after = decoded.Values
+	}
+
+	// Fetch one extra row to learn whether another page follows
+	expenses, err := s.repo.GetPage(filter, keys, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.ExpensePage{Items: expenses}
+	if len(expenses) > limit {
+		page.Items = expenses[:limit]
+		next := EncodeCursor(models.ExpenseCursor{
+			Sort:   sortSpec,
+			Values: repository.CursorValues(page.Items[limit-1], keys),
+		})
+		page.NextCursor = &next
+	}
+	if page.Items == nil {
+		page.Items = []models.Expense{}
+	}
+
+	return page, nil
+}
+
+// GetTotals counts and sums every expense matching the filter, per currency.
+// Sums are exact decimals and cover the whole filtered set regardless of pagination.
+func (s *ExpenseService) GetTotals(filter models.ExpenseFilter, sort string) (*models.ExpenseTotals, error) {
+	if err := normalizeFilter(&filter); err != nil {
+		return nil, err
+	}
+
+	keys, err := ParseSort(sort)
+	if err != nil {
+		return nil, err
+	}
+	if keys, err = rankSearch(keys, filter, s.repo.FullTextSearch()); err != nil {
+		return nil, err
+	}
+
+	count := 0
+	sums := make(map[string]money.Decimal)
+	err = s.repo.ForEachAmount(filter, func(entry models.AmountEntry) error {
+		amount, err := money.Parse(strings.TrimSpace(entry.Amount))
+		if err != nil {
+			return fmt.Errorf("stored amount %q: %w", entry.Amount, err)
+		}
+		sums[entry.Currency] = sums[entry.Currency].Add(amount)
+		count++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	totals := &models.ExpenseTotals{
+		Count:   count,
+		Total:   make(map[string]string, len(sums)),
+		Filters: models.AppliedFilters{ExpenseFilter: filter, Sort: FormatSort(keys)},
+	}
+	for code, sum := range sums {
+		totals.Total[code] = formatInCurrency(sum, code)
+	}
+
+	return totals, nil
+}
+
+// formatInCurrency renders an amount with the currency's minor-unit scale
+func formatInCurrency(amount money.Decimal, code string) string {
+	if currency, ok := money.LookupCurrency(code); ok {
+		return amount.StringFixed(currency.MinorUnits)
+	}
+	return amount.String()
+}
+
+// resolveCategory replaces the expense's category with the matching stored category
+// name, so spellings differing in case or spacing file under the same category
+func (s *ExpenseService) resolveCategory(expense *models.Expense) error {
+	if s.categories == nil {
+		return nil
+	}
+	name, err := s.categories.Resolve(expense.Category)
+	if err != nil {
+		return err
+	}
+	expense.Category = name
+	return nil
+}
+
+// currencyOrDefault returns the requested currency, or the default when none was given
+func (s *ExpenseService) currencyOrDefault(currency string) string {
+	if currency == "" {
+		return s.defaultCurrency
+	}
+	return currency
+}
+
+// validateExpense checks the user-supplied fields of an expense and rewrites the
+// amount and currency in canonical form (e.g. " 100.5" -> "100.50", "usd" -> "USD")
+func validateExpense(expense *models.Expense) error {
+	// Validate currency
+	currency, ok := money.LookupCurrency(expense.Currency)
+	if !ok {
+		return &ValidationError{Message: "unsupported currency: " + expense.Currency}
+	}
+	expense.Currency = currency.Code
+
+	// Validate amount against the currency's minor-unit scale
+	amount, err := utils.ParseAmountWithScale(expense.Amount, currency.MinorUnits)
+	if err != nil {
+		return &ValidationError{Message: err.Error()}
+	}
+	expense.Amount = amount.StringFixed(currency.MinorUnits)
+
+	// Validate date
+	if err := utils.ValidateDate(expense.Date); err != nil {
+		return &ValidationError{Message: err.Error()}
+	}
+
+	// Validate category and description are not empty
+	if expense.Category == "" {
+		return &ValidationError{Message: "category is required"}
+	}
+	if expense.Description == "" {
+		return &ValidationError{Message: "description is required"}
+	}
+
+	return nil
+}
+
+// translateRepoError maps repository errors onto service errors
+func translateRepoError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrNotFound),
+		errors.Is(err, repository.ErrBudgetNotFound),
+		errors.Is(err, repository.ErrEnvelopeNotFound),
+		errors.Is(err, repository.ErrRecurringNotFound),
+		errors.Is(err, repository.ErrCategoryNotFound),
+		errors.Is(err, repository.ErrTagNotFound):
+		return &NotFoundError{Message: err.Error()}
+	case errors.Is(err, repository.ErrBudgetExists),
+		errors.Is(err, repository.ErrEnvelopeExists),
+		errors.Is(err, repository.ErrOccurrenceExists),
+		errors.Is(err, repository.ErrCategoryExists),
+		errors.Is(err, repository.ErrCategoryRewriteConflict),
+		errors.Is(err, repository.ErrTagExists):
+		return &ConflictError{Message: err.Error()}
+	case errors.Is(err, repository.ErrExpenseStoreDiverged):
+		// The expense store cannot be rolled back, so this needs repairing by hand
+		log.Printf("Category change failed after rewriting the expense store: %v", err)
+	}
+	return err
 }
 
 // ValidationError represents a validation error
@@ -86,3 +380,21 @@ type ValidationError struct {
 func (e *ValidationError) Error() string {
 	return e.Message
 }
+
+// NotFoundError represents a lookup of a resource that does not exist
+type NotFoundError struct {
+	Message string
+}
+
+func (e *NotFoundError) Error() string {
+	return e.Message
+}
+
+// ConflictError represents a request that conflicts with the current state of a resource
+type ConflictError struct {
+	Message string
+}
+
+func (e *ConflictError) Error() string {
+	return e.Message
+}
@@ -1,11 +1,17 @@
 package service
 
 import (
+	"database/sql"
+	"encoding/json"
 	"fenmo-ai-assignment/database"
 	"fenmo-ai-assignment/models"
+	"fenmo-ai-assignment/money"
 	"fenmo-ai-assignment/repository"
-	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 // Integration tests that require a real database
@@ -18,18 +24,11 @@ func TestExpenseService_CreateExpense_Integration(t *testing.T) {
 	}
 
 	// Setup test database
-	testDBPath := "./test_expenses.db"
-	defer os.Remove(testDBPath) // Clean up after test
-
-	err := database.Init(testDBPath)
-	if err != nil {
-		t.Fatalf("Failed to initialize test database: %v", err)
-	}
-	defer database.Close()
+	db := openIntegrationDB(t)
 
 	// Create repository and service
-	repo := repository.NewExpenseRepository(database.DB)
-	service := NewExpenseService(repo)
+	repo := repository.NewExpenseRepository(db)
+	service := NewExpenseService(repo, nil, nil, "INR")
 
 	tests := []struct {
 		name    string
@@ -98,18 +97,11 @@ func TestExpenseService_GetExpenses_Integration(t *testing.T) {
 	}
 
 	// Setup test database
-	testDBPath := "./test_expenses.db"
-	defer os.Remove(testDBPath) // Clean up after test
-
-	err := database.Init(testDBPath)
-	if err != nil {
-		t.Fatalf("Failed to initialize test database: %v", err)
-	}
-	defer database.Close()
+	db := openIntegrationDB(t)
 
 	// Create repository and service
-	repo := repository.NewExpenseRepository(database.DB)
-	service := NewExpenseService(repo)
+	repo := repository.NewExpenseRepository(db)
+	service := NewExpenseService(repo, nil, nil, "INR")
 
 	// Create test expenses
 	_, _ = service.CreateExpense(models.CreateExpenseRequest{
@@ -134,21 +126,30 @@ func TestExpenseService_GetExpenses_Integration(t *testing.T) {
 	})
 
 	tests := []struct {
-		name     string
-		category string
-		sort     string
-		wantLen  int
+		name    string
+		filter  models.ExpenseFilter
+		sort    string
+		wantLen int
 	}{
-		{"get all", "", "", 3},
-		{"filter by category", "Food", "", 2},
-		{"filter by non-existent category", "NonExistent", "", 0},
-		{"sort by date", "", "date_desc", 3},
-		{"filter and sort", "Food", "date_desc", 2},
+		{"get all", models.ExpenseFilter{}, "", 3},
+		{"filter by category", models.ExpenseFilter{Categories: []string{"Food"}}, "", 2},
+		{"filter by non-existent category", models.ExpenseFilter{Categories: []string{"NonExistent"}}, "", 0},
+		{"sort by date", models.ExpenseFilter{}, "date_desc", 3},
+		{"filter and sort", models.ExpenseFilter{Categories: []string{"Food"}}, "date_desc", 2},
+		{"multiple categories", models.ExpenseFilter{Categories: []string{"Food", "Transport"}}, "", 3},
+		{"exclude category", models.ExpenseFilter{ExcludeCategories: []string{"Food"}}, "", 1},
+		{"date range", models.ExpenseFilter{From: "2024-01-15", To: "2024-01-16"}, "", 2},
+		{"open-ended date range", models.ExpenseFilter{To: "2024-01-14"}, "", 1},
+		{"amount range is numeric", models.ExpenseFilter{MinAmount: "75.25", MaxAmount: "100"}, "", 1},
+		{"min amount below all", models.ExpenseFilter{MinAmount: "9"}, "", 3},
+		{"description substring is case-insensitive", models.ExpenseFilter{Query: "DIN"}, "", 1},
+		{"like wildcards match literally", models.ExpenseFilter{Query: "%"}, "", 0},
+		{"combined filters", models.ExpenseFilter{Categories: []string{"Food"}, From: "2024-01-16", MinAmount: "50"}, "date_desc", 1},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			expenses, err := service.GetExpenses(tt.category, tt.sort)
+			expenses, err := service.GetExpenses(tt.filter, tt.sort)
 			if err != nil {
 				t.Errorf("GetExpenses() error = %v", err)
 				return
@@ -159,3 +160,1695 @@ func TestExpenseService_GetExpenses_Integration(t *testing.T) {
 		})
 	}
 }
+
+// openIntegrationDB initialises a fresh test database, private to the test, which is
+// closed when it ends
+func openIntegrationDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+	t.Parallel()
+
+	db, err := database.Init(filepath.Join(t.TempDir(), "test_expenses.db"), true)
+	if err != nil {
+		t.Fatalf("Failed to initialize test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// setupIntegrationService initialises a fresh test database and returns a service backed by it
+func setupIntegrationService(t *testing.T) *ExpenseService {
+	t.Helper()
+	service, _ := setupIntegration(t)
+	return service
+}
+
+// setupIntegration is setupIntegrationService for tests that also use the database
+func setupIntegration(t *testing.T) (*ExpenseService, *sql.DB) {
+	t.Helper()
+	db := openIntegrationDB(t)
+
+	repo := repository.NewExpenseRepository(db)
+	categories := NewCategoryService(repository.NewCategoryRepository(db, nil), true)
+	budgets := NewBudgetService(repository.NewBudgetRepository(db), repo, "INR")
+	return NewExpenseService(repo, categories, budgets, "INR"), db
+}
+
+func TestExpenseService_UpdateDelete_Integration(t *testing.T) {
+	service := setupIntegrationService(t)
+
+	created, err := service.CreateExpense(models.CreateExpenseRequest{
+		Amount:      "100.50",
+		Category:    "Food",
+		Description: "Lnuch",
+		Date:        "2024-01-15",
+	})
+	if err != nil {
+		t.Fatalf("CreateExpense() error = %v", err)
+	}
+
+	t.Run("get by id", func(t *testing.T) {
+		expense, err := service.GetExpense(created.ID)
+		if err != nil {
+			t.Fatalf("GetExpense() error = %v", err)
+		}
+		if expense.Description != "Lnuch" {
+			t.Errorf("GetExpense() description = %v, want Lnuch", expense.Description)
+		}
+	})
+
+	t.Run("patch single field", func(t *testing.T) {
+		description := "Lunch"
+		expense, err := service.PatchExpense(created.ID, models.UpdateExpenseRequest{Description: &description})
+		if err != nil {
+			t.Fatalf("PatchExpense() error = %v", err)
+		}
+		if expense.Description != "Lunch" || expense.Amount != "100.50" {
+			t.Errorf("PatchExpense() = %+v, want description updated and amount unchanged", expense)
+		}
+	})
+
+	t.Run("put replaces all fields", func(t *testing.T) {
+		expense, err := service.UpdateExpense(created.ID, models.CreateExpenseRequest{
+			Amount:      "42.00",
+			Category:    "Transport",
+			Description: "Taxi",
+			Date:        "2024-01-16",
+		})
+		if err != nil {
+			t.Fatalf("UpdateExpense() error = %v", err)
+		}
+		stored, _ := service.GetExpense(created.ID)
+		if stored.Amount != "42.00" || stored.Category != "Transport" || expense.Date != "2024-01-16" {
+			t.Errorf("UpdateExpense() stored = %+v", stored)
+		}
+	})
+
+	t.Run("patch rejects invalid amount", func(t *testing.T) {
+		amount := "-1"
+		_, err := service.PatchExpense(created.ID, models.UpdateExpenseRequest{Amount: &amount})
+		if _, ok := err.(*ValidationError); !ok {
+			t.Errorf("PatchExpense() error = %v, want *ValidationError", err)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		if err := service.DeleteExpense(created.ID); err != nil {
+			t.Fatalf("DeleteExpense() error = %v", err)
+		}
+		if _, err := service.GetExpense(created.ID); err == nil {
+			t.Errorf("GetExpense() after delete returned no error")
+		}
+	})
+
+	t.Run("unknown id is not found", func(t *testing.T) {
+		if _, err := service.GetExpense("missing"); !isNotFound(err) {
+			t.Errorf("GetExpense() error = %v, want *NotFoundError", err)
+		}
+		if err := service.DeleteExpense("missing"); !isNotFound(err) {
+			t.Errorf("DeleteExpense() error = %v, want *NotFoundError", err)
+		}
+		description := "x"
+		if _, err := service.PatchExpense("missing", models.UpdateExpenseRequest{Description: &description}); !isNotFound(err) {
+			t.Errorf("PatchExpense() error = %v, want *NotFoundError", err)
+		}
+	})
+}
+
+func isNotFound(err error) bool {
+	_, ok := err.(*NotFoundError)
+	return ok
+}
+
+func isValidation(err error) bool {
+	_, ok := err.(*ValidationError)
+	return ok
+}
+
+func TestExpenseService_TrashRestorePurge_Integration(t *testing.T) {
+	service, db := setupIntegration(t)
+	repo := repository.NewExpenseRepository(db)
+
+	kept, _ := service.CreateExpense(models.CreateExpenseRequest{
+		Amount: "10.00", Category: "Food", Description: "Snack", Date: "2024-01-15",
+	})
+	trashed, _ := service.CreateExpense(models.CreateExpenseRequest{
+		Amount: "20.00", Category: "Food", Description: "Dinner", Date: "2024-01-15",
+	})
+
+	if err := service.DeleteExpense(trashed.ID); err != nil {
+		t.Fatalf("DeleteExpense() error = %v", err)
+	}
+
+	// Deleted rows are hidden from every read path
+	expenses, _ := service.GetExpenses(models.ExpenseFilter{Categories: []string{"Food"}}, "date_desc")
+	if len(expenses) != 1 || expenses[0].ID != kept.ID {
+		t.Errorf("GetExpenses() after delete = %+v, want only %s", expenses, kept.ID)
+	}
+	if err := service.DeleteExpense(trashed.ID); !isNotFound(err) {
+		t.Errorf("DeleteExpense() twice error = %v, want *NotFoundError", err)
+	}
+
+	trash, err := service.GetTrash()
+	if err != nil {
+		t.Fatalf("GetTrash() error = %v", err)
+	}
+	if len(trash) != 1 || trash[0].ID != trashed.ID || trash[0].DeletedAt == nil {
+		t.Errorf("GetTrash() = %+v, want %s with deleted_at", trash, trashed.ID)
+	}
+
+	restored, err := service.RestoreExpense(trashed.ID)
+	if err != nil {
+		t.Fatalf("RestoreExpense() error = %v", err)
+	}
+	if restored.DeletedAt != nil {
+		t.Errorf("RestoreExpense() deleted_at = %v, want nil", restored.DeletedAt)
+	}
+	if _, err := service.RestoreExpense(kept.ID); !isNotFound(err) {
+		t.Errorf("RestoreExpense() of live expense error = %v, want *NotFoundError", err)
+	}
+
+	// Purge only removes rows older than the retention
+	_ = service.DeleteExpense(trashed.ID)
+	purged, err := NewTrashPurger(repo, time.Hour, time.Hour).PurgeOnce()
+	if err != nil || purged != 0 {
+		t.Errorf("PurgeOnce() with long retention = %d, %v, want 0", purged, err)
+	}
+	purged, err = repo.PurgeDeletedBefore(time.Now().Add(time.Minute))
+	if err != nil || purged != 1 {
+		t.Errorf("PurgeDeletedBefore() = %d, %v, want 1", purged, err)
+	}
+	if trash, _ := service.GetTrash(); len(trash) != 0 {
+		t.Errorf("GetTrash() after purge = %+v, want empty", trash)
+	}
+}
+
+func TestIdempotencyService_Integration(t *testing.T) {
+	db := openIntegrationDB(t)
+	idempotency := NewIdempotencyService(repository.NewIdempotencyRepository(db), time.Hour)
+
+	hash := HashRequest([]byte(`{"amount":"10.00"}`))
+
+	record, err := idempotency.Begin("key-1", hash)
+	if err != nil || record != nil {
+		t.Fatalf("Begin() first use = %v, %v, want nil, nil", record, err)
+	}
+
+	// A concurrent retry before the first request completes is a conflict
+	if _, err := idempotency.Begin("key-1", hash); err == nil {
+		t.Errorf("Begin() while in flight error = nil, want *ConflictError")
+	} else if _, ok := err.(*ConflictError); !ok {
+		t.Errorf("Begin() while in flight error = %v, want *ConflictError", err)
+	}
+
+	if err := idempotency.Complete("key-1", 201, []byte(`{"id":"abc"}`)); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+
+	record, err = idempotency.Begin("key-1", hash)
+	if err != nil || record == nil {
+		t.Fatalf("Begin() retry = %v, %v, want stored record", record, err)
+	}
+	if record.StatusCode != 201 || record.ResponseBody != `{"id":"abc"}` {
+		t.Errorf("Begin() retry record = %+v", record)
+	}
+
+	// Same key with a different body is rejected
+	_, err = idempotency.Begin("key-1", HashRequest([]byte(`{"amount":"20.00"}`)))
+	if _, ok := err.(*IdempotencyMismatchError); !ok {
+		t.Errorf("Begin() different body error = %v, want *IdempotencyMismatchError", err)
+	}
+
+	// Aborted keys can be claimed again
+	_, _ = idempotency.Begin("key-2", hash)
+	if err := idempotency.Abort("key-2"); err != nil {
+		t.Fatalf("Abort() error = %v", err)
+	}
+	if record, err := idempotency.Begin("key-2", hash); err != nil || record != nil {
+		t.Errorf("Begin() after abort = %v, %v, want nil, nil", record, err)
+	}
+
+	// Expired keys are forgotten
+	expiring := NewIdempotencyService(repository.NewIdempotencyRepository(db), -time.Minute)
+	if record, err := expiring.Begin("key-1", HashRequest([]byte("other"))); err != nil || record != nil {
+		t.Errorf("Begin() after expiry = %v, %v, want nil, nil", record, err)
+	}
+}
+
+func TestExpenseService_Currency_Integration(t *testing.T) {
+	service := setupIntegrationService(t)
+
+	tests := []struct {
+		name       string
+		amount     string
+		currency   string
+		wantAmount string
+		wantCode   string
+		wantErr    bool
+	}{
+		{"default currency", "100.5", "", "100.50", "INR", false},
+		{"lower-case code", "12", "usd", "12.00", "USD", false},
+		{"zero-decimal currency", "1500", "JPY", "1500", "JPY", false},
+		{"zero-decimal with trailing zero", "1500.0", "JPY", "1500", "JPY", false},
+		{"zero-decimal rejects fraction", "1500.5", "JPY", "", "", true},
+		{"three-decimal currency", "1.234", "KWD", "1.234", "KWD", false},
+		{"three-decimal rejects four", "1.2345", "KWD", "", "", true},
+		{"unknown currency", "10", "XYZ", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expense, err := service.CreateExpense(models.CreateExpenseRequest{
+				Amount: tt.amount, Currency: tt.currency, Category: "Travel", Description: "Trip", Date: "2024-03-01",
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CreateExpense() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if _, ok := err.(*ValidationError); !ok {
+					t.Errorf("CreateExpense() error = %T, want *ValidationError", err)
+				}
+				return
+			}
+			if expense.Amount != tt.wantAmount || expense.Currency != tt.wantCode {
+				t.Errorf("CreateExpense() = %s %s, want %s %s", expense.Amount, expense.Currency, tt.wantAmount, tt.wantCode)
+			}
+		})
+	}
+
+	jpy, err := service.GetExpenses(models.ExpenseFilter{Currency: "jpy"}, "")
+	if err != nil || len(jpy) != 2 {
+		t.Errorf("GetExpenses(currency=jpy) = %d rows, %v, want 2", len(jpy), err)
+	}
+	usd, err := service.GetExpenses(models.ExpenseFilter{Categories: []string{"Travel"}, Currency: "USD"}, "date_desc")
+	if err != nil || len(usd) != 1 {
+		t.Errorf("GetExpenses(Travel, USD) = %d rows, %v, want 1", len(usd), err)
+	}
+	if _, err := service.GetExpenses(models.ExpenseFilter{Currency: "XYZ"}, ""); err == nil {
+		t.Errorf("GetExpenses(currency=XYZ) error = nil, want validation error")
+	}
+}
+
+func TestFXService_Integration(t *testing.T) {
+	service, db := setupIntegration(t)
+	fx := NewFXService(repository.NewFXRateRepository(db))
+
+	rates := `Date,USD,INR,JPY
+2024-01-17,1.0900,90.50,161.00
+2024-01-15,1.0945,90.3415,160.89
+`
+	result, err := fx.Import(strings.NewReader(rates), FXFormatCSV, "EUR")
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if result.Imported != 6 || result.From != "2024-01-15" || result.To != "2024-01-17" {
+		t.Errorf("Import() = %+v", result)
+	}
+
+	tests := []struct {
+		name    string
+		amount  string
+		from    string
+		to      string
+		date    string
+		want    string
+		wantErr bool
+	}{
+		{"same currency", "10.00", "USD", "usd", "2024-01-15", "10.00", false},
+		{"from base", "100.00", "EUR", "USD", "2024-01-15", "109.45", false},
+		{"to base", "109.45", "USD", "EUR", "2024-01-15", "100.00", false},
+		{"cross rate", "1000.00", "INR", "USD", "2024-01-15", "12.12", false},
+		{"nearest earlier rate", "100.00", "EUR", "USD", "2024-01-16", "109.45", false},
+		{"zero-decimal target", "10.00", "EUR", "JPY", "2024-01-17", "1610", false},
+		{"no rate before date", "10.00", "EUR", "USD", "2024-01-01", "", true},
+		{"unknown currency", "10.00", "EUR", "GBP", "2024-01-15", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fx.Convert(money.MustParse(tt.amount), tt.from, tt.to, tt.date)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Convert() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.String() != tt.want {
+				t.Errorf("Convert() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// Re-importing a day replaces its rates
+	if _, err := fx.Import(strings.NewReader("Date,USD\n2024-01-15,1.2000\n"), FXFormatCSV, "EUR"); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if got, _ := fx.Convert(money.MustParse("1.00"), "EUR", "USD", "2024-01-15"); got.String() != "1.20" {
+		t.Errorf("Convert() after re-import = %s, want 1.20", got)
+	}
+
+	// Listing with a conversion currency fills converted_amount when a rate exists
+	_, _ = service.CreateExpense(models.CreateExpenseRequest{
+		Amount: "50.00", Currency: "EUR", Category: "Travel", Description: "Hotel", Date: "2024-01-17",
+	})
+	_, _ = service.CreateExpense(models.CreateExpenseRequest{
+		Amount: "5.00", Currency: "EUR", Category: "Travel", Description: "Coffee", Date: "2023-12-31",
+	})
+	expenses, _ := service.GetExpenses(models.ExpenseFilter{}, "date_desc")
+	if err := fx.ConvertExpenses(expenses, "INR"); err != nil {
+		t.Fatalf("ConvertExpenses() error = %v", err)
+	}
+	if expenses[0].ConvertedAmount == nil || *expenses[0].ConvertedAmount != "4525.00" || expenses[0].ConvertedCurrency != "INR" {
+		t.Errorf("ConvertExpenses() first = %+v", expenses[0])
+	}
+	if expenses[1].ConvertedAmount != nil {
+		t.Errorf("ConvertExpenses() without rate = %v, want nil", *expenses[1].ConvertedAmount)
+	}
+}
+
+func TestSummaryService_Conversion_Integration(t *testing.T) {
+	service, db := setupIntegration(t)
+	fx := NewFXService(repository.NewFXRateRepository(db))
+	summaries := NewSummaryService(service.repo, repository.NewCategoryRepository(db, nil), fx, time.Monday)
+
+	rates := "Date,USD,INR\n2024-02-01,1.1000,90.00\n2024-01-15,1.0000,80.00\n"
+	if _, err := fx.Import(strings.NewReader(rates), FXFormatCSV, "EUR"); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	for _, req := range []models.CreateExpenseRequest{
+		{Amount: "10.00", Currency: "EUR", Category: "Food", Description: "Lunch", Date: "2024-01-20", Tags: []string{"work"}},
+		{Amount: "10.00", Currency: "EUR", Category: "Food", Description: "Dinner", Date: "2024-02-03", Tags: []string{"work"}},
+		{Amount: "100.00", Currency: "INR", Category: "Travel", Description: "Bus", Date: "2024-02-03"},
+	} {
+		if _, err := service.CreateExpense(req); err != nil {
+			t.Fatalf("CreateExpense() error = %v", err)
+		}
+	}
+
+	// Each expense converts at the rate of its own date: 800 + 900 + 100
+	categories, err := summaries.GetCategorySummary(models.ExpenseFilter{}, "INR")
+	if err != nil {
+		t.Fatalf("GetCategorySummary() error = %v", err)
+	}
+	if categories.ConvertedCurrency != "INR" || categories.ConvertedTotal == nil || *categories.ConvertedTotal != "1800.00" {
+		t.Errorf("GetCategorySummary() converted = %s %v", categories.ConvertedCurrency, categories.ConvertedTotal)
+	}
+	if food := categories.Categories[0]; food.ConvertedTotal == nil || *food.ConvertedTotal != "1700.00" {
+		t.Errorf("GetCategorySummary() Food = %+v", food)
+	}
+
+	tags, err := summaries.GetTagSummary(models.ExpenseFilter{}, "INR")
+	if err != nil || len(tags.Tags) != 1 || tags.Tags[0].ConvertedTotal == nil || *tags.Tags[0].ConvertedTotal != "1700.00" {
+		t.Errorf("GetTagSummary() = %+v, %v", tags, err)
+	}
+
+	series, err := summaries.GetTimeSeries(models.ExpenseFilter{}, TimeSeriesOptions{ConvertTo: "INR"})
+	if err != nil || len(series.Points) != 2 {
+		t.Fatalf("GetTimeSeries() = %+v, %v", series, err)
+	}
+	if jan, feb := series.Points[0].ConvertedTotal, series.Points[1].ConvertedTotal; jan == nil || *jan != "800.00" || feb == nil || *feb != "1000.00" {
+		t.Errorf("GetTimeSeries() converted points = %v %v", jan, feb)
+	}
+
+	// An expense from before the first rate leaves the totals it belongs to unconverted
+	if _, err := service.CreateExpense(models.CreateExpenseRequest{
+		Amount: "5.00", Currency: "EUR", Category: "Food", Description: "Snack", Date: "2024-01-01",
+	}); err != nil {
+		t.Fatalf("CreateExpense() error = %v", err)
+	}
+	categories, _ = summaries.GetCategorySummary(models.ExpenseFilter{}, "INR")
+	if categories.ConvertedTotal != nil || categories.Unconverted != 1 || categories.Categories[0].ConvertedTotal != nil {
+		t.Errorf("GetCategorySummary() with a missing rate = %+v", categories)
+	}
+	if travel := categories.Categories[1]; travel.ConvertedTotal == nil || *travel.ConvertedTotal != "100.00" {
+		t.Errorf("GetCategorySummary() Travel with a missing rate elsewhere = %+v", travel)
+	}
+
+	if _, err := summaries.GetCategorySummary(models.ExpenseFilter{}, "XYZ"); err == nil {
+		t.Error("GetCategorySummary() converting to an unknown currency should fail")
+	}
+}
+
+func TestExpenseService_Pagination_Integration(t *testing.T) {
+	service := setupIntegrationService(t)
+
+	dates := []string{"2024-01-10", "2024-01-12", "2024-01-12", "2024-01-12", "2024-01-11", "2024-01-15", "2024-01-10"}
+	for i, date := range dates {
+		currency := "INR"
+		if i%2 == 1 {
+			currency = "USD"
+		}
+		_, err := service.CreateExpense(models.CreateExpenseRequest{
+			Amount: "1.00", Currency: currency, Category: "Food", Description: "Item", Date: date,
+		})
+		if err != nil {
+			t.Fatalf("CreateExpense() error = %v", err)
+		}
+	}
+
+	var all []models.Expense
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > len(dates) {
+			t.Fatalf("pagination did not terminate")
+		}
+		page, err := service.GetExpensesPage(models.ExpenseFilter{}, "", cursor, 3)
+		if err != nil {
+			t.Fatalf("GetExpensesPage() error = %v", err)
+		}
+		all = append(all, page.Items...)
+		if page.NextCursor == nil {
+			break
+		}
+		cursor = *page.NextCursor
+	}
+
+	if len(all) != len(dates) {
+		t.Fatalf("paginated %d expenses, want %d", len(all), len(dates))
+	}
+	seen := make(map[string]bool)
+	for i, expense := range all {
+		if seen[expense.ID] {
+			t.Errorf("expense %s returned twice", expense.ID)
+		}
+		seen[expense.ID] = true
+		if i > 0 && expense.Date > all[i-1].Date {
+			t.Errorf("expense %d date %s after %s, want descending", i, expense.Date, all[i-1].Date)
+		}
+	}
+
+	// Filters apply to every page
+	page, err := service.GetExpensesPage(models.ExpenseFilter{Categories: []string{"Food"}, Currency: "USD"}, "", "", 2)
+	if err != nil || len(page.Items) != 2 || page.NextCursor == nil {
+		t.Fatalf("GetExpensesPage(USD) = %+v, %v", page, err)
+	}
+	page, _ = service.GetExpensesPage(models.ExpenseFilter{Categories: []string{"Food"}, Currency: "USD"}, "", *page.NextCursor, 2)
+	if len(page.Items) != 1 || page.NextCursor != nil {
+		t.Errorf("GetExpensesPage(USD) second page = %d items, next %v", len(page.Items), page.NextCursor)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		cursor string
+		limit  int
+	}{
+		{"garbage cursor", "not-a-cursor!", 10},
+		{"limit too large", "", MaxPageSize + 1},
+		{"negative limit", "", -1},
+	} {
+		if _, err := service.GetExpensesPage(models.ExpenseFilter{}, "", tt.cursor, tt.limit); err == nil {
+			t.Errorf("GetExpensesPage(%s) error = nil, want validation error", tt.name)
+		}
+	}
+}
+
+func TestExpenseService_FilterValidation_Integration(t *testing.T) {
+	service := setupIntegrationService(t)
+
+	tests := []struct {
+		name   string
+		filter models.ExpenseFilter
+	}{
+		{"bad from date", models.ExpenseFilter{From: "2024/01/01"}},
+		{"from after to", models.ExpenseFilter{From: "2024-02-01", To: "2024-01-01"}},
+		{"bad min amount", models.ExpenseFilter{MinAmount: "1e3"}},
+		{"min above max", models.ExpenseFilter{MinAmount: "10", MaxAmount: "5"}},
+		{"unknown currency", models.ExpenseFilter{Currency: "XYZ"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.GetExpenses(tt.filter, "")
+			if _, ok := err.(*ValidationError); !ok {
+				t.Errorf("GetExpenses() error = %v, want *ValidationError", err)
+			}
+		})
+	}
+}
+
+func TestExpenseService_Sorting_Integration(t *testing.T) {
+	service := setupIntegrationService(t)
+
+	for _, e := range []struct{ amount, category, date string }{
+		{"9.00", "Food", "2024-01-03"},
+		{"100.00", "Food", "2024-01-01"},
+		{"10.00", "Travel", "2024-01-02"},
+		{"10.50", "Food", "2024-01-04"},
+		{"2.25", "Travel", "2024-01-05"},
+	} {
+		if _, err := service.CreateExpense(models.CreateExpenseRequest{
+			Amount: e.amount, Category: e.category, Description: "Item", Date: e.date,
+		}); err != nil {
+			t.Fatalf("CreateExpense() error = %v", err)
+		}
+	}
+
+	amounts := func(expenses []models.Expense) []string {
+		var out []string
+		for _, e := range expenses {
+			out = append(out, e.Amount)
+		}
+		return out
+	}
+
+	tests := []struct {
+		sort string
+		want []string
+	}{
+		{"amount", []string{"2.25", "9.00", "10.00", "10.50", "100.00"}},
+		{"-amount", []string{"100.00", "10.50", "10.00", "9.00", "2.25"}},
+		{"date", []string{"100.00", "10.00", "9.00", "10.50", "2.25"}},
+		{"-date", []string{"2.25", "10.50", "9.00", "10.00", "100.00"}},
+		{"category,-amount", []string{"100.00", "10.50", "9.00", "10.00", "2.25"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			expenses, err := service.GetExpenses(models.ExpenseFilter{}, tt.sort)
+			if err != nil {
+				t.Fatalf("GetExpenses() error = %v", err)
+			}
+			if got := amounts(expenses); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetExpenses(sort=%s) = %v, want %v", tt.sort, got, tt.want)
+			}
+
+			// Paging through with a small limit yields the same order
+			var paged []models.Expense
+			cursor := ""
+			for i := 0; i < 10; i++ {
+				page, err := service.GetExpensesPage(models.ExpenseFilter{}, tt.sort, cursor, 2)
+				if err != nil {
+					t.Fatalf("GetExpensesPage() error = %v", err)
+				}
+				paged = append(paged, page.Items...)
+				if page.NextCursor == nil {
+					break
+				}
+				cursor = *page.NextCursor
+			}
+			if got := amounts(paged); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetExpensesPage(sort=%s) = %v, want %v", tt.sort, got, tt.want)
+			}
+		})
+	}
+
+	// A cursor only works with the sort it was issued for
+	page, _ := service.GetExpensesPage(models.ExpenseFilter{}, "amount", "", 2)
+	if _, err := service.GetExpensesPage(models.ExpenseFilter{}, "-date", *page.NextCursor, 2); err == nil {
+		t.Errorf("GetExpensesPage() with mismatched cursor error = nil, want validation error")
+	}
+
+	if _, err := service.GetExpenses(models.ExpenseFilter{}, "price"); err == nil {
+		t.Errorf("GetExpenses(sort=price) error = nil, want *InvalidSortError")
+	}
+}
+
+func TestExpenseService_Totals_Integration(t *testing.T) {
+	service := setupIntegrationService(t)
+
+	for _, e := range []struct{ amount, currency, category string }{
+		{"0.10", "INR", "Food"},
+		{"0.20", "INR", "Food"},
+		{"100.05", "INR", "Travel"},
+		{"1500", "JPY", "Travel"},
+		{"1.005", "KWD", "Travel"},
+		{"2.010", "KWD", "Travel"},
+	} {
+		if _, err := service.CreateExpense(models.CreateExpenseRequest{
+			Amount: e.amount, Currency: e.currency, Category: e.category, Description: "Item", Date: "2024-02-01",
+		}); err != nil {
+			t.Fatalf("CreateExpense() error = %v", err)
+		}
+	}
+
+	totals, err := service.GetTotals(models.ExpenseFilter{}, "")
+	if err != nil {
+		t.Fatalf("GetTotals() error = %v", err)
+	}
+	want := map[string]string{"INR": "100.35", "JPY": "1500", "KWD": "3.015"}
+	if totals.Count != 6 || !reflect.DeepEqual(totals.Total, want) {
+		t.Errorf("GetTotals() = %d %v, want 6 %v", totals.Count, totals.Total, want)
+	}
+
+	// Totals follow the filter and echo it back normalised
+	totals, err = service.GetTotals(models.ExpenseFilter{Categories: []string{" Food "}, Currency: "inr"}, "date_desc")
+	if err != nil {
+		t.Fatalf("GetTotals() error = %v", err)
+	}
+	if totals.Count != 2 || totals.Total["INR"] != "0.30" {
+		t.Errorf("GetTotals(Food) = %d %v, want 2 INR 0.30", totals.Count, totals.Total)
+	}
+	if totals.Filters.Currency != "INR" || totals.Filters.Categories[0] != "Food" || totals.Filters.Sort != "-date" {
+		t.Errorf("GetTotals() filters = %+v", totals.Filters)
+	}
+
+	// Totals are inlined into the page envelope
+	page, _ := service.GetExpensesPage(models.ExpenseFilter{}, "", "", 1)
+	page.ExpenseTotals, _ = service.GetTotals(models.ExpenseFilter{}, "")
+	data, _ := json.Marshal(page)
+	var decoded map[string]interface{}
+	_ = json.Unmarshal(data, &decoded)
+	for _, key := range []string{"items", "next_cursor", "count", "total", "filters"} {
+		if _, ok := decoded[key]; !ok {
+			t.Errorf("page JSON missing %q: %s", key, data)
+		}
+	}
+	if len(page.Items) != 1 || decoded["count"].(float64) != 6 {
+		t.Errorf("page should hold 1 item but count the whole set: %s", data)
+	}
+}
+
+func TestSummaryService_Categories_Integration(t *testing.T) {
+	service, db := setupIntegration(t)
+	summaries := NewSummaryService(repository.NewExpenseRepository(db), repository.NewCategoryRepository(db, nil), nil, time.Monday)
+
+	for _, e := range []struct{ amount, currency, category, date string }{
+		{"0.10", "INR", "Food", "2024-01-10"},
+		{"0.20", "INR", "Food", "2024-01-20"},
+		{"0.70", "INR", "Travel", "2024-01-15"},
+		{"12.00", "USD", "Travel", "2024-01-15"},
+		{"50.00", "INR", "Rent", "2024-02-01"},
+	} {
+		if _, err := service.CreateExpense(models.CreateExpenseRequest{
+			Amount: e.amount, Currency: e.currency, Category: e.category, Description: "Item", Date: e.date,
+		}); err != nil {
+			t.Fatalf("CreateExpense() error = %v", err)
+		}
+	}
+
+	summary, err := summaries.GetCategorySummary(models.ExpenseFilter{From: "2024-01-01", To: "2024-01-31"}, "")
+	if err != nil {
+		t.Fatalf("GetCategorySummary() error = %v", err)
+	}
+	if summary.Count != 4 || !reflect.DeepEqual(summary.Total, map[string]string{"INR": "1.00", "USD": "12.00"}) {
+		t.Errorf("GetCategorySummary() overall = %d %v", summary.Count, summary.Total)
+	}
+
+	want := []models.CategoryTotal{
+		{Category: "Food", Count: 2, Total: map[string]string{"INR": "0.30"}, Share: map[string]string{"INR": "0.3000"}},
+		{Category: "Travel", Count: 2, Total: map[string]string{"INR": "0.70", "USD": "12.00"}, Share: map[string]string{"INR": "0.7000", "USD": "1.0000"}},
+	}
+	if !reflect.DeepEqual(summary.Categories, want) {
+		t.Errorf("GetCategorySummary() categories = %+v, want %+v", summary.Categories, want)
+	}
+
+	// Filters are validated like GET /expenses
+	if _, err := summaries.GetCategorySummary(models.ExpenseFilter{From: "2024-02-01", To: "2024-01-01"}, ""); err == nil {
+		t.Error("GetCategorySummary() with from > to should fail")
+	}
+
+	// An empty period yields an empty list rather than null
+	summary, err = summaries.GetCategorySummary(models.ExpenseFilter{From: "2030-01-01"}, "")
+	if err != nil || summary.Count != 0 || summary.Categories == nil {
+		t.Errorf("GetCategorySummary(empty) = %+v, %v", summary, err)
+	}
+}
+
+func TestSummaryService_TimeSeries_Integration(t *testing.T) {
+	service, db := setupIntegration(t)
+	summaries := NewSummaryService(repository.NewExpenseRepository(db), repository.NewCategoryRepository(db, nil), nil, time.Monday)
+
+	for _, e := range []struct{ amount, currency, category, date string }{
+		{"10.00", "INR", "Food", "2024-11-05"},
+		{"5.50", "INR", "Travel", "2024-11-20"},
+		{"3.00", "USD", "Food", "2024-12-29"}, // Sunday, end of ISO week 2024-W52
+		{"1.25", "INR", "Food", "2024-12-30"}, // Monday, ISO week 2025-W01
+	} {
+		if _, err := service.CreateExpense(models.CreateExpenseRequest{
+			Amount: e.amount, Currency: e.currency, Category: e.category, Description: "Item", Date: e.date,
+		}); err != nil {
+			t.Fatalf("CreateExpense() error = %v", err)
+		}
+	}
+
+	// Monthly buckets are zero-filled across the requested range
+	series, err := summaries.GetTimeSeries(models.ExpenseFilter{From: "2024-10-15", To: "2025-01-10"}, TimeSeriesOptions{})
+	if err != nil {
+		t.Fatalf("GetTimeSeries() error = %v", err)
+	}
+	if series.Interval != "month" || !reflect.DeepEqual(series.Currencies, []string{"INR", "USD"}) {
+		t.Errorf("GetTimeSeries() = %s %v", series.Interval, series.Currencies)
+	}
+	want := []models.TimeBucket{
+		{Label: "2024-10", Start: "2024-10-01", End: "2024-10-31", Count: 0, Total: map[string]string{"INR": "0.00", "USD": "0.00"}},
+		{Label: "2024-11", Start: "2024-11-01", End: "2024-11-30", Count: 2, Total: map[string]string{"INR": "15.50", "USD": "0.00"}},
+		{Label: "2024-12", Start: "2024-12-01", End: "2024-12-31", Count: 2, Total: map[string]string{"INR": "1.25", "USD": "3.00"}},
+		{Label: "2025-01", Start: "2025-01-01", End: "2025-01-31", Count: 0, Total: map[string]string{"INR": "0.00", "USD": "0.00"}},
+	}
+	if !reflect.DeepEqual(series.Points, want) {
+		t.Errorf("GetTimeSeries(month) points = %+v", series.Points)
+	}
+
+	// Monday weeks are ISO weeks and cross the year boundary correctly
+	series, err = summaries.GetTimeSeries(models.ExpenseFilter{From: "2024-12-23", To: "2025-01-05"}, TimeSeriesOptions{Interval: "week"})
+	if err != nil {
+		t.Fatalf("GetTimeSeries(week) error = %v", err)
+	}
+	if len(series.Points) != 2 || series.Points[0].Label != "2024-W52" || series.Points[1].Label != "2025-W01" ||
+		series.Points[0].Count != 1 || series.Points[1].Count != 1 || series.WeekStart != "monday" {
+		t.Errorf("GetTimeSeries(week) = %+v", series)
+	}
+
+	// Sunday weeks group the Sunday and Monday together and are labelled by start date
+	series, err = summaries.GetTimeSeries(models.ExpenseFilter{From: "2024-12-29", To: "2024-12-31"}, TimeSeriesOptions{Interval: "week", WeekStart: "sun"})
+	if err != nil {
+		t.Fatalf("GetTimeSeries(week, sunday) error = %v", err)
+	}
+	if len(series.Points) != 1 || series.Points[0].Label != "2024-12-29" || series.Points[0].End != "2025-01-04" || series.Points[0].Count != 2 {
+		t.Errorf("GetTimeSeries(week, sunday) = %+v", series.Points)
+	}
+
+	// Without a range the series spans the data; breakdown adds a series per category
+	series, err = summaries.GetTimeSeries(models.ExpenseFilter{Currency: "INR"}, TimeSeriesOptions{Interval: "year", ByCategory: true})
+	if err != nil {
+		t.Fatalf("GetTimeSeries(year) error = %v", err)
+	}
+	if series.From != "2024-11-05" || series.To != "2024-12-30" || len(series.Points) != 1 || series.Points[0].Total["INR"] != "16.75" {
+		t.Errorf("GetTimeSeries(year) = %+v", series)
+	}
+	if len(series.Categories) != 2 || series.Categories[0].Category != "Food" ||
+		series.Categories[0].Points[0].Total["INR"] != "11.25" || series.Categories[1].Points[0].Total["INR"] != "5.50" {
+		t.Errorf("GetTimeSeries(year) categories = %+v", series.Categories)
+	}
+
+	// Invalid options are rejected
+	for _, opts := range []TimeSeriesOptions{{Interval: "fortnight"}, {Interval: "week", WeekStart: "someday"}} {
+		if _, err := summaries.GetTimeSeries(models.ExpenseFilter{}, opts); err == nil {
+			t.Errorf("GetTimeSeries(%+v) should fail", opts)
+		}
+	}
+	if _, err := summaries.GetTimeSeries(models.ExpenseFilter{From: "2000-01-01", To: "2024-12-31"}, TimeSeriesOptions{Interval: "day"}); err == nil {
+		t.Error("GetTimeSeries() over too many buckets should fail")
+	}
+	for _, filter := range []models.ExpenseFilter{{From: "2024-02-30"}, {To: "2024-13-01"}} {
+		if _, err := summaries.GetTimeSeries(filter, TimeSeriesOptions{}); !isValidation(err) {
+			t.Errorf("GetTimeSeries(%+v) error = %v, want a validation error", filter, err)
+		}
+	}
+	if _, err := service.CreateExpense(models.CreateExpenseRequest{
+		Amount: "1", Category: "Food", Description: "Item", Date: "2024-02-30",
+	}); !isValidation(err) {
+		t.Errorf("CreateExpense() on a day that does not exist error = %v, want a validation error", err)
+	}
+}
+
+func TestBudgetService_Integration(t *testing.T) {
+	service := setupIntegrationService(t)
+	budgets := service.budgets
+
+	monthly, err := budgets.CreateBudget(models.BudgetRequest{Category: "Food", Limit: "100"})
+	if err != nil {
+		t.Fatalf("CreateBudget() error = %v", err)
+	}
+	if monthly.Period != "monthly" || monthly.Currency != "INR" || monthly.Limit != "100.00" {
+		t.Errorf("CreateBudget() = %+v", monthly)
+	}
+
+	// A budget for a specific month replaces the monthly one in that month
+	if _, err := budgets.CreateBudget(models.BudgetRequest{Category: "Food", Period: "2024-02", Limit: "50"}); err != nil {
+		t.Fatalf("CreateBudget(2024-02) error = %v", err)
+	}
+	travel, err := budgets.CreateBudget(models.BudgetRequest{Category: "Travel", Limit: "20", Currency: "usd"})
+	if err != nil {
+		t.Fatalf("CreateBudget(Travel) error = %v", err)
+	}
+
+	// Duplicates and invalid budgets are rejected
+	if _, err := budgets.CreateBudget(models.BudgetRequest{Category: "Food", Limit: "10"}); err == nil {
+		t.Error("CreateBudget() duplicate should fail")
+	} else if _, ok := err.(*ConflictError); !ok {
+		t.Errorf("CreateBudget() duplicate error = %v, want *ConflictError", err)
+	}
+	for _, req := range []models.BudgetRequest{
+		{Category: "Food", Limit: "0", Period: "2024-03"},
+		{Category: "Food", Limit: "10", Period: "weekly"},
+		{Category: "Food", Limit: "1.005", Period: "2024-03"},
+		{Category: " ", Limit: "10"},
+	} {
+		if _, err := budgets.CreateBudget(req); err == nil {
+			t.Errorf("CreateBudget(%+v) should fail", req)
+		}
+	}
+
+	create := func(amount, currency, category, date string) *models.Expense {
+		t.Helper()
+		expense, err := service.CreateExpense(models.CreateExpenseRequest{
+			Amount: amount, Currency: currency, Category: category, Description: "Item", Date: date,
+		})
+		if err != nil {
+			t.Fatalf("CreateExpense() error = %v", err)
+		}
+		return expense
+	}
+
+	// Only the expense crossing the limit reports it
+	if e := create("60.00", "INR", "Food", "2024-01-10"); len(e.ExceededBudgets) != 0 {
+		t.Errorf("expense within budget reported %+v", e.ExceededBudgets)
+	}
+	e := create("40.01", "INR", "Food", "2024-01-20")
+	if len(e.ExceededBudgets) != 1 || e.ExceededBudgets[0].ID != monthly.ID || e.ExceededBudgets[0].Remaining != "-0.01" {
+		t.Errorf("expense crossing budget reported %+v", e.ExceededBudgets)
+	}
+	if e := create("5.00", "INR", "Food", "2024-01-21"); len(e.ExceededBudgets) != 0 {
+		t.Errorf("expense on an exceeded budget reported %+v", e.ExceededBudgets)
+	}
+	create("60.00", "INR", "Food", "2024-02-03") // Over the February budget of 50
+	create("15.00", "USD", "Travel", "2024-01-05")
+	create("99.00", "INR", "Travel", "2024-01-05") // Other currency, not counted
+
+	report, err := budgets.GetStatus("2024-01")
+	if err != nil {
+		t.Fatalf("GetStatus() error = %v", err)
+	}
+	if len(report.Budgets) != 2 {
+		t.Fatalf("GetStatus() = %+v", report)
+	}
+	food, trav := report.Budgets[0], report.Budgets[1]
+	if food.Spent != "105.01" || food.Remaining != "-5.01" || food.Percentage != "105.01" || !food.Over {
+		t.Errorf("GetStatus() Food = %+v", food)
+	}
+	if trav.ID != travel.ID || trav.Spent != "15.00" || trav.Remaining != "5.00" || trav.Percentage != "75.00" || trav.Over {
+		t.Errorf("GetStatus() Travel = %+v", trav)
+	}
+
+	report, err = budgets.GetStatus("2024-02")
+	if err != nil {
+		t.Fatalf("GetStatus(2024-02) error = %v", err)
+	}
+	if report.Budgets[0].Period != "2024-02" || report.Budgets[0].Spent != "60.00" || !report.Budgets[0].Over {
+		t.Errorf("GetStatus(2024-02) Food = %+v", report.Budgets[0])
+	}
+
+	if _, err := budgets.GetStatus("2024-1"); err == nil {
+		t.Error("GetStatus() with an invalid month should fail")
+	}
+
+	// Update and delete
+	updated, err := budgets.UpdateBudget(travel.ID, models.BudgetRequest{Category: "Travel", Limit: "10", Currency: "USD"})
+	if err != nil || updated.Limit != "10.00" {
+		t.Errorf("UpdateBudget() = %+v, %v", updated, err)
+	}
+	if err := budgets.DeleteBudget(travel.ID); err != nil {
+		t.Errorf("DeleteBudget() error = %v", err)
+	}
+	if _, err := budgets.GetBudget(travel.ID); !isNotFound(err) {
+		t.Errorf("GetBudget() after delete error = %v, want not found", err)
+	}
+	if err := budgets.DeleteBudget(travel.ID); !isNotFound(err) {
+		t.Errorf("DeleteBudget() twice error = %v, want not found", err)
+	}
+}
+
+func TestEnvelopeService_Integration(t *testing.T) {
+	service, db := setupIntegration(t)
+	envelopes := NewEnvelopeService(repository.NewEnvelopeRepository(db), service.repo, "INR")
+
+	food, err := envelopes.CreateEnvelope(models.EnvelopeRequest{Category: "Food", Rollover: true})
+	if err != nil {
+		t.Fatalf("CreateEnvelope() error = %v", err)
+	}
+	travel, err := envelopes.CreateEnvelope(models.EnvelopeRequest{Category: "Travel", Allocation: "100"})
+	if err != nil {
+		t.Fatalf("CreateEnvelope() error = %v", err)
+	}
+	if food.Allocation != "0.00" || travel.Allocation != "100.00" || food.Currency != "INR" {
+		t.Errorf("CreateEnvelope() = %+v, %+v", food, travel)
+	}
+	if _, err := envelopes.CreateEnvelope(models.EnvelopeRequest{Category: "Food"}); err == nil {
+		t.Error("CreateEnvelope() duplicate should fail")
+	}
+	usd, err := envelopes.CreateEnvelope(models.EnvelopeRequest{Category: "Gifts", Currency: "USD"})
+	if err != nil {
+		t.Fatalf("CreateEnvelope(USD) error = %v", err)
+	}
+
+	// Income is split across envelopes; the split may not exceed it
+	period, err := envelopes.SetIncome(models.IncomeRequest{
+		Month:  "2024-01",
+		Amount: "1000",
+		Split:  []models.IncomeSplit{{EnvelopeID: food.ID, Amount: "300"}, {EnvelopeID: travel.ID, Amount: "200"}},
+	})
+	if err != nil {
+		t.Fatalf("SetIncome() error = %v", err)
+	}
+	if period.Income["INR"] != "1000.00" || period.Allocated["INR"] != "500.00" || period.Unassigned["INR"] != "500.00" {
+		t.Errorf("SetIncome() period = %+v", period)
+	}
+	for _, req := range []models.IncomeRequest{
+		{Month: "2024-01", Amount: "100", Split: []models.IncomeSplit{{EnvelopeID: food.ID, Amount: "100.01"}}},
+		{Month: "2024-01", Amount: "100", Split: []models.IncomeSplit{{EnvelopeID: usd.ID, Amount: "1"}}},
+		{Month: "2024-13", Amount: "100"},
+	} {
+		if _, err := envelopes.SetIncome(req); err == nil {
+			t.Errorf("SetIncome(%+v) should fail", req)
+		}
+	}
+
+	for _, e := range []struct{ amount, category, date string }{
+		{"250.00", "Food", "2024-01-12"},
+		{"50.00", "Travel", "2024-01-20"},
+		{"80.00", "Food", "2024-02-02"},
+	} {
+		if _, err := service.CreateExpense(models.CreateExpenseRequest{
+			Amount: e.amount, Category: e.category, Description: "Item", Date: e.date,
+		}); err != nil {
+			t.Fatalf("CreateExpense() error = %v", err)
+		}
+	}
+
+	// Transfers are recorded, and may not overdraw the source envelope
+	transfer, err := envelopes.Transfer(models.TransferRequest{
+		FromEnvelopeID: travel.ID, ToEnvelopeID: food.ID, Month: "2024-01", Amount: "20", Note: "Dinner out",
+	})
+	if err != nil {
+		t.Fatalf("Transfer() error = %v", err)
+	}
+	if transfer.Amount != "20.00" || transfer.Currency != "INR" || transfer.Note != "Dinner out" {
+		t.Errorf("Transfer() = %+v", transfer)
+	}
+	if _, err := envelopes.Transfer(models.TransferRequest{
+		FromEnvelopeID: travel.ID, ToEnvelopeID: food.ID, Month: "2024-01", Amount: "130.01",
+	}); err == nil {
+		t.Error("Transfer() overdrawing the source should fail")
+	} else if _, ok := err.(*ConflictError); !ok {
+		t.Errorf("Transfer() overdraw error = %v, want *ConflictError", err)
+	}
+	if _, err := envelopes.Transfer(models.TransferRequest{
+		FromEnvelopeID: travel.ID, ToEnvelopeID: usd.ID, Month: "2024-01", Amount: "1",
+	}); err == nil {
+		t.Error("Transfer() across currencies should fail")
+	}
+	if transfers, err := envelopes.GetTransfers("2024-01"); err != nil || len(transfers) != 1 {
+		t.Errorf("GetTransfers() = %+v, %v", transfers, err)
+	}
+
+	report, err := envelopes.GetStatus("2024-01", "2024-03")
+	if err != nil {
+		t.Fatalf("GetStatus() error = %v", err)
+	}
+	if len(report.Periods) != 3 {
+		t.Fatalf("GetStatus() periods = %d, want 3", len(report.Periods))
+	}
+
+	balance := func(month int, id string) models.EnvelopeBalance {
+		for _, b := range report.Periods[month].Envelopes {
+			if b.EnvelopeID == id {
+				return b
+			}
+		}
+		t.Fatalf("no balance for %s in %s", id, report.Periods[month].Month)
+		return models.EnvelopeBalance{}
+	}
+	want := []struct {
+		month                                         int
+		id                                            string
+		opening, allocated, in, out, spent, closingAt string
+	}{
+		{0, food.ID, "0.00", "300.00", "20.00", "0.00", "250.00", "70.00"},
+		{0, travel.ID, "0.00", "200.00", "0.00", "20.00", "50.00", "130.00"},
+		{1, food.ID, "70.00", "0.00", "0.00", "0.00", "80.00", "-10.00"},   // Rollover carries the surplus
+		{1, travel.ID, "0.00", "100.00", "0.00", "0.00", "0.00", "100.00"}, // No rollover; default allocation
+		{2, food.ID, "-10.00", "0.00", "0.00", "0.00", "0.00", "-10.00"},   // Overspending carries too
+	}
+	for _, w := range want {
+		b := balance(w.month, w.id)
+		got := []string{b.Opening, b.Allocated, b.TransfersIn, b.TransfersOut, b.Spent, b.Closing}
+		if !reflect.DeepEqual(got, []string{w.opening, w.allocated, w.in, w.out, w.spent, w.closingAt}) {
+			t.Errorf("%s %s = %v", report.Periods[w.month].Month, b.Category, got)
+		}
+	}
+	if feb := report.Periods[1]; feb.Income["INR"] != "0.00" || feb.Unassigned["INR"] != "-100.00" {
+		t.Errorf("February totals = %+v", feb)
+	}
+
+	// An explicit allocation overrides the default
+	if _, err := envelopes.SetAllocation(travel.ID, "2024-02", models.AllocationRequest{Amount: "40"}); err != nil {
+		t.Fatalf("SetAllocation() error = %v", err)
+	}
+	report, _ = envelopes.GetStatus("2024-02", "2024-02")
+	if b := balance(0, travel.ID); b.Allocated != "40.00" {
+		t.Errorf("allocation after SetAllocation() = %s, want 40.00", b.Allocated)
+	}
+
+	if _, err := envelopes.GetStatus("2024-03", "2024-01"); err == nil {
+		t.Error("GetStatus() with from > to should fail")
+	}
+	if err := envelopes.DeleteEnvelope(usd.ID); err != nil {
+		t.Errorf("DeleteEnvelope() error = %v", err)
+	}
+	if _, err := envelopes.GetEnvelope(usd.ID); !isNotFound(err) {
+		t.Errorf("GetEnvelope() after delete error = %v, want not found", err)
+	}
+}
+
+func TestEnvelopeService_TransferOverdraw_Integration(t *testing.T) {
+	service, db := setupIntegration(t)
+	envelopes := NewEnvelopeService(repository.NewEnvelopeRepository(db), service.repo, "INR")
+
+	food, err := envelopes.CreateEnvelope(models.EnvelopeRequest{Category: "Food", Allocation: "100"})
+	if err != nil {
+		t.Fatalf("CreateEnvelope() error = %v", err)
+	}
+	travel, err := envelopes.CreateEnvelope(models.EnvelopeRequest{Category: "Travel"})
+	if err != nil {
+		t.Fatalf("CreateEnvelope() error = %v", err)
+	}
+	month := time.Now().Format(monthLayout)
+
+	// Concurrent transfers are checked one after another, so together they cannot
+	// take more than the envelope holds
+	const attempts = 8
+	results := make(chan error, attempts)
+	for i := 0; i < attempts; i++ {
+		go func() {
+			_, err := envelopes.Transfer(models.TransferRequest{
+				FromEnvelopeID: food.ID, ToEnvelopeID: travel.ID, Month: month, Amount: "30",
+			})
+			results <- err
+		}()
+	}
+	succeeded := 0
+	for i := 0; i < attempts; i++ {
+		err := <-results
+		if err == nil {
+			succeeded++
+		} else if _, ok := err.(*ConflictError); !ok {
+			t.Errorf("concurrent Transfer() error = %v, want nil or *ConflictError", err)
+		}
+	}
+	if succeeded != 3 {
+		t.Errorf("concurrent Transfer() succeeded %d times, want 3", succeeded)
+	}
+
+	// A month before the envelope was active has no balance, which counts as zero
+	if _, err := envelopes.Transfer(models.TransferRequest{
+		FromEnvelopeID: travel.ID, ToEnvelopeID: food.ID, Month: "2000-01", Amount: "1",
+	}); err == nil {
+		t.Error("Transfer() from a month without a balance should fail")
+	} else if _, ok := err.(*ConflictError); !ok {
+		t.Errorf("Transfer() without a balance error = %v, want *ConflictError", err)
+	}
+	if transfers, err := envelopes.GetTransfers(""); err != nil || len(transfers) != succeeded {
+		t.Errorf("GetTransfers() = %d transfers, %v; want %d", len(transfers), err, succeeded)
+	}
+}
+
+func TestRecurringService_Integration(t *testing.T) {
+	service, db := setupIntegration(t)
+	repo := repository.NewRecurringRepository(db)
+	recurring := NewRecurringService(repo, service)
+
+	rent, err := recurring.CreateRecurring(models.RecurringExpenseRequest{
+		Amount: "1200", Category: "Rent", Description: "Flat", Frequency: "monthly", MonthDay: -1, StartDate: "2024-01-15",
+	})
+	if err != nil {
+		t.Fatalf("CreateRecurring() error = %v", err)
+	}
+	if rent.Amount != "1200.00" || rent.Currency != "INR" || rent.NextDate != "2024-01-31" {
+		t.Errorf("CreateRecurring() = %+v", rent)
+	}
+	if _, err := recurring.CreateRecurring(models.RecurringExpenseRequest{
+		Amount: "10", Category: "Rent", Description: "Flat", Frequency: "hourly", StartDate: "2024-01-15",
+	}); err == nil {
+		t.Error("CreateRecurring() with an invalid frequency should fail")
+	}
+
+	// Catch-up generates every missed occurrence, each linked to the template
+	created, err := recurring.Materialise(time.Date(2024, 4, 10, 9, 0, 0, 0, time.UTC))
+	if err != nil || created != 3 {
+		t.Fatalf("Materialise() = %d, %v, want 3", created, err)
+	}
+	expenses, _ := service.GetExpenses(models.ExpenseFilter{Categories: []string{"Rent"}}, "date")
+	var dates []string
+	for _, e := range expenses {
+		dates = append(dates, e.Date)
+		if e.RecurringID == nil || *e.RecurringID != rent.ID {
+			t.Errorf("expense %s recurring_id = %v, want %s", e.Date, e.RecurringID, rent.ID)
+		}
+	}
+	if !reflect.DeepEqual(dates, []string{"2024-01-31", "2024-02-29", "2024-03-31"}) {
+		t.Errorf("generated dates = %v", dates)
+	}
+
+	rent, _ = recurring.GetRecurring(rent.ID)
+	if rent.NextDate != "2024-04-30" || rent.LastGenerated != "2024-03-31" {
+		t.Errorf("schedule after Materialise() = next %s, last %s", rent.NextDate, rent.LastGenerated)
+	}
+
+	// Running again, or replaying after a crash that lost the schedule update, creates nothing
+	if created, err := recurring.Materialise(time.Date(2024, 4, 10, 0, 0, 0, 0, time.UTC)); err != nil || created != 0 {
+		t.Errorf("second Materialise() = %d, %v, want 0", created, err)
+	}
+	if err := repo.Advance(rent.ID, "2024-01-31", ""); err != nil {
+		t.Fatalf("Advance() error = %v", err)
+	}
+	if err := service.DeleteExpense(expenses[1].ID); err != nil {
+		t.Fatalf("DeleteExpense() error = %v", err)
+	}
+	if created, err := recurring.Materialise(time.Date(2024, 4, 10, 0, 0, 0, 0, time.UTC)); err != nil || created != 0 {
+		t.Errorf("replayed Materialise() = %d, %v, want 0 (trashed occurrences stay deleted)", created, err)
+	}
+
+	// Editing the template reschedules from the last generated occurrence
+	updated, err := recurring.UpdateRecurring(rent.ID, models.RecurringExpenseRequest{
+		Amount: "1300", Category: "Rent", Description: "Flat", Frequency: "monthly", MonthDay: 1, StartDate: "2024-01-15",
+	})
+	if err != nil {
+		t.Fatalf("UpdateRecurring() error = %v", err)
+	}
+	if updated.NextDate != "2024-04-01" || updated.Amount != "1300.00" {
+		t.Errorf("UpdateRecurring() = %+v", updated)
+	}
+
+	// A finished schedule has no next date; deleting the template keeps its expenses
+	finished, err := recurring.CreateRecurring(models.RecurringExpenseRequest{
+		Amount: "5", Category: "Coffee", Description: "Daily", Frequency: "daily", StartDate: "2024-01-01", EndDate: "2024-01-03",
+	})
+	if err != nil {
+		t.Fatalf("CreateRecurring() error = %v", err)
+	}
+	if created, _ := recurring.Materialise(time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)); created != 3 {
+		t.Errorf("Materialise() bounded schedule = %d, want 3", created)
+	}
+	if finished, _ = recurring.GetRecurring(finished.ID); finished.NextDate != "" {
+		t.Errorf("finished schedule next_date = %q, want empty", finished.NextDate)
+	}
+	if err := recurring.DeleteRecurring(finished.ID); err != nil {
+		t.Errorf("DeleteRecurring() error = %v", err)
+	}
+	if coffee, _ := service.GetExpenses(models.ExpenseFilter{Categories: []string{"Coffee"}}, ""); len(coffee) != 3 {
+		t.Errorf("expenses after DeleteRecurring() = %d, want 3", len(coffee))
+	}
+	if _, err := recurring.GetRecurring(finished.ID); !isNotFound(err) {
+		t.Errorf("GetRecurring() after delete error = %v, want not found", err)
+	}
+}
+
+func TestCategoryService_Integration(t *testing.T) {
+	service := setupIntegrationService(t)
+	categories := service.categories
+
+	food, err := categories.CreateCategory(models.CreateCategoryRequest{Name: " Food ", Colour: "#4CAF50", Icon: "🍔"})
+	if err != nil {
+		t.Fatalf("CreateCategory() error = %v", err)
+	}
+	if food.Name != "Food" || food.Colour != "#4caf50" {
+		t.Errorf("CreateCategory() = %+v", food)
+	}
+	if _, err := categories.CreateCategory(models.CreateCategoryRequest{Name: "FOOD"}); err == nil {
+		t.Error("CreateCategory() with a name differing only in case should conflict")
+	}
+	if _, err := categories.CreateCategory(models.CreateCategoryRequest{Name: "Misc", Colour: "green"}); err == nil {
+		t.Error("CreateCategory() with an invalid colour should fail")
+	}
+
+	// Expenses resolve to the canonical spelling; unknown categories are auto-created
+	expense, err := service.CreateExpense(models.CreateExpenseRequest{Amount: "10", Category: "food", Description: "Lunch", Date: "2024-01-15"})
+	if err != nil || expense.Category != "Food" {
+		t.Fatalf("CreateExpense() = %+v, %v, want category Food", expense, err)
+	}
+	if _, err := service.CreateExpense(models.CreateExpenseRequest{Amount: "10", Category: "Travel", Description: "Bus", Date: "2024-01-15"}); err != nil {
+		t.Fatalf("CreateExpense() with a new category error = %v", err)
+	}
+	if list, _ := categories.GetCategories(false); len(list) != 2 {
+		t.Errorf("GetCategories() = %d categories, want 2", len(list))
+	}
+
+	categories.autoCreate = false
+	if _, err := service.CreateExpense(models.CreateExpenseRequest{Amount: "10", Category: "Games", Description: "x", Date: "2024-01-15"}); err == nil {
+		t.Error("CreateExpense() with an unknown category should fail without auto-create")
+	}
+
+	// Archived categories stay listed on request but cannot be used
+	archived := true
+	if _, err := categories.UpdateCategory(food.ID, models.UpdateCategoryRequest{Archived: &archived}); err != nil {
+		t.Fatalf("UpdateCategory() error = %v", err)
+	}
+	if _, err := service.CreateExpense(models.CreateExpenseRequest{Amount: "10", Category: "Food", Description: "x", Date: "2024-01-15"}); err == nil {
+		t.Error("CreateExpense() with an archived category should fail")
+	}
+	if list, _ := categories.GetCategories(false); len(list) != 1 {
+		t.Errorf("GetCategories() without archived = %d, want 1", len(list))
+	}
+	if list, _ := categories.GetCategories(true); len(list) != 2 {
+		t.Errorf("GetCategories() with archived = %d, want 2", len(list))
+	}
+
+	// Categories in use cannot be deleted
+	if err := categories.DeleteCategory(food.ID, ""); err == nil {
+		t.Error("DeleteCategory() of a used category should conflict")
+	}
+	unused, _ := categories.CreateCategory(models.CreateCategoryRequest{Name: "Unused"})
+	if err := categories.DeleteCategory(unused.ID, ""); err != nil {
+		t.Errorf("DeleteCategory() error = %v", err)
+	}
+	if _, err := categories.GetCategory(unused.ID); !isNotFound(err) {
+		t.Errorf("GetCategory() after delete error = %v, want not found", err)
+	}
+}
+
+func TestCategoryMigration_Integration(t *testing.T) {
+	db := openIntegrationDB(t)
+
+	// Simulate a database from before categories and versioned migrations existed
+	for _, name := range []string{"Food", "food ", "FOOD", "Travel"} {
+		if _, err := db.Exec(
+			`INSERT INTO expenses (id, amount, category, description, date, created_at) VALUES (?, '1.00', ?, 'x', '2024-01-01', ?)`,
+			name+"-id", name, time.Now().Format(time.RFC3339),
+		); err != nil {
+			t.Fatalf("seed expense: %v", err)
+		}
+	}
+	if _, err := db.Exec(`DROP TABLE categories; DROP TABLE schema_migrations`); err != nil {
+		t.Fatalf("drop categories: %v", err)
+	}
+	if _, err := database.MigrateUp(db, database.DriverSQLite); err != nil {
+		t.Fatalf("MigrateUp() error = %v", err)
+	}
+
+	list, err := repository.NewCategoryRepository(db, nil).List(true)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	var names []string
+	for _, c := range list {
+		names = append(names, c.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"FOOD", "Travel"}) {
+		t.Errorf("migrated categories = %v, want [FOOD Travel]", names)
+	}
+
+	var spellings int
+	db.QueryRow(`SELECT COUNT(DISTINCT category) FROM expenses`).Scan(&spellings)
+	if spellings != 2 {
+		t.Errorf("distinct expense categories after migration = %d, want 2", spellings)
+	}
+}
+
+func TestCategoryHierarchy_Integration(t *testing.T) {
+	service, db := setupIntegration(t)
+	categories := service.categories
+	summaries := NewSummaryService(repository.NewExpenseRepository(db), repository.NewCategoryRepository(db, nil), nil, time.Monday)
+
+	food, _ := categories.CreateCategory(models.CreateCategoryRequest{Name: "Food"})
+	groceries, err := categories.CreateCategory(models.CreateCategoryRequest{Name: "Groceries", ParentID: food.ID})
+	if err != nil || groceries.ParentID == nil || *groceries.ParentID != food.ID {
+		t.Fatalf("CreateCategory() with parent = %+v, %v", groceries, err)
+	}
+	produce, _ := categories.CreateCategory(models.CreateCategoryRequest{Name: "Produce", ParentID: groceries.ID})
+	categories.CreateCategory(models.CreateCategoryRequest{Name: "Restaurants", ParentID: food.ID})
+	if _, err := categories.CreateCategory(models.CreateCategoryRequest{Name: "Orphan", ParentID: "missing"}); err == nil {
+		t.Error("CreateCategory() with an unknown parent should fail")
+	}
+
+	// Moving a category under itself or a descendant would create a cycle
+	for _, parent := range []string{food.ID, produce.ID} {
+		if _, err := categories.UpdateCategory(food.ID, models.UpdateCategoryRequest{ParentID: &parent}); err == nil {
+			t.Errorf("UpdateCategory() parent %s should be rejected as a cycle", parent)
+		}
+	}
+
+	for _, e := range []struct{ amount, category string }{
+		{"10", "Food"}, {"20", "Groceries"}, {"5", "Produce"}, {"40", "Restaurants"}, {"25", "Rent"},
+	} {
+		if _, err := service.CreateExpense(models.CreateExpenseRequest{Amount: e.amount, Category: e.category, Description: "x", Date: "2024-01-15"}); err != nil {
+			t.Fatalf("CreateExpense() error = %v", err)
+		}
+	}
+
+	// The category filter optionally matches descendants
+	for _, tc := range []struct {
+		filter models.ExpenseFilter
+		want   int
+	}{
+		{models.ExpenseFilter{Categories: []string{"Food"}}, 1},
+		{models.ExpenseFilter{Categories: []string{"Food"}, IncludeSubcategories: true}, 4},
+		{models.ExpenseFilter{Categories: []string{"Groceries"}, IncludeSubcategories: true}, 2},
+		{models.ExpenseFilter{ExcludeCategories: []string{"Groceries"}, IncludeSubcategories: true}, 3},
+	} {
+		if got, _ := service.GetExpenses(tc.filter, ""); len(got) != tc.want {
+			t.Errorf("GetExpenses(%+v) = %d expenses, want %d", tc.filter, len(got), tc.want)
+		}
+	}
+
+	// Summaries roll subcategory spend up into every ancestor
+	summary, err := summaries.GetCategorySummary(models.ExpenseFilter{}, "")
+	if err != nil {
+		t.Fatalf("GetCategorySummary() error = %v", err)
+	}
+	rollups := map[string]string{}
+	for _, c := range summary.Categories {
+		if c.Rollup != nil {
+			rollups[c.Category] = c.Rollup.Total["INR"] + "/" + c.Rollup.Share["INR"]
+		}
+		if c.Category == "Produce" && c.Parent != "Groceries" {
+			t.Errorf("Produce parent = %q, want Groceries", c.Parent)
+		}
+	}
+	if want := map[string]string{"Food": "75.00/0.7500", "Groceries": "25.00/0.2500"}; !reflect.DeepEqual(rollups, want) {
+		t.Errorf("rollups = %v, want %v", rollups, want)
+	}
+
+	// Deleting a parent re-parents its children, or reassigns them and its expenses
+	if err := categories.DeleteCategory(groceries.ID, ""); err == nil {
+		t.Error("DeleteCategory() of a used category without reassign_to should conflict")
+	}
+	if err := categories.DeleteCategory(food.ID, produce.ID); err == nil {
+		t.Error("DeleteCategory() reassigning to a descendant should fail")
+	}
+	other, _ := categories.CreateCategory(models.CreateCategoryRequest{Name: "Other"})
+	if err := categories.DeleteCategory(groceries.ID, other.ID); err != nil {
+		t.Fatalf("DeleteCategory() with reassign_to error = %v", err)
+	}
+	if moved, _ := categories.GetCategory(produce.ID); moved.ParentID == nil || *moved.ParentID != other.ID {
+		t.Errorf("Produce parent after reassign = %v, want %s", moved.ParentID, other.ID)
+	}
+	if got, _ := service.GetExpenses(models.ExpenseFilter{Categories: []string{"Other"}}, ""); len(got) != 1 {
+		t.Errorf("expenses reassigned to Other = %d, want 1", len(got))
+	}
+
+	empty, _ := categories.CreateCategory(models.CreateCategoryRequest{Name: "Empty", ParentID: food.ID})
+	child, _ := categories.CreateCategory(models.CreateCategoryRequest{Name: "Child", ParentID: empty.ID})
+	if err := categories.DeleteCategory(empty.ID, ""); err != nil {
+		t.Fatalf("DeleteCategory() error = %v", err)
+	}
+	if moved, _ := categories.GetCategory(child.ID); moved.ParentID == nil || *moved.ParentID != food.ID {
+		t.Errorf("Child parent after delete = %v, want %s", moved.ParentID, food.ID)
+	}
+}
+
+func TestCategoryDelete_References_Integration(t *testing.T) {
+	service, db := setupIntegration(t)
+	categories := service.categories
+	recurring := NewRecurringService(repository.NewRecurringRepository(db), service)
+
+	gym, err := categories.CreateCategory(models.CreateCategoryRequest{Name: "Gym"})
+	if err != nil {
+		t.Fatalf("CreateCategory() error = %v", err)
+	}
+	other, _ := categories.CreateCategory(models.CreateCategoryRequest{Name: "Other"})
+	if _, err := service.budgets.CreateBudget(models.BudgetRequest{Category: "Gym", Limit: "50"}); err != nil {
+		t.Fatalf("CreateBudget() error = %v", err)
+	}
+	template, err := recurring.CreateRecurring(models.RecurringExpenseRequest{
+		Amount: "30", Category: "Gym", Description: "Membership", Frequency: "monthly", MonthDay: 1, StartDate: "2024-01-01",
+	})
+	if err != nil {
+		t.Fatalf("CreateRecurring() error = %v", err)
+	}
+
+	// A category without expenses is still in use by its budget and template
+	if err := categories.DeleteCategory(gym.ID, ""); err == nil {
+		t.Fatal("DeleteCategory() of a category with a budget and template should conflict")
+	} else if _, ok := err.(*ConflictError); !ok {
+		t.Fatalf("DeleteCategory() error = %v, want *ConflictError", err)
+	}
+
+	// Reassigning moves them along with the expenses
+	if err := categories.DeleteCategory(gym.ID, other.ID); err != nil {
+		t.Fatalf("DeleteCategory() with reassign_to error = %v", err)
+	}
+	if got, _ := recurring.GetRecurring(template.ID); got.Category != "Other" {
+		t.Errorf("template category after reassign = %q, want Other", got.Category)
+	}
+	if budgets, _ := service.budgets.GetBudgets(); len(budgets) != 1 || budgets[0].Category != "Other" {
+		t.Errorf("budgets after reassign = %+v, want one for Other", budgets)
+	}
+}
+
+func TestCategoryRenameMerge_Integration(t *testing.T) {
+	service := setupIntegrationService(t)
+	categories := service.categories
+	budgets := service.budgets
+
+	for _, e := range []struct{ amount, category string }{
+		{"10", "Food"}, {"20", "food stuff"}, {"30", "Meals"}, {"40", "Travel"},
+	} {
+		if _, err := service.CreateExpense(models.CreateExpenseRequest{Amount: e.amount, Category: e.category, Description: "x", Date: "2024-01-15"}); err != nil {
+			t.Fatalf("CreateExpense() error = %v", err)
+		}
+	}
+	byName := map[string]*models.Category{}
+	list, _ := categories.GetCategories(true)
+	for i := range list {
+		byName[list[i].Name] = &list[i]
+	}
+	budgets.CreateBudget(models.BudgetRequest{Category: "Food", Limit: "100"})
+	budgets.CreateBudget(models.BudgetRequest{Category: "Meals", Limit: "50"})
+
+	// Rename rewrites expenses and budgets and records the change
+	change, err := categories.RenameCategory(byName["food stuff"].ID, models.RenameCategoryRequest{Name: " Groceries "})
+	if err != nil {
+		t.Fatalf("RenameCategory() error = %v", err)
+	}
+	if change.Category.Name != "Groceries" || change.Audit.RowsChanged.Expenses != 1 || change.Audit.Action != models.CategoryActionRename {
+		t.Errorf("RenameCategory() = %+v", change)
+	}
+	if _, err := categories.RenameCategory(byName["Meals"].ID, models.RenameCategoryRequest{Name: "TRAVEL"}); err == nil {
+		t.Error("RenameCategory() onto another category's name should conflict")
+	}
+
+	// A merge whose budgets collide is rolled back entirely
+	merge := models.MergeCategoriesRequest{SourceIDs: []string{byName["food stuff"].ID, byName["Meals"].ID}, TargetID: byName["Food"].ID}
+	if _, err := categories.MergeCategories(merge); err == nil {
+		t.Fatal("MergeCategories() with colliding budgets should conflict")
+	}
+	if got, _ := service.GetExpenses(models.ExpenseFilter{Categories: []string{"Groceries"}}, ""); len(got) != 1 {
+		t.Errorf("expenses left in Groceries after failed merge = %d, want 1", len(got))
+	}
+	if _, err := categories.GetCategory(byName["food stuff"].ID); err != nil {
+		t.Errorf("source category after failed merge error = %v", err)
+	}
+
+	all, _ := budgets.GetBudgets()
+	for _, b := range all {
+		if b.Category == "Meals" {
+			budgets.DeleteBudget(b.ID)
+		}
+	}
+	change, err = categories.MergeCategories(merge)
+	if err != nil {
+		t.Fatalf("MergeCategories() error = %v", err)
+	}
+	if want := (models.CategoryRowsChanged{Expenses: 2}); change.Audit.RowsChanged != want {
+		t.Errorf("MergeCategories() rows changed = %+v, want %+v", change.Audit.RowsChanged, want)
+	}
+	if got, _ := service.GetExpenses(models.ExpenseFilter{Categories: []string{"Food"}}, ""); len(got) != 3 {
+		t.Errorf("expenses in Food after merge = %d, want 3", len(got))
+	}
+	if _, err := categories.GetCategory(byName["Meals"].ID); !isNotFound(err) {
+		t.Errorf("merged source error = %v, want not found", err)
+	}
+
+	audit, _ := categories.GetAudit()
+	if len(audit) != 2 || audit[0].Action != models.CategoryActionMerge ||
+		!reflect.DeepEqual(audit[0].PreviousNames, []string{"Groceries", "Meals"}) {
+		t.Errorf("GetAudit() = %+v", audit)
+	}
+}
+
+func TestTags_Integration(t *testing.T) {
+	service, db := setupIntegration(t)
+	tags := NewTagService(repository.NewExpenseRepository(db))
+	summaries := NewSummaryService(repository.NewExpenseRepository(db), nil, nil, time.Monday)
+
+	create := func(amount string, tags ...string) *models.Expense {
+		t.Helper()
+		expense, err := service.CreateExpense(models.CreateExpenseRequest{
+			Amount: amount, Category: "Travel", Description: "x", Date: "2024-01-15", Tags: tags,
+		})
+		if err != nil {
+			t.Fatalf("CreateExpense() error = %v", err)
+		}
+		return expense
+	}
+	taxi := create("10", " Business", "trip-goa-2024", "business", "")
+	hotel := create("20", "business", "reimbursable")
+	create("30", "trip-goa-2024")
+	untagged := create("40")
+
+	if !reflect.DeepEqual(taxi.Tags, []string{"business", "trip-goa-2024"}) {
+		t.Errorf("CreateExpense() tags = %v", taxi.Tags)
+	}
+	if got, _ := service.GetExpense(hotel.ID); !reflect.DeepEqual(got.Tags, []string{"business", "reimbursable"}) {
+		t.Errorf("GetExpense() tags = %v", got.Tags)
+	}
+	if got, _ := service.GetExpense(untagged.ID); got.Tags == nil || len(got.Tags) != 0 {
+		t.Errorf("GetExpense() untagged tags = %#v, want empty list", got.Tags)
+	}
+
+	// Tag filters match any or all of the given tags
+	for _, tc := range []struct {
+		filter models.ExpenseFilter
+		want   int
+	}{
+		{models.ExpenseFilter{Tags: []string{"business"}}, 2},
+		{models.ExpenseFilter{Tags: []string{"BUSINESS", "trip-goa-2024"}}, 3},
+		{models.ExpenseFilter{Tags: []string{"business", "trip-goa-2024"}, TagMode: "all"}, 1},
+		{models.ExpenseFilter{Tags: []string{"business", "business"}, TagMode: "all"}, 2},
+	} {
+		if got, err := service.GetExpenses(tc.filter, ""); err != nil || len(got) != tc.want {
+			t.Errorf("GetExpenses(%+v) = %d expenses, %v, want %d", tc.filter, len(got), err, tc.want)
+		}
+	}
+	if _, err := service.GetExpenses(models.ExpenseFilter{Tags: []string{"x"}, TagMode: "some"}, ""); err == nil {
+		t.Error("GetExpenses() with an invalid tag_mode should fail")
+	}
+
+	// PATCH replaces tags only when given
+	empty := []string{}
+	if patched, _ := service.PatchExpense(taxi.ID, models.UpdateExpenseRequest{Description: &taxi.Description}); len(patched.Tags) != 2 {
+		t.Errorf("PatchExpense() without tags = %v, want tags kept", patched.Tags)
+	}
+	if patched, _ := service.PatchExpense(taxi.ID, models.UpdateExpenseRequest{Tags: &empty}); len(patched.Tags) != 0 {
+		t.Errorf("PatchExpense() with [] = %v, want no tags", patched.Tags)
+	}
+	service.PatchExpense(taxi.ID, models.UpdateExpenseRequest{Tags: &[]string{"business", "trip-goa-2024"}})
+
+	summary, err := summaries.GetTagSummary(models.ExpenseFilter{}, "")
+	if err != nil {
+		t.Fatalf("GetTagSummary() error = %v", err)
+	}
+	got := map[string]string{}
+	for _, tag := range summary.Tags {
+		got[tag.Tag] = tag.Total["INR"] + "/" + tag.Share["INR"]
+	}
+	want := map[string]string{"business": "30.00/0.3000", "reimbursable": "20.00/0.2000", "trip-goa-2024": "40.00/0.4000"}
+	if summary.Count != 4 || summary.Total["INR"] != "100.00" || !reflect.DeepEqual(got, want) {
+		t.Errorf("GetTagSummary() = %d %v %v, want 4 100.00 %v", summary.Count, summary.Total, got, want)
+	}
+
+	// Rename and merge keep every expense's labels
+	list, _ := tags.GetTags()
+	ids := map[string]string{}
+	for _, tag := range list {
+		ids[tag.Name] = tag.ID
+	}
+	if _, err := tags.RenameTag(ids["reimbursable"], models.RenameTagRequest{Name: "Business"}); err == nil {
+		t.Error("RenameTag() onto an existing tag should conflict")
+	}
+	if renamed, err := tags.RenameTag(ids["trip-goa-2024"], models.RenameTagRequest{Name: "Trip-Goa"}); err != nil || renamed.Name != "trip-goa" || renamed.ExpenseCount != 2 {
+		t.Errorf("RenameTag() = %+v, %v", renamed, err)
+	}
+	merge, err := tags.MergeTags(models.MergeTagsRequest{SourceIDs: []string{ids["reimbursable"]}, TargetID: ids["business"]})
+	if err != nil || merge.ExpensesRetagged != 0 || merge.Tag.ExpenseCount != 2 {
+		t.Errorf("MergeTags() = %+v, %v, want nothing retagged", merge, err)
+	}
+	if got, _ := service.GetExpense(hotel.ID); !reflect.DeepEqual(got.Tags, []string{"business"}) {
+		t.Errorf("tags after merge = %v, want [business]", got.Tags)
+	}
+	if err := tags.DeleteTag(ids["business"]); err != nil {
+		t.Fatalf("DeleteTag() error = %v", err)
+	}
+	if got, _ := service.GetExpenses(models.ExpenseFilter{Tags: []string{"business"}}, ""); len(got) != 0 {
+		t.Errorf("expenses tagged business after delete = %d, want 0", len(got))
+	}
+}
+
+func TestExpenseService_Search_Integration(t *testing.T) {
+	service := setupIntegrationService(t)
+
+	ids := map[string]string{}
+	for _, description := range []string{
+		"Amazon order: headphones",
+		"Amazon order",
+		"Dinner near the Amazon office",
+		"Café latte",
+		"Order pizza from Amazonia",
+	} {
+		expense, err := service.CreateExpense(models.CreateExpenseRequest{Amount: "10", Category: "Misc", Description: description, Date: "2024-03-05"})
+		if err != nil {
+			t.Fatalf("CreateExpense() error = %v", err)
+		}
+		ids[description] = expense.ID
+	}
+
+	if _, err := service.GetExpenses(models.ExpenseFilter{}, "relevance"); err == nil {
+		t.Error("GetExpenses() sorted by relevance without q should fail")
+	}
+
+	if !service.repo.FullTextSearch() {
+		// Without FTS5 (build with -tags sqlite_fts5) q is a substring match
+		if got, _ := service.GetExpenses(models.ExpenseFilter{Query: "amazon order"}, ""); len(got) != 2 {
+			t.Errorf("substring search = %d expenses, want 2", len(got))
+		}
+		t.Skip("SQLite built without FTS5")
+	}
+
+	search := func(q string) []models.Expense {
+		t.Helper()
+		got, err := service.GetExpenses(models.ExpenseFilter{Query: q}, "")
+		if err != nil {
+			t.Fatalf("GetExpenses(q=%q) error = %v", q, err)
+		}
+		return got
+	}
+
+	// Words match the start of tokens in any order, ranked by relevance
+	got := search("order amazon")
+	if len(got) != 3 || got[0].ID != ids["Amazon order"] {
+		t.Errorf("search(order amazon) = %v, want the exact match first", descriptions(got))
+	}
+	if got[0].Relevance == nil || got[0].Snippet != "<mark>Amazon</mark> <mark>order</mark>" {
+		t.Errorf("search(order amazon) relevance = %v, snippet = %q", got[0].Relevance, got[0].Snippet)
+	}
+	if got := search("amazon"); len(got) != 4 {
+		t.Errorf("search(amazon) = %v, want 4 prefix matches", descriptions(got))
+	}
+	if got := search(`"amazon"`); len(got) != 3 {
+		t.Errorf(`search("amazon") = %v, want 3 exact matches`, descriptions(got))
+	}
+	if got := search(`"order amazon"`); len(got) != 0 {
+		t.Errorf(`search("order amazon") = %v, want no phrase match`, descriptions(got))
+	}
+	if got := search("cafe"); len(got) != 1 {
+		t.Errorf("search(cafe) = %v, want the accented match", descriptions(got))
+	}
+	if got := search(`"`); len(got) != 0 {
+		t.Errorf(`search(") = %v, want nothing`, descriptions(got))
+	}
+
+	// The index follows edits and purges
+	headphones := "Flipkart order: headphones"
+	service.PatchExpense(ids["Amazon order: headphones"], models.UpdateExpenseRequest{Description: &headphones})
+	if got := search("flipkart"); len(got) != 1 {
+		t.Errorf("search(flipkart) after edit = %d, want 1", len(got))
+	}
+	if got := search(`"amazon order"`); len(got) != 1 {
+		t.Errorf("phrase search after edit = %d, want 1", len(got))
+	}
+	service.DeleteExpense(ids["Amazon order"])
+	if got := search(`"amazon order"`); len(got) != 0 {
+		t.Errorf("phrase search after delete = %d, want 0", len(got))
+	}
+
+	// Relevance order pages with a cursor
+	page, err := service.GetExpensesPage(models.ExpenseFilter{Query: "amazon*"}, "", "", 1)
+	if err != nil || page.NextCursor == nil {
+		t.Fatalf("GetExpensesPage() = %+v, %v", page, err)
+	}
+	seen := map[string]bool{page.Items[0].ID: true}
+	for cursor := page.NextCursor; cursor != nil; cursor = page.NextCursor {
+		if page, err = service.GetExpensesPage(models.ExpenseFilter{Query: "amazon*"}, "", *cursor, 1); err != nil {
+			t.Fatalf("GetExpensesPage() error = %v", err)
+		}
+		for _, e := range page.Items {
+			if seen[e.ID] {
+				t.Errorf("expense %s returned twice", e.ID)
+			}
+			seen[e.ID] = true
+		}
+	}
+	if len(seen) != 2 {
+		t.Errorf("paged search returned %d expenses, want 2", len(seen))
+	}
+}
+
+// descriptions lists the descriptions of expenses, for failure messages
+func descriptions(expenses []models.Expense) []string {
+	var list []string
+	for _, e := range expenses {
+		list = append(list, e.Description)
+	}
+	return list
+}
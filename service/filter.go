@@ -0,0 +1,95 @@
+package service
+
+import (
+	"fenmo-ai-assignment/models"
+	"fenmo-ai-assignment/money"
+	"fenmo-ai-assignment/utils"
+	"strings"
+)
+
+// filterAmountScale allows filter amounts as precise as the most precise currency
+const filterAmountScale = 3
+
+// normalizeFilter validates a filter and rewrites its values in canonical form
+func normalizeFilter(filter *models.ExpenseFilter) error {
+	filter.Categories = cleanValues(filter.Categories)
+	filter.ExcludeCategories = cleanValues(filter.ExcludeCategories)
+	filter.Query = strings.TrimSpace(filter.Query)
+
+	tags, err := normalizeTags(filter.Tags)
+	if err != nil {
+		return err
+	}
+	filter.Tags = nil
+	if len(tags) > 0 {
+		filter.Tags = tags
+	}
+	switch filter.TagMode = strings.ToLower(strings.TrimSpace(filter.TagMode)); filter.TagMode {
+	case "", models.TagModeAny, models.TagModeAll:
+	default:
+		return &ValidationError{Message: "tag_mode must be any or all"}
+	}
+
+	currency, err := normalizeCurrencyFilter(filter.Currency)
+	if err != nil {
+		return err
+	}
+	filter.Currency = currency
+
+	// Validate date range
+	for _, date := range []*string{&filter.From, &filter.To} {
+		*date = strings.TrimSpace(*date)
+		if *date == "" {
+			continue
+		}
+		if err := utils.ValidateDate(*date); err != nil {
+			return &ValidationError{Message: err.Error()}
+		}
+	}
+	if filter.From != "" && filter.To != "" && filter.From > filter.To {
+		return &ValidationError{Message: "from must not be after to"}
+	}
+
+	// Validate amount range
+	var bounds [2]*money.Decimal
+	for i, amount := range []*string{&filter.MinAmount, &filter.MaxAmount} {
+		if strings.TrimSpace(*amount) == "" {
+			*amount = ""
+			continue
+		}
+		value, err := utils.ParseAmountWithScale(*amount, filterAmountScale)
+		if err != nil {
+			return &ValidationError{Message: strings.Replace(err.Error(), "amount", "amount filter", 1)}
+		}
+		*amount = value.String()
+		bounds[i] = &value
+	}
+	if bounds[0] != nil && bounds[1] != nil && bounds[0].Cmp(*bounds[1]) > 0 {
+		return &ValidationError{Message: "min_amount must not be greater than max_amount"}
+	}
+
+	return nil
+}
+
+// normalizeCurrencyFilter validates an optional currency filter
+func normalizeCurrencyFilter(currency string) (string, error) {
+	if currency == "" {
+		return "", nil
+	}
+	c, ok := money.LookupCurrency(currency)
+	if !ok {
+		return "", &ValidationError{Message: "unsupported currency: " + currency}
+	}
+	return c.Code, nil
+}
+
+// cleanValues trims each value and drops empty ones
+func cleanValues(values []string) []string {
+	var cleaned []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			cleaned = append(cleaned, v)
+		}
+	}
+	return cleaned
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fenmo-ai-assignment/models"
+	"fenmo-ai-assignment/money"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// FX file formats accepted by ParseRates
+const (
+	FXFormatCSV = "csv"
+	FXFormatXML = "xml"
+)
+
+// ecbDateLayouts are the date formats used by ECB reference rate files:
+// the historical files use ISO dates, the daily CSV uses "15 January 2024"
+var ecbDateLayouts = []string{"2006-01-02", "2 January 2006"}
+
+// ParseRates parses an ECB-style rates file in the given format.
+// Every rate is expressed as units of currency per one unit of base.
+func ParseRates(r io.Reader, format, base string) ([]models.FXRate, error) {
+	baseCurrency, ok := money.LookupCurrency(base)
+	if !ok {
+		return nil, &ValidationError{Message: "unsupported base currency: " + base}
+	}
+
+	switch format {
+	case FXFormatCSV:
+		return parseECBCSV(r, baseCurrency.Code)
+	case FXFormatXML:
+		return parseECBXML(r, baseCurrency.Code)
+	default:
+		return nil, &ValidationError{Message: "unsupported FX file format: " + format}
+	}
+}
+
+// parseECBCSV parses the ECB CSV layout: a header of "Date,USD,JPY,..." followed
+// by one row per day. Missing values ("N/A" or empty) are skipped.
+func parseECBCSV(r io.Reader, base string) ([]models.FXRate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, &ValidationError{Message: "FX file is empty"}
+	}
+	if err != nil {
+		return nil, &ValidationError{Message: "invalid FX CSV: " + err.Error()}
+	}
+	if len(header) < 2 || !strings.EqualFold(strings.TrimSpace(header[0]), "Date") {
+		return nil, &ValidationError{Message: "invalid FX CSV: first column must be Date"}
+	}
+
+	var rates []models.FXRate
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, &ValidationError{Message: "invalid FX CSV: " + err.Error()}
+		}
+
+		date, err := parseECBDate(record[0])
+		if err != nil {
+			return nil, &ValidationError{Message: fmt.Sprintf("invalid FX CSV line %d: %v", line, err)}
+		}
+
+		for i := 1; i < len(record) && i < len(header); i++ {
+			rate, ok, err := newFXRate(base, header[i], date, record[i])
+			if err != nil {
+				return nil, &ValidationError{Message: fmt.Sprintf("invalid FX CSV line %d: %v", line, err)}
+			}
+			if ok {
+				rates = append(rates, rate)
+			}
+		}
+	}
+
+	return rates, nil
+}
+
+// ecbEnvelope mirrors the ECB eurofxref XML layout:
+// <Cube><Cube time="2024-01-15"><Cube currency="USD" rate="1.0945"/>...</Cube></Cube>
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string `xml:"currency,attr"`
+			Rate     string `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+// parseECBXML parses the ECB eurofxref XML layout
+func parseECBXML(r io.Reader, base string) ([]models.FXRate, error) {
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(r).Decode(&envelope); err != nil {
+		return nil, &ValidationError{Message: "invalid FX XML: " + err.Error()}
+	}
+
+	var rates []models.FXRate
+	for _, day := range envelope.Days {
+		date, err := parseECBDate(day.Time)
+		if err != nil {
+			return nil, &ValidationError{Message: "invalid FX XML: " + err.Error()}
+		}
+
+		for _, entry := range day.Rates {
+			rate, ok, err := newFXRate(base, entry.Currency, date, entry.Rate)
+			if err != nil {
+				return nil, &ValidationError{Message: "invalid FX XML: " + err.Error()}
+			}
+			if ok {
+				rates = append(rates, rate)
+			}
+		}
+	}
+
+	return rates, nil
+}
+
+// parseECBDate normalises an ECB date to YYYY-MM-DD
+func parseECBDate(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range ecbDateLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed.Format("2006-01-02"), nil
+		}
+	}
+	return "", fmt.Errorf("invalid date %q", value)
+}
+
+// newFXRate validates a single rate. It returns ok=false for blank or N/A values,
+// which ECB uses for currencies that were not quoted on a given day.
+func newFXRate(base, currency, date, value string) (models.FXRate, bool, error) {
+	currency = money.NormalizeCurrencyCode(currency)
+	value = strings.TrimSpace(value)
+
+	if currency == "" || value == "" || strings.EqualFold(value, "N/A") {
+		return models.FXRate{}, false, nil
+	}
+	if len(currency) != 3 {
+		return models.FXRate{}, false, fmt.Errorf("invalid currency code %q", currency)
+	}
+
+	rate, err := money.Parse(value)
+	if err != nil || rate.Sign() <= 0 {
+		return models.FXRate{}, false, fmt.Errorf("invalid rate %q for %s on %s", value, currency, date)
+	}
+
+	return models.FXRate{Base: base, Currency: currency, Date: date, Rate: rate.String()}, true, nil
+}
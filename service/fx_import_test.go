@@ -0,0 +1,90 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRates_CSV(t *testing.T) {
+	// Layout of the ECB historical file, including N/A values and a trailing comma
+	input := `Date,USD,JPY,CYP,
+2024-01-16,1.0875,160.46,N/A,
+2024-01-15,1.0945,160.89,N/A,
+`
+	rates, err := ParseRates(strings.NewReader(input), FXFormatCSV, "eur")
+	if err != nil {
+		t.Fatalf("ParseRates() error = %v", err)
+	}
+	if len(rates) != 4 {
+		t.Fatalf("ParseRates() returned %d rates, want 4", len(rates))
+	}
+	if rates[0].Base != "EUR" || rates[0].Currency != "USD" || rates[0].Date != "2024-01-16" || rates[0].Rate != "1.0875" {
+		t.Errorf("ParseRates() first rate = %+v", rates[0])
+	}
+}
+
+func TestParseRates_DailyCSV(t *testing.T) {
+	// Layout of the ECB daily file: spaces after commas and a long-form date
+	input := "Date, USD, JPY, \n15 January 2024, 1.0945, 160.89, \n"
+	rates, err := ParseRates(strings.NewReader(input), FXFormatCSV, "EUR")
+	if err != nil {
+		t.Fatalf("ParseRates() error = %v", err)
+	}
+	if len(rates) != 2 || rates[1].Currency != "JPY" || rates[1].Date != "2024-01-15" {
+		t.Errorf("ParseRates() = %+v", rates)
+	}
+}
+
+func TestParseRates_XML(t *testing.T) {
+	input := `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2024-01-16">
+			<Cube currency="USD" rate="1.0875"/>
+			<Cube currency="INR" rate="90.3415"/>
+		</Cube>
+		<Cube time="2024-01-15">
+			<Cube currency="USD" rate="1.0945"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+	rates, err := ParseRates(strings.NewReader(input), FXFormatXML, "EUR")
+	if err != nil {
+		t.Fatalf("ParseRates() error = %v", err)
+	}
+	if len(rates) != 3 {
+		t.Fatalf("ParseRates() returned %d rates, want 3", len(rates))
+	}
+	if rates[1].Currency != "INR" || rates[1].Rate != "90.3415" || rates[2].Date != "2024-01-15" {
+		t.Errorf("ParseRates() = %+v", rates)
+	}
+}
+
+func TestParseRates_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		format string
+		base   string
+	}{
+		{"empty csv", "", FXFormatCSV, "EUR"},
+		{"missing date header", "Day,USD\n2024-01-15,1.1\n", FXFormatCSV, "EUR"},
+		{"bad date", "Date,USD\n15/01/2024,1.1\n", FXFormatCSV, "EUR"},
+		{"negative rate", "Date,USD\n2024-01-15,-1.1\n", FXFormatCSV, "EUR"},
+		{"exponent rate", "Date,USD\n2024-01-15,1e3\n", FXFormatCSV, "EUR"},
+		{"malformed xml", "<Cube><Cube time=", FXFormatXML, "EUR"},
+		{"unknown format", "Date,USD\n", "json", "EUR"},
+		{"unknown base", "Date,USD\n", FXFormatCSV, "XYZ"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRates(strings.NewReader(tt.input), tt.format, tt.base)
+			if _, ok := err.(*ValidationError); !ok {
+				t.Errorf("ParseRates() error = %v, want *ValidationError", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"errors"
+	"fenmo-ai-assignment/models"
+	"fenmo-ai-assignment/money"
+	"fenmo-ai-assignment/repository"
+	"io"
+)
+
+// FXService imports exchange rates and converts amounts between currencies.
+// All rates come from locally imported files, so conversion works offline.
+type FXService struct {
+	repo *repository.FXRateRepository
+}
+
+// NewFXService creates a new FX service
+func NewFXService(repo *repository.FXRateRepository) *FXService {
+	return &FXService{repo: repo}
+}
+
+// Import parses a rates file and stores every rate it contains
+func (s *FXService) Import(r io.Reader, format, base string) (*models.FXImportResult, error) {
+	rates, err := ParseRates(r, format, base)
+	if err != nil {
+		return nil, err
+	}
+	if len(rates) == 0 {
+		return nil, &ValidationError{Message: "FX file contains no rates"}
+	}
+
+	if err := s.repo.Upsert(rates); err != nil {
+		return nil, err
+	}
+
+	result := &models.FXImportResult{Imported: len(rates), From: rates[0].Date, To: rates[0].Date}
+	for _, rate := range rates {
+		if rate.Date < result.From {
+			result.From = rate.Date
+		}
+		if rate.Date > result.To {
+			result.To = rate.Date
+		}
+	}
+	return result, nil
+}
+
+// Convert converts amount from one currency to another using the rate effective on date
+// (the nearest earlier rate when none was published that day). The result is rounded
+// with banker's rounding to the target currency's minor units.
+func (s *FXService) Convert(amount money.Decimal, from, to, date string) (money.Decimal, error) {
+	return s.newConverter().convert(amount, from, to, date)
+}
+
+// ConvertExpenses sets ConvertedAmount and ConvertedCurrency on each expense.
+// Expenses without an available rate are left unconverted.
+func (s *FXService) ConvertExpenses(expenses []models.Expense, to string) error {
+	target, ok := money.LookupCurrency(to)
+	if !ok {
+		return &ValidationError{Message: "unsupported currency: " + to}
+	}
+
+	converter := s.newConverter()
+	for i := range expenses {
+		amount, err := money.Parse(expenses[i].Amount)
+		if err != nil {
+			return err
+		}
+
+		converted, err := converter.convert(amount, expenses[i].Currency, target.Code, expenses[i].Date)
+		if err != nil {
+			if _, missing := err.(*NotFoundError); missing {
+				continue
+			}
+			return err
+		}
+
+		value := converted.String()
+		expenses[i].ConvertedAmount = &value
+		expenses[i].ConvertedCurrency = target.Code
+	}
+
+	return nil
+}
+
+// converter performs conversions while caching rate lookups, so converting
+// many expenses on the same dates does not repeat queries
+type converter struct {
+	repo  *repository.FXRateRepository
+	bases []string
+	rates map[string]*money.Decimal // nil entry means no rate available
+}
+
+func (s *FXService) newConverter() *converter {
+	return &converter{repo: s.repo, rates: make(map[string]*money.Decimal)}
+}
+
+// convert converts amount between currencies on the given date
+func (c *converter) convert(amount money.Decimal, from, to, date string) (money.Decimal, error) {
+	target, ok := money.LookupCurrency(to)
+	if !ok {
+		return money.Decimal{}, &ValidationError{Message: "unsupported currency: " + to}
+	}
+	from = money.NormalizeCurrencyCode(from)
+	if from == target.Code {
+		return amount.Round(target.MinorUnits, money.RoundHalfEven), nil
+	}
+
+	fromRate, toRate, err := c.crossRates(from, target.Code, date)
+	if err != nil {
+		return money.Decimal{}, err
+	}
+
+	// Rates are units per base, so amount in base = amount / fromRate
+	return amount.MulRatio(toRate, fromRate, target.MinorUnits, money.RoundHalfEven)
+}
+
+// crossRates finds the from and to rates against a common base currency
+func (c *converter) crossRates(from, to, date string) (money.Decimal, money.Decimal, error) {
+	if c.bases == nil {
+		bases, err := c.repo.GetBases()
+		if err != nil {
+			return money.Decimal{}, money.Decimal{}, err
+		}
+		c.bases = bases
+	}
+
+	for _, base := range c.bases {
+		fromRate, err := c.rateAgainst(base, from, date)
+		if err != nil {
+			return money.Decimal{}, money.Decimal{}, err
+		}
+		toRate, err := c.rateAgainst(base, to, date)
+		if err != nil {
+			return money.Decimal{}, money.Decimal{}, err
+		}
+		if fromRate != nil && toRate != nil {
+			return *fromRate, *toRate, nil
+		}
+	}
+
+	return money.Decimal{}, money.Decimal{}, &NotFoundError{
+		Message: "no exchange rate from " + from + " to " + to + " on or before " + date,
+	}
+}
+
+// rateAgainst returns units of currency per unit of base, or nil when no rate
+// is available; the base itself is always 1
+func (c *converter) rateAgainst(base, currency, date string) (*money.Decimal, error) {
+	if currency == base {
+		one := money.NewFromInt(1)
+		return &one, nil
+	}
+
+	key := base + "|" + currency + "|" + date
+	if rate, cached := c.rates[key]; cached {
+		return rate, nil
+	}
+
+	stored, err := c.repo.GetEffective(base, currency, date)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.rates[key] = nil
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rate, err := money.Parse(stored.Rate)
+	if err != nil {
+		return nil, err
+	}
+	c.rates[key] = &rate
+	return &rate, nil
+}
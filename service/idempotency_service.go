@@ -0,0 +1,91 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fenmo-ai-assignment/models"
+	"fenmo-ai-assignment/repository"
+	"time"
+)
+
+// MaxIdempotencyKeyLength is the longest Idempotency-Key header value accepted
+const MaxIdempotencyKeyLength = 255
+
+// IdempotencyService records and replays responses for requests carrying an Idempotency-Key
+type IdempotencyService struct {
+	repo *repository.IdempotencyRepository
+	ttl  time.Duration
+}
+
+// NewIdempotencyService creates a new idempotency service.
+// Keys older than ttl are forgotten and may be reused.
+func NewIdempotencyService(repo *repository.IdempotencyRepository, ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{repo: repo, ttl: ttl}
+}
+
+// HashRequest returns a stable fingerprint of a request body
+func HashRequest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// Begin claims a key for a new request. It returns nil when the caller should
+// process the request and later call Complete or Abort, or the stored record when
+// the request is a retry whose response should be replayed.
+func (s *IdempotencyService) Begin(key, requestHash string) (*models.IdempotencyRecord, error) {
+	if len(key) > MaxIdempotencyKeyLength {
+		return nil, &ValidationError{Message: "Idempotency-Key must be at most 255 characters"}
+	}
+
+	// Forget expired keys so they can be reused and the table stays small
+	now := time.Now()
+	if _, err := s.repo.DeleteCreatedBefore(now.Add(-s.ttl)); err != nil {
+		return nil, err
+	}
+
+	reserved, err := s.repo.Reserve(key, requestHash, now)
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	record, err := s.repo.Get(key)
+	if errors.Is(err, repository.ErrNotFound) {
+		// The record expired between Reserve and Get; treat it as a conflict and let the client retry
+		return nil, &ConflictError{Message: "a request with this Idempotency-Key is already in progress"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if record.RequestHash != requestHash {
+		return nil, &IdempotencyMismatchError{Message: "Idempotency-Key was already used with a different request body"}
+	}
+	if !record.Completed() {
+		return nil, &ConflictError{Message: "a request with this Idempotency-Key is already in progress"}
+	}
+
+	return record, nil
+}
+
+// Complete stores the response for a key claimed with Begin
+func (s *IdempotencyService) Complete(key string, statusCode int, responseBody []byte) error {
+	return s.repo.Complete(key, statusCode, string(responseBody))
+}
+
+// Abort releases a key claimed with Begin so the request can be retried
+func (s *IdempotencyService) Abort(key string) error {
+	return s.repo.Delete(key)
+}
+
+// IdempotencyMismatchError is returned when an Idempotency-Key is reused with a different request body
+type IdempotencyMismatchError struct {
+	Message string
+}
+
+func (e *IdempotencyMismatchError) Error() string {
+	return e.Message
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fenmo-ai-assignment/models"
+)
+
+const (
+	// DefaultPageSize is used when a paginated request gives no limit
+	DefaultPageSize = 50
+	// MaxPageSize is the largest page a client may request
+	MaxPageSize = 500
+)
+
+// EncodeCursor turns a cursor into an opaque, URL-safe token
+func EncodeCursor(cursor models.ExpenseCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a token produced by EncodeCursor
+func DecodeCursor(token string) (*models.ExpenseCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, &ValidationError{Message: "invalid cursor"}
+	}
+
+	var cursor models.ExpenseCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Sort == "" {
+		return nil, &ValidationError{Message: "invalid cursor"}
+	}
+	return &cursor, nil
+}
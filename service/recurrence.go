@@ -0,0 +1,189 @@
+package service
+
+import (
+	"fenmo-ai-assignment/models"
+	"fenmo-ai-assignment/utils"
+	"strings"
+	"time"
+)
+
+// MaxRecurrenceInterval bounds the interval of a recurring schedule
+const MaxRecurrenceInterval = 1000
+
+// schedule computes the occurrence dates of a recurring expense
+type schedule struct {
+	frequency string
+	interval  int
+	monthDay  int // Monthly only; LastDayOfMonth for the last day
+	weekday   time.Weekday
+	start     time.Time
+	end       time.Time // Zero for an open-ended schedule
+}
+
+// newSchedule builds the schedule of a validated recurring expense
+func newSchedule(recurring *models.RecurringExpense) (schedule, error) {
+	s := schedule{
+		frequency: recurring.Frequency,
+		interval:  recurring.Interval,
+		monthDay:  recurring.MonthDay,
+	}
+
+	var err error
+	if s.start, err = time.Parse(dateLayout, recurring.StartDate); err != nil {
+		return s, err
+	}
+	if recurring.EndDate != "" {
+		if s.end, err = time.Parse(dateLayout, recurring.EndDate); err != nil {
+			return s, err
+		}
+	}
+	if recurring.Frequency == models.FrequencyWeekly {
+		if s.weekday, err = utils.ParseWeekday(recurring.Weekday); err != nil {
+			return s, err
+		}
+	}
+	if s.interval < 1 {
+		s.interval = 1
+	}
+	return s, nil
+}
+
+// firstOnOrAfter returns the first occurrence on or after date (YYYY-MM-DD), or ""
+// when the schedule ends before then
+func (s schedule) firstOnOrAfter(date string) string {
+	d, err := time.Parse(dateLayout, date)
+	if err != nil || d.Before(s.start) {
+		d = s.start
+	}
+
+	var occurrence time.Time
+	switch s.frequency {
+	case models.FrequencyDaily:
+		occurrence = s.start.AddDate(0, 0, ceilDiv(daysBetween(s.start, d), s.interval)*s.interval)
+	case models.FrequencyWeekly:
+		first := s.start.AddDate(0, 0, (int(s.weekday)-int(s.start.Weekday())+7)%7)
+		occurrence = first
+		if d.After(first) {
+			step := 7 * s.interval
+			occurrence = first.AddDate(0, 0, ceilDiv(daysBetween(first, d), step)*step)
+		}
+	case models.FrequencyMonthly:
+		// Start one period early: clamping can put an occurrence before its month's start day
+		months := (d.Year()-s.start.Year())*12 + int(d.Month()-s.start.Month())
+		for k := max(months/s.interval-1, 0); ; k++ {
+			if occurrence = s.monthly(k); !occurrence.Before(d) {
+				break
+			}
+		}
+	case models.FrequencyYearly:
+		for k := max((d.Year()-s.start.Year())/s.interval-1, 0); ; k++ {
+			if occurrence = s.yearly(k); !occurrence.Before(d) {
+				break
+			}
+		}
+	default:
+		return ""
+	}
+
+	if !s.end.IsZero() && occurrence.After(s.end) {
+		return ""
+	}
+	return occurrence.Format(dateLayout)
+}
+
+// after returns the first occurrence strictly after date, or "" when there is none
+func (s schedule) after(date string) string {
+	d, err := time.Parse(dateLayout, date)
+	if err != nil {
+		return ""
+	}
+	return s.firstOnOrAfter(d.AddDate(0, 0, 1).Format(dateLayout))
+}
+
+// monthly returns the k-th monthly candidate, which may fall before the start date
+func (s schedule) monthly(k int) time.Time {
+	first := time.Date(s.start.Year(), s.start.Month()+time.Month(k*s.interval), 1, 0, 0, 0, 0, time.UTC)
+	last := daysIn(first.Year(), first.Month())
+	day := s.monthDay
+	if day == models.LastDayOfMonth || day > last {
+		day = last
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, time.UTC)
+}
+
+// yearly returns the k-th yearly occurrence; 29 February falls on the 28th in common years
+func (s schedule) yearly(k int) time.Time {
+	year := s.start.Year() + k*s.interval
+	day := min(s.start.Day(), daysIn(year, s.start.Month()))
+	return time.Date(year, s.start.Month(), day, 0, 0, 0, 0, time.UTC)
+}
+
+// validateSchedule checks the schedule of a recurring expense and fills in its defaults
+func validateSchedule(recurring *models.RecurringExpense) error {
+	recurring.Frequency = strings.ToLower(strings.TrimSpace(recurring.Frequency))
+	switch recurring.Frequency {
+	case models.FrequencyDaily, models.FrequencyWeekly, models.FrequencyMonthly, models.FrequencyYearly:
+	default:
+		return &ValidationError{Message: "frequency must be one of daily, weekly, monthly, yearly"}
+	}
+
+	if recurring.Interval == 0 {
+		recurring.Interval = 1
+	}
+	if recurring.Interval < 1 || recurring.Interval > MaxRecurrenceInterval {
+		return &ValidationError{Message: "interval must be between 1 and 1000"}
+	}
+
+	start, err := time.Parse(dateLayout, recurring.StartDate)
+	if err != nil {
+		return &ValidationError{Message: "start_date must be in YYYY-MM-DD format"}
+	}
+	if recurring.EndDate != "" {
+		if err := utils.ValidateDate(recurring.EndDate); err != nil {
+			return &ValidationError{Message: "end_date: " + err.Error()}
+		}
+		if recurring.EndDate < recurring.StartDate {
+			return &ValidationError{Message: "end_date must not be before start_date"}
+		}
+	}
+
+	if recurring.Frequency == models.FrequencyMonthly {
+		if recurring.MonthDay == 0 {
+			recurring.MonthDay = start.Day()
+		}
+		if recurring.MonthDay != models.LastDayOfMonth && (recurring.MonthDay < 1 || recurring.MonthDay > 31) {
+			return &ValidationError{Message: "month_day must be between 1 and 31, or -1 for the last day"}
+		}
+	} else if recurring.MonthDay != 0 {
+		return &ValidationError{Message: "month_day only applies to monthly schedules"}
+	}
+
+	if recurring.Frequency == models.FrequencyWeekly {
+		weekday := start.Weekday()
+		if strings.TrimSpace(recurring.Weekday) != "" {
+			if weekday, err = utils.ParseWeekday(recurring.Weekday); err != nil {
+				return &ValidationError{Message: "weekday: " + err.Error()}
+			}
+		}
+		recurring.Weekday = strings.ToLower(weekday.String())
+	} else if recurring.Weekday != "" {
+		return &ValidationError{Message: "weekday only applies to weekly schedules"}
+	}
+
+	return nil
+}
+
+// daysBetween returns the whole days from a to b, both UTC midnights
+func daysBetween(a, b time.Time) int {
+	return int(b.Sub(a).Hours() / 24)
+}
+
+// daysIn returns the number of days in a month
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// ceilDiv divides non-negative a by positive b, rounding up
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
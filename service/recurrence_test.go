@@ -0,0 +1,123 @@
+package service
+
+import (
+	"fenmo-ai-assignment/models"
+	"reflect"
+	"testing"
+)
+
+func TestSchedule_Occurrences(t *testing.T) {
+	tests := []struct {
+		name      string
+		recurring models.RecurringExpense
+		from      string
+		want      []string
+	}{
+		{
+			"daily every 3 days",
+			models.RecurringExpense{Frequency: "daily", Interval: 3, StartDate: "2024-01-30"},
+			"2024-01-31",
+			[]string{"2024-02-02", "2024-02-05", "2024-02-08"},
+		},
+		{
+			"weekly on a later weekday than the start",
+			models.RecurringExpense{Frequency: "weekly", Weekday: "friday", StartDate: "2024-01-01"},
+			"",
+			[]string{"2024-01-05", "2024-01-12", "2024-01-19"},
+		},
+		{
+			"fortnightly",
+			models.RecurringExpense{Frequency: "weekly", Interval: 2, Weekday: "monday", StartDate: "2024-01-01"},
+			"2024-01-02",
+			[]string{"2024-01-15", "2024-01-29", "2024-02-12"},
+		},
+		{
+			"monthly on the 31st clamps to short months",
+			models.RecurringExpense{Frequency: "monthly", MonthDay: 31, StartDate: "2024-01-31"},
+			"",
+			[]string{"2024-01-31", "2024-02-29", "2024-03-31"},
+		},
+		{
+			"monthly on the last day",
+			models.RecurringExpense{Frequency: "monthly", MonthDay: -1, StartDate: "2023-01-15"},
+			"",
+			[]string{"2023-01-31", "2023-02-28", "2023-03-31"},
+		},
+		{
+			"monthly day before start day begins next month",
+			models.RecurringExpense{Frequency: "monthly", MonthDay: 5, StartDate: "2024-01-20"},
+			"",
+			[]string{"2024-02-05", "2024-03-05", "2024-04-05"},
+		},
+		{
+			"quarterly from a later date",
+			models.RecurringExpense{Frequency: "monthly", Interval: 3, MonthDay: 1, StartDate: "2024-01-01"},
+			"2024-05-10",
+			[]string{"2024-07-01", "2024-10-01", "2025-01-01"},
+		},
+		{
+			"yearly on 29 February",
+			models.RecurringExpense{Frequency: "yearly", StartDate: "2024-02-29"},
+			"",
+			[]string{"2024-02-29", "2025-02-28", "2026-02-28"},
+		},
+		{
+			"ends on end date",
+			models.RecurringExpense{Frequency: "monthly", MonthDay: 1, StartDate: "2024-01-01", EndDate: "2024-02-15"},
+			"",
+			[]string{"2024-01-01", "2024-02-01"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sched, err := newSchedule(&tt.recurring)
+			if err != nil {
+				t.Fatalf("newSchedule() error = %v", err)
+			}
+
+			var got []string
+			for next := sched.firstOnOrAfter(tt.from); next != "" && len(got) < 3; next = sched.after(next) {
+				got = append(got, next)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("occurrences = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSchedule(t *testing.T) {
+	tests := []struct {
+		name      string
+		recurring models.RecurringExpense
+		wantErr   bool
+	}{
+		{"monthly defaults", models.RecurringExpense{Frequency: "Monthly", StartDate: "2024-01-15"}, false},
+		{"unknown frequency", models.RecurringExpense{Frequency: "hourly", StartDate: "2024-01-15"}, true},
+		{"negative interval", models.RecurringExpense{Frequency: "daily", Interval: -1, StartDate: "2024-01-15"}, true},
+		{"month day out of range", models.RecurringExpense{Frequency: "monthly", MonthDay: 32, StartDate: "2024-01-15"}, true},
+		{"month day on weekly", models.RecurringExpense{Frequency: "weekly", MonthDay: 3, StartDate: "2024-01-15"}, true},
+		{"weekday on monthly", models.RecurringExpense{Frequency: "monthly", Weekday: "monday", StartDate: "2024-01-15"}, true},
+		{"unknown weekday", models.RecurringExpense{Frequency: "weekly", Weekday: "noday", StartDate: "2024-01-15"}, true},
+		{"end before start", models.RecurringExpense{Frequency: "daily", StartDate: "2024-01-15", EndDate: "2024-01-14"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSchedule(&tt.recurring)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateSchedule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Defaults come from the start date
+	monthly := models.RecurringExpense{Frequency: "Monthly", StartDate: "2024-01-15"}
+	weekly := models.RecurringExpense{Frequency: "weekly", StartDate: "2024-01-15"}
+	_ = validateSchedule(&monthly)
+	_ = validateSchedule(&weekly)
+	if monthly.Frequency != "monthly" || monthly.Interval != 1 || monthly.MonthDay != 15 || weekly.Weekday != "monday" {
+		t.Errorf("defaults = %+v, %+v", monthly, weekly)
+	}
+}
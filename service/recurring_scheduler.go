@@ -0,0 +1,73 @@
+package service
+
+import (
+	"log"
+	"time"
+)
+
+// RecurringScheduler periodically generates due recurring expenses
+type RecurringScheduler struct {
+	service  *RecurringService
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewRecurringScheduler creates a new recurring expense scheduler
+func NewRecurringScheduler(service *RecurringService, interval time.Duration) *RecurringScheduler {
+	return &RecurringScheduler{
+		service:  service,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start runs the scheduler loop in a background goroutine. The first run happens
+// immediately, catching up on occurrences missed while the application was down.
+// It does nothing when interval is not positive.
+func (r *RecurringScheduler) Start() {
+	if r.interval <= 0 {
+		close(r.done)
+		return
+	}
+
+	go func() {
+		defer close(r.done)
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			r.runOnce()
+
+			select {
+			case <-ticker.C:
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop signals the scheduler loop to exit and waits for it to finish.
+// It must only be called after Start.
+func (r *RecurringScheduler) Stop() {
+	select {
+	case <-r.stop:
+	default:
+		close(r.stop)
+	}
+	<-r.done
+}
+
+// runOnce generates due occurrences and logs the outcome
+func (r *RecurringScheduler) runOnce() {
+	created, err := r.service.Materialise(time.Now())
+	if err != nil {
+		log.Printf("Recurring expense generation failed: %v", err)
+	}
+	if created > 0 {
+		log.Printf("Recurring expenses created %d expense(s)", created)
+	}
+}
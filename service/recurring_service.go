@@ -0,0 +1,178 @@
+package service
+
+import (
+	"fenmo-ai-assignment/models"
+	"fenmo-ai-assignment/repository"
+	"fenmo-ai-assignment/utils"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RecurringService manages recurring expense templates and generates their occurrences
+type RecurringService struct {
+	repo     *repository.RecurringRepository
+	expenses *ExpenseService
+}
+
+// NewRecurringService creates a new recurring expense service. Occurrences are created
+// through the expense service, so they are validated and budget-checked like any expense.
+func NewRecurringService(repo *repository.RecurringRepository, expenses *ExpenseService) *RecurringService {
+	return &RecurringService{repo: repo, expenses: expenses}
+}
+
+// CreateRecurring creates a new recurring expense with validation
+func (s *RecurringService) CreateRecurring(req models.RecurringExpenseRequest) (*models.RecurringExpense, error) {
+	now := time.Now()
+	recurring := &models.RecurringExpense{
+		ID:        utils.GenerateUUID(),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.applyRequest(recurring, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(recurring); err != nil {
+		return nil, err
+	}
+	return recurring, nil
+}
+
+// GetRecurring retrieves a single recurring expense by ID
+func (s *RecurringService) GetRecurring(id string) (*models.RecurringExpense, error) {
+	recurring, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	return recurring, nil
+}
+
+// GetRecurringExpenses retrieves every recurring expense
+func (s *RecurringService) GetRecurringExpenses() ([]models.RecurringExpense, error) {
+	return s.repo.List()
+}
+
+// UpdateRecurring replaces the template and schedule of a recurring expense (PUT).
+// The new schedule continues after the last generated occurrence.
+func (s *RecurringService) UpdateRecurring(id string, req models.RecurringExpenseRequest) (*models.RecurringExpense, error) {
+	recurring, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	if err := s.applyRequest(recurring, req); err != nil {
+		return nil, err
+	}
+	recurring.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(recurring); err != nil {
+		return nil, translateRepoError(err)
+	}
+	return recurring, nil
+}
+
+// DeleteRecurring removes a recurring expense; expenses already generated are kept
+func (s *RecurringService) DeleteRecurring(id string) error {
+	return translateRepoError(s.repo.Delete(id))
+}
+
+// Materialise creates an expense for every occurrence due on or before today,
+// including occurrences missed while the application was down. Occurrences that were
+// already generated are skipped, so running it repeatedly never creates duplicates.
+// It returns the number of expenses created.
+func (s *RecurringService) Materialise(today time.Time) (int, error) {
+	date := today.Format(dateLayout)
+	due, err := s.repo.ListDue(date)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	var firstErr error
+	for i := range due {
+		n, err := s.materialiseOne(&due[i], date)
+		created += n
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("recurring expense %s: %w", due[i].ID, err)
+		}
+	}
+	return created, firstErr
+}
+
+// materialiseOne generates the due occurrences of one template and advances its schedule.
+// On failure the schedule stops at the failed occurrence so the next run retries it.
+func (s *RecurringService) materialiseOne(recurring *models.RecurringExpense, today string) (int, error) {
+	sched, err := newSchedule(recurring)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	next, last := recurring.NextDate, recurring.LastGenerated
+	for next != "" && next <= today {
+		_, err := s.expenses.CreateExpense(models.CreateExpenseRequest{
+			Amount:      recurring.Amount,
+			Currency:    recurring.Currency,
+			Category:    recurring.Category,
+			Description: recurring.Description,
+			Date:        next,
+			RecurringID: recurring.ID,
+		})
+		if err != nil {
+			// A conflict means this occurrence was generated before; anything else is retried
+			if _, ok := err.(*ConflictError); !ok {
+				if advanceErr := s.repo.Advance(recurring.ID, next, last); advanceErr != nil {
+					return created, advanceErr
+				}
+				return created, err
+			}
+		} else {
+			created++
+		}
+		last = next
+		next = sched.after(next)
+	}
+
+	return created, s.repo.Advance(recurring.ID, next, last)
+}
+
+// applyRequest validates a recurring expense request and copies it onto the template,
+// rescheduling it from the day after its last generated occurrence
+func (s *RecurringService) applyRequest(recurring *models.RecurringExpense, req models.RecurringExpenseRequest) error {
+	// The template must make a valid expense dated on its start
+	template := &models.Expense{
+		Amount:      req.Amount,
+		Currency:    s.expenses.currencyOrDefault(strings.TrimSpace(req.Currency)),
+		Category:    strings.TrimSpace(req.Category),
+		Description: strings.TrimSpace(req.Description),
+		Date:        strings.TrimSpace(req.StartDate),
+	}
+	if err := validateExpense(template); err != nil {
+		return err
+	}
+
+	recurring.Amount = template.Amount
+	recurring.Currency = template.Currency
+	recurring.Category = template.Category
+	recurring.Description = template.Description
+	recurring.Frequency = req.Frequency
+	recurring.Interval = req.Interval
+	recurring.MonthDay = req.MonthDay
+	recurring.Weekday = strings.TrimSpace(req.Weekday)
+	recurring.StartDate = template.Date
+	recurring.EndDate = strings.TrimSpace(req.EndDate)
+	if err := validateSchedule(recurring); err != nil {
+		return err
+	}
+
+	sched, err := newSchedule(recurring)
+	if err != nil {
+		return err
+	}
+	if recurring.LastGenerated == "" {
+		recurring.NextDate = sched.firstOnOrAfter(recurring.StartDate)
+	} else {
+		recurring.NextDate = sched.after(recurring.LastGenerated)
+	}
+	return nil
+}
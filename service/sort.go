@@ -0,0 +1,129 @@
+package service
+
+import (
+	"fenmo-ai-assignment/models"
+	"strings"
+)
+
+// AllowedSortKeys lists the fields clients may sort expenses by.
+// Prefix a key with "-" for descending order. relevance applies only to searches (q).
+var AllowedSortKeys = []string{"date", "amount", "created_at", "category", "relevance"}
+
+// legacySortAliases keeps older sort values working
+var legacySortAliases = map[string]string{
+	"date_desc": "-date",
+}
+
+// defaultPageSort orders paginated results newest first
+var defaultPageSort = []models.SortKey{{Field: "date", Desc: true}}
+
+// InvalidSortError is returned for a sort parameter using keys outside the whitelist
+type InvalidSortError struct {
+	Message string
+	Allowed []string
+}
+
+func (e *InvalidSortError) Error() string {
+	return e.Message
+}
+
+// ParseSort parses a comma-separated sort parameter such as "category,-amount"
+func ParseSort(sort string) ([]models.SortKey, error) {
+	sort = strings.TrimSpace(sort)
+	if alias, ok := legacySortAliases[sort]; ok {
+		sort = alias
+	}
+	if sort == "" {
+		return nil, nil
+	}
+
+	var keys []models.SortKey
+	seen := make(map[string]bool)
+	for _, term := range strings.Split(sort, ",") {
+		term = strings.TrimSpace(term)
+		key := models.SortKey{Field: strings.TrimPrefix(term, "-"), Desc: strings.HasPrefix(term, "-")}
+
+		if !isAllowedSortKey(key.Field) {
+			return nil, &InvalidSortError{
+				Message: "invalid sort key \"" + term + "\"; allowed keys are " + strings.Join(AllowedSortKeys, ", ") + " (prefix with - for descending)",
+				Allowed: AllowedSortKeys,
+			}
+		}
+		if seen[key.Field] {
+			return nil, &InvalidSortError{
+				Message: "sort key \"" + key.Field + "\" appears more than once",
+				Allowed: AllowedSortKeys,
+			}
+		}
+		seen[key.Field] = true
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// FormatSort renders sort keys back into the sort parameter syntax
+func FormatSort(keys []models.SortKey) string {
+	terms := make([]string, len(keys))
+	for i, key := range keys {
+		terms[i] = key.Field
+		if key.Desc {
+			terms[i] = "-" + key.Field
+		}
+	}
+	return strings.Join(terms, ",")
+}
+
+// relevanceSort orders search results best match first
+var relevanceSort = []models.SortKey{{Field: "relevance", Desc: true}}
+
+// rankSearch adjusts sort keys for the description query of a filter. Without a
+// query relevance cannot be sorted by. With one, results are ranked best match first
+// unless another order was asked for; when the database has no full-text index,
+// relevance keys are dropped because substring matches are not ranked.
+func rankSearch(keys []models.SortKey, filter models.ExpenseFilter, fullText bool) ([]models.SortKey, error) {
+	var ranked []models.SortKey
+	for _, key := range keys {
+		if key.Field != "relevance" {
+			ranked = append(ranked, key)
+			continue
+		}
+		if filter.Query == "" {
+			return nil, &ValidationError{Message: "sorting by relevance requires a search query (q)"}
+		}
+		if fullText {
+			ranked = append(ranked, key)
+		}
+	}
+
+	if len(keys) == 0 && filter.Query != "" && fullText {
+		return relevanceSort, nil
+	}
+	return ranked, nil
+}
+
+// withTiebreakers appends created_at and id so every row has a unique position,
+// which keeps results stable across requests and pages
+func withTiebreakers(keys []models.SortKey) []models.SortKey {
+	resolved := append([]models.SortKey{}, keys...)
+	hasCreatedAt := false
+	for _, key := range keys {
+		if key.Field == "created_at" {
+			hasCreatedAt = true
+		}
+	}
+	if !hasCreatedAt {
+		resolved = append(resolved, models.SortKey{Field: "created_at", Desc: true})
+	}
+	return append(resolved, models.SortKey{Field: "id", Desc: true})
+}
+
+// isAllowedSortKey reports whether field is in the whitelist
+func isAllowedSortKey(field string) bool {
+	for _, allowed := range AllowedSortKeys {
+		if field == allowed {
+			return true
+		}
+	}
+	return false
+}
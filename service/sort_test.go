@@ -0,0 +1,57 @@
+package service
+
+import (
+	"fenmo-ai-assignment/models"
+	"reflect"
+	"testing"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		name    string
+		sort    string
+		want    []models.SortKey
+		wantErr bool
+	}{
+		{"empty", "", nil, false},
+		{"ascending", "date", []models.SortKey{{Field: "date"}}, false},
+		{"descending", "-amount", []models.SortKey{{Field: "amount", Desc: true}}, false},
+		{"multi-key", "category,-amount", []models.SortKey{{Field: "category"}, {Field: "amount", Desc: true}}, false},
+		{"spaces around keys", " category , created_at ", []models.SortKey{{Field: "category"}, {Field: "created_at"}}, false},
+		{"legacy alias", "date_desc", []models.SortKey{{Field: "date", Desc: true}}, false},
+		{"unknown key", "description", nil, true},
+		{"internal key not exposed", "id", nil, true},
+		{"duplicate key", "date,-date", nil, true},
+		{"empty term", "date,", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSort(tt.sort)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSort(%q) error = %v, wantErr %v", tt.sort, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if sortErr, ok := err.(*InvalidSortError); !ok || len(sortErr.Allowed) == 0 {
+					t.Errorf("ParseSort(%q) error = %#v, want *InvalidSortError with allowed keys", tt.sort, err)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSort(%q) = %+v, want %+v", tt.sort, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatSortWithTiebreakers(t *testing.T) {
+	keys, _ := ParseSort("category,-amount")
+	if got := FormatSort(withTiebreakers(keys)); got != "category,-amount,-created_at,-id" {
+		t.Errorf("FormatSort() = %q", got)
+	}
+
+	keys, _ = ParseSort("created_at")
+	if got := FormatSort(withTiebreakers(keys)); got != "created_at,-id" {
+		t.Errorf("FormatSort() = %q", got)
+	}
+}
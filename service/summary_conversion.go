@@ -0,0 +1,173 @@
+package service
+
+import (
+	"fenmo-ai-assignment/models"
+	"fenmo-ai-assignment/money"
+	"fmt"
+	"time"
+)
+
+// convertedSums converts amounts into one currency at the rate of their date and sums
+// them per group key. The empty key is the overall total.
+type convertedSums struct {
+	converter *converter
+	target    string
+	sums      map[string]money.Decimal
+	missing   map[string]int
+}
+
+// newConvertedSums prepares sums in currency to, or returns nil when no conversion was
+// requested or no FX service is configured
+func (s *SummaryService) newConvertedSums(to string) (*convertedSums, error) {
+	if to == "" || s.fx == nil {
+		return nil, nil
+	}
+	target, ok := money.LookupCurrency(to)
+	if !ok {
+		return nil, &ValidationError{Message: "unsupported currency: " + to}
+	}
+	return &convertedSums{
+		converter: s.fx.newConverter(),
+		target:    target.Code,
+		sums:      make(map[string]money.Decimal),
+		missing:   make(map[string]int),
+	}, nil
+}
+
+// add converts an amount and adds it to the overall total and to every given key. An
+// amount without a rate on or before its date is counted as missing instead.
+func (c *convertedSums) add(amount, currency, date string, keys ...string) error {
+	value, err := money.Parse(amount)
+	if err != nil {
+		return err
+	}
+	keys = append(keys, "")
+
+	converted, err := c.converter.convert(value, currency, c.target, date)
+	if _, noRate := err.(*NotFoundError); noRate {
+		for _, key := range keys {
+			c.missing[key]++
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		c.sums[key] = c.sums[key].Add(converted)
+	}
+	return nil
+}
+
+// total renders the converted total of a key, or nil when any of its amounts could
+// not be converted
+func (c *convertedSums) total(key string) *string {
+	if c.missing[key] > 0 {
+		return nil
+	}
+	total := formatInCurrency(c.sums[key], c.target)
+	return &total
+}
+
+// addCategoryConversions fills in the converted totals of a category summary. Spend is
+// rolled up along the parents the summary lists.
+func (s *SummaryService) addCategoryConversions(summary *models.CategorySummary, filter models.ExpenseFilter, to string) error {
+	sums, err := s.newConvertedSums(to)
+	if sums == nil || err != nil {
+		return err
+	}
+
+	parentOf := make(map[string]string, len(summary.Categories))
+	for _, category := range summary.Categories {
+		parentOf[category.Category] = category.Parent
+	}
+
+	err = s.repo.ForEachAmount(filter, func(entry models.AmountEntry) error {
+		keys := []string{entry.Category}
+		// The step bound guards against a corrupt tree containing a cycle
+		for name, steps := entry.Category, 0; name != "" && steps <= len(parentOf); name, steps = parentOf[name], steps+1 {
+			keys = append(keys, "rollup:"+name)
+		}
+		return sums.add(entry.Amount, entry.Currency, entry.Date, keys...)
+	})
+	if err != nil {
+		return err
+	}
+
+	summary.ConvertedCurrency = sums.target
+	summary.ConvertedTotal = sums.total("")
+	summary.Unconverted = sums.missing[""]
+	for i := range summary.Categories {
+		category := &summary.Categories[i]
+		if category.Count > 0 {
+			category.ConvertedTotal = sums.total(category.Category)
+		}
+		if category.Rollup != nil {
+			category.Rollup.ConvertedTotal = sums.total("rollup:" + category.Category)
+		}
+	}
+	return nil
+}
+
+// addTagConversions fills in the converted totals of a tag summary
+func (s *SummaryService) addTagConversions(summary *models.TagSummary, filter models.ExpenseFilter, to string) error {
+	sums, err := s.newConvertedSums(to)
+	if sums == nil || err != nil {
+		return err
+	}
+
+	// Aggregates carry no tags, so the matching expenses are loaded whole
+	expenses, err := s.repo.List(filter, nil)
+	if err != nil {
+		return err
+	}
+	for _, expense := range expenses {
+		if err := sums.add(expense.Amount, expense.Currency, expense.Date, expense.Tags...); err != nil {
+			return err
+		}
+	}
+
+	summary.ConvertedCurrency = sums.target
+	summary.ConvertedTotal = sums.total("")
+	summary.Unconverted = sums.missing[""]
+	for i := range summary.Tags {
+		summary.Tags[i].ConvertedTotal = sums.total(summary.Tags[i].Tag)
+	}
+	return nil
+}
+
+// addTimeSeriesConversions fills in the converted totals of every bucket of a series
+func (s *SummaryService) addTimeSeriesConversions(series *models.TimeSeries, filter models.ExpenseFilter, b bucketer, index map[string]int, to string) error {
+	sums, err := s.newConvertedSums(to)
+	if sums == nil || err != nil {
+		return err
+	}
+
+	err = s.repo.ForEachAmount(filter, func(entry models.AmountEntry) error {
+		date, err := time.Parse(dateLayout, entry.Date)
+		if err != nil {
+			return fmt.Errorf("stored date %q: %w", entry.Date, err)
+		}
+		bucket := b.start(date).Format(dateLayout)
+		if _, ok := index[bucket]; !ok {
+			return nil
+		}
+		return sums.add(entry.Amount, entry.Currency, entry.Date, bucket, entry.Category+"|"+bucket)
+	})
+	if err != nil {
+		return err
+	}
+
+	series.ConvertedCurrency = sums.target
+	series.ConvertedTotal = sums.total("")
+	series.Unconverted = sums.missing[""]
+	for i := range series.Points {
+		series.Points[i].ConvertedTotal = sums.total(series.Points[i].Start)
+	}
+	for _, category := range series.Categories {
+		for i := range category.Points {
+			category.Points[i].ConvertedTotal = sums.total(category.Category + "|" + category.Points[i].Start)
+		}
+	}
+	return nil
+}
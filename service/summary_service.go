@@ -0,0 +1,482 @@
+package service
+
+import (
+	"fenmo-ai-assignment/models"
+	"fenmo-ai-assignment/money"
+	"fenmo-ai-assignment/repository"
+	"fenmo-ai-assignment/utils"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// shareScale is the number of decimal places shares are reported with
+const shareScale = 4
+
+// Time series bucket intervals
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+	IntervalYear  = "year"
+)
+
+// MaxTimeSeriesBuckets bounds the length of a zero-filled time series
+const MaxTimeSeriesBuckets = 5000
+
+// dateLayout is the format of the expense date column
+const dateLayout = "2006-01-02"
+
+// SummaryService aggregates expenses into reports
+type SummaryService struct {
+	repo       repository.ExpenseStore
+	categories *repository.CategoryRepository
+	fx         *FXService
+	weekStart  time.Weekday
+}
+
+// NewSummaryService creates a new summary service. categories supplies the category
+// tree that category summaries are rolled up along; nil disables roll-ups. fx converts
+// totals into a requested currency; nil ignores conversion requests.
+// weekStart is the first day of week buckets when a request does not choose one.
+func NewSummaryService(repo repository.ExpenseStore, categories *repository.CategoryRepository, fx *FXService, weekStart time.Weekday) *SummaryService {
+	return &SummaryService{repo: repo, categories: categories, fx: fx, weekStart: weekStart}
+}
+
+// TimeSeriesOptions shapes a time series request
+type TimeSeriesOptions struct {
+	Interval   string // day, week, month (default) or year
+	WeekStart  string // Weekday name overriding the configured week start
+	ByCategory bool   // Add one series per category
+	ConvertTo  string // Currency to add converted totals in
+}
+
+// GetCategorySummary reports the count, exact total and share of the overall total of
+// every category among the expenses matching the filter, ordered by category. Spend in
+// subcategories is also rolled up into every ancestor category. With convertTo every
+// total is also given converted into that currency.
+func (s *SummaryService) GetCategorySummary(filter models.ExpenseFilter, convertTo string) (*models.CategorySummary, error) {
+	if err := normalizeFilter(&filter); err != nil {
+		return nil, err
+	}
+
+	groups, err := s.repo.SumByCategory(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Overall totals per currency are needed before any share can be computed
+	count, overall := sumGroups(groups)
+
+	summary := &models.CategorySummary{
+		Count:      count,
+		Total:      formatTotals(overall),
+		Categories: []models.CategoryTotal{},
+		Filters:    filter,
+	}
+
+	// Groups arrive ordered by category, then currency
+	for _, group := range groups {
+		n := len(summary.Categories)
+		if n == 0 || summary.Categories[n-1].Category != group.Category {
+			summary.Categories = append(summary.Categories, models.CategoryTotal{
+				Category: group.Category,
+				Total:    make(map[string]string),
+				Share:    make(map[string]string),
+			})
+			n++
+		}
+		category := &summary.Categories[n-1]
+		category.Count += group.Count
+		category.Total[group.Currency] = formatInCurrency(group.Total, group.Currency)
+		category.Share[group.Currency] = share(group.Total, overall[group.Currency])
+	}
+
+	if s.categories != nil {
+		if err := s.rollUp(summary, groups, overall); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.addCategoryConversions(summary, filter, convertTo); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// rollUp adds every category's spend to all of its ancestors. Ancestors without spend
+// of their own are added to the summary, and every category with spend below it gets
+// a Rollup covering itself and its subcategories.
+func (s *SummaryService) rollUp(summary *models.CategorySummary, groups []repository.AmountGroup, overall map[string]money.Decimal) error {
+	all, err := s.categories.List(true)
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]models.Category, len(all))
+	parentOf := make(map[string]string, len(all))
+	for _, c := range all {
+		byID[c.ID] = c
+	}
+	for _, c := range all {
+		if c.ParentID != nil {
+			if parent, ok := byID[*c.ParentID]; ok {
+				parentOf[c.Name] = parent.Name
+			}
+		}
+	}
+
+	type rollup struct {
+		count int
+		total map[string]money.Decimal
+	}
+	rollups := make(map[string]*rollup)
+	hasSubcategorySpend := make(map[string]bool)
+	for _, group := range groups {
+		// The step bound guards against a corrupt tree containing a cycle
+		for name, steps := group.Category, 0; name != "" && steps <= len(all); name, steps = parentOf[name], steps+1 {
+			r := rollups[name]
+			if r == nil {
+				r = &rollup{total: make(map[string]money.Decimal)}
+				rollups[name] = r
+			}
+			r.count += group.Count
+			r.total[group.Currency] = r.total[group.Currency].Add(group.Total)
+			if name != group.Category {
+				hasSubcategorySpend[name] = true
+			}
+		}
+	}
+
+	present := make(map[string]bool, len(summary.Categories))
+	for _, category := range summary.Categories {
+		present[category.Category] = true
+	}
+	for name := range hasSubcategorySpend {
+		if !present[name] {
+			summary.Categories = append(summary.Categories, models.CategoryTotal{
+				Category: name,
+				Total:    map[string]string{},
+				Share:    map[string]string{},
+			})
+		}
+	}
+	sort.Slice(summary.Categories, func(i, j int) bool {
+		return summary.Categories[i].Category < summary.Categories[j].Category
+	})
+
+	for i := range summary.Categories {
+		category := &summary.Categories[i]
+		category.Parent = parentOf[category.Category]
+		if !hasSubcategorySpend[category.Category] {
+			continue
+		}
+		r := rollups[category.Category]
+		category.Rollup = &models.CategoryRollup{
+			Count: r.count,
+			Total: make(map[string]string, len(r.total)),
+			Share: make(map[string]string, len(r.total)),
+		}
+		for code, total := range r.total {
+			category.Rollup.Total[code] = formatInCurrency(total, code)
+			category.Rollup.Share[code] = share(total, overall[code])
+		}
+	}
+	return nil
+}
+
+// GetTagSummary reports the count, exact total and share of every tag among the
+// expenses matching the filter, ordered by tag. An expense counts towards each of its
+// tags, so shares are fractions of the overall total of the matching expenses and can
+// add up to more than one, or to less when some expenses are untagged. With convertTo
+// every total is also given converted into that currency.
+func (s *SummaryService) GetTagSummary(filter models.ExpenseFilter, convertTo string) (*models.TagSummary, error) {
+	if err := normalizeFilter(&filter); err != nil {
+		return nil, err
+	}
+
+	// Every matching expense counts once towards the overall totals
+	all, err := s.repo.SumByCategory(filter)
+	if err != nil {
+		return nil, err
+	}
+	count, overall := sumGroups(all)
+
+	groups, err := s.repo.SumByTag(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.TagSummary{
+		Count:   count,
+		Total:   formatTotals(overall),
+		Tags:    []models.TagTotal{},
+		Filters: filter,
+	}
+	// Groups arrive ordered by tag, then currency
+	for _, group := range groups {
+		n := len(summary.Tags)
+		if n == 0 || summary.Tags[n-1].Tag != group.Tag {
+			summary.Tags = append(summary.Tags, models.TagTotal{
+				Tag:   group.Tag,
+				Total: make(map[string]string),
+				Share: make(map[string]string),
+			})
+			n++
+		}
+		tag := &summary.Tags[n-1]
+		tag.Count += group.Count
+		tag.Total[group.Currency] = formatInCurrency(group.Total, group.Currency)
+		tag.Share[group.Currency] = share(group.Total, overall[group.Currency])
+	}
+
+	if err := s.addTagConversions(summary, filter, convertTo); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// sumGroups adds up the expense count and the exact total per currency of groups
+func sumGroups(groups []repository.AmountGroup) (int, map[string]money.Decimal) {
+	count := 0
+	totals := make(map[string]money.Decimal)
+	for _, group := range groups {
+		totals[group.Currency] = totals[group.Currency].Add(group.Total)
+		count += group.Count
+	}
+	return count, totals
+}
+
+// formatTotals renders per-currency totals in each currency's minor units
+func formatTotals(totals map[string]money.Decimal) map[string]string {
+	formatted := make(map[string]string, len(totals))
+	for code, total := range totals {
+		formatted[code] = formatInCurrency(total, code)
+	}
+	return formatted
+}
+
+// share renders part/whole as a fraction rounded half-even to shareScale places.
+// A zero whole (every amount zero) yields a zero share.
+func share(part, whole money.Decimal) string {
+	fraction, err := part.Quo(whole, shareScale, money.RoundHalfEven)
+	if err != nil {
+		return money.Zero.StringFixed(shareScale)
+	}
+	return fraction.StringFixed(shareScale)
+}
+
+// GetTimeSeries totals the expenses matching the filter per date bucket. Every bucket
+// from the filter's from to its to date is returned, with zero totals where nothing
+// was spent; without a from or to the range starts or ends at the matching data.
+// Monday-based week buckets are ISO 8601 weeks and are labelled as such (2024-W03).
+func (s *SummaryService) GetTimeSeries(filter models.ExpenseFilter, opts TimeSeriesOptions) (*models.TimeSeries, error) {
+	if err := normalizeFilter(&filter); err != nil {
+		return nil, err
+	}
+
+	interval := strings.ToLower(strings.TrimSpace(opts.Interval))
+	if interval == "" {
+		interval = IntervalMonth
+	}
+	switch interval {
+	case IntervalDay, IntervalWeek, IntervalMonth, IntervalYear:
+	default:
+		return nil, &ValidationError{Message: "interval must be one of day, week, month, year"}
+	}
+
+	weekStart := s.weekStart
+	if opts.WeekStart != "" {
+		parsed, err := utils.ParseWeekday(opts.WeekStart)
+		if err != nil {
+			return nil, &ValidationError{Message: "week_start: " + err.Error()}
+		}
+		weekStart = parsed
+	}
+	b := bucketer{interval: interval, weekStart: weekStart}
+
+	groups, err := s.repo.SumByDate(filter, opts.ByCategory)
+	if err != nil {
+		return nil, err
+	}
+
+	series := &models.TimeSeries{
+		Interval:   interval,
+		From:       filter.From,
+		To:         filter.To,
+		Currencies: []string{},
+		Points:     []models.TimeBucket{},
+		Filters:    filter,
+	}
+	if interval == IntervalWeek {
+		series.WeekStart = strings.ToLower(weekStart.String())
+	}
+	if opts.ByCategory {
+		series.Categories = []models.CategorySeries{}
+	}
+
+	// Groups arrive ordered by date, so they bound an open-ended range
+	if len(groups) > 0 {
+		if series.From == "" {
+			series.From = groups[0].Date
+		}
+		if series.To == "" {
+			series.To = groups[len(groups)-1].Date
+		}
+	}
+	if series.From == "" || series.To == "" {
+		return series, nil
+	}
+
+	from, err := time.Parse(dateLayout, series.From)
+	if err != nil {
+		return nil, &ValidationError{Message: "from is not a valid date: " + series.From}
+	}
+	to, err := time.Parse(dateLayout, series.To)
+	if err != nil {
+		return nil, &ValidationError{Message: "to is not a valid date: " + series.To}
+	}
+
+	var starts []time.Time
+	index := make(map[string]int)
+	for start := b.start(from); !start.After(to); start = b.next(start) {
+		if len(starts) == MaxTimeSeriesBuckets {
+			return nil, &ValidationError{Message: fmt.Sprintf("time series would exceed %d buckets; narrow the range or use a longer interval", MaxTimeSeriesBuckets)}
+		}
+		index[start.Format(dateLayout)] = len(starts)
+		starts = append(starts, start)
+	}
+
+	overall := newBucketSums(len(starts))
+	perCategory := make(map[string][]bucketSums)
+	currencies := make(map[string]bool)
+	for _, group := range groups {
+		date, err := time.Parse(dateLayout, group.Date)
+		if err != nil {
+			return nil, fmt.Errorf("stored date %q: %w", group.Date, err)
+		}
+		i := index[b.start(date).Format(dateLayout)]
+
+		currencies[group.Currency] = true
+		overall[i].add(group)
+		if opts.ByCategory {
+			if perCategory[group.Category] == nil {
+				perCategory[group.Category] = newBucketSums(len(starts))
+			}
+			perCategory[group.Category][i].add(group)
+		}
+	}
+
+	for code := range currencies {
+		series.Currencies = append(series.Currencies, code)
+	}
+	sort.Strings(series.Currencies)
+
+	series.Points = b.points(starts, overall, series.Currencies)
+	for category, sums := range perCategory {
+		series.Categories = append(series.Categories, models.CategorySeries{
+			Category: category,
+			Points:   b.points(starts, sums, series.Currencies),
+		})
+	}
+	sort.Slice(series.Categories, func(i, j int) bool {
+		return series.Categories[i].Category < series.Categories[j].Category
+	})
+
+	if err := s.addTimeSeriesConversions(series, filter, b, index, opts.ConvertTo); err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// bucketSums accumulates the count and per-currency totals of one bucket
+type bucketSums struct {
+	count int
+	sums  map[string]money.Decimal
+}
+
+// newBucketSums returns n empty accumulators
+func newBucketSums(n int) []bucketSums {
+	sums := make([]bucketSums, n)
+	for i := range sums {
+		sums[i].sums = make(map[string]money.Decimal)
+	}
+	return sums
+}
+
+// add folds an amount group into the bucket
+func (b *bucketSums) add(group repository.AmountGroup) {
+	b.count += group.Count
+	b.sums[group.Currency] = b.sums[group.Currency].Add(group.Total)
+}
+
+// bucketer maps dates onto the buckets of an interval
+type bucketer struct {
+	interval  string
+	weekStart time.Weekday
+}
+
+// start returns the first day of the bucket containing date
+func (b bucketer) start(date time.Time) time.Time {
+	switch b.interval {
+	case IntervalWeek:
+		offset := (int(date.Weekday()) - int(b.weekStart) + 7) % 7
+		return date.AddDate(0, 0, -offset)
+	case IntervalMonth:
+		return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case IntervalYear:
+		return time.Date(date.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return date
+	}
+}
+
+// next returns the first day of the bucket after the one starting at start
+func (b bucketer) next(start time.Time) time.Time {
+	switch b.interval {
+	case IntervalWeek:
+		return start.AddDate(0, 0, 7)
+	case IntervalMonth:
+		return start.AddDate(0, 1, 0)
+	case IntervalYear:
+		return start.AddDate(1, 0, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// label names the bucket starting at start
+func (b bucketer) label(start time.Time) string {
+	switch b.interval {
+	case IntervalWeek:
+		if b.weekStart == time.Monday {
+			year, week := start.ISOWeek()
+			return fmt.Sprintf("%04d-W%02d", year, week)
+		}
+		return start.Format(dateLayout)
+	case IntervalMonth:
+		return start.Format("2006-01")
+	case IntervalYear:
+		return start.Format("2006")
+	default:
+		return start.Format(dateLayout)
+	}
+}
+
+// points renders accumulated buckets, listing every currency in each bucket's total
+func (b bucketer) points(starts []time.Time, sums []bucketSums, currencies []string) []models.TimeBucket {
+	points := make([]models.TimeBucket, len(starts))
+	for i, start := range starts {
+		points[i] = models.TimeBucket{
+			Label: b.label(start),
+			Start: start.Format(dateLayout),
+			End:   b.next(start).AddDate(0, 0, -1).Format(dateLayout),
+			Count: sums[i].count,
+			Total: make(map[string]string, len(currencies)),
+		}
+		for _, code := range currencies {
+			points[i].Total[code] = formatInCurrency(sums[i].sums[code], code)
+		}
+	}
+	return points
+}
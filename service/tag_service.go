@@ -0,0 +1,128 @@
+package service
+
+import (
+	"fenmo-ai-assignment/models"
+	"fenmo-ai-assignment/repository"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Limits on expense tags
+const (
+	MaxTagLength      = 50
+	MaxTagsPerExpense = 20
+)
+
+// TagService handles business logic for tags
+type TagService struct {
+	repo repository.TagStore
+}
+
+// NewTagService creates a new tag service over the tags of an expense store
+func NewTagService(repo repository.TagStore) *TagService {
+	return &TagService{repo: repo}
+}
+
+// GetTags retrieves every tag with its expense count, ordered by name
+func (s *TagService) GetTags() ([]models.Tag, error) {
+	return s.repo.ListTags()
+}
+
+// RenameTag renames a tag on every expense carrying it. Renaming onto an existing
+// tag is a conflict; merge the tags instead.
+func (s *TagService) RenameTag(id string, req models.RenameTagRequest) (*models.Tag, error) {
+	name, err := normalizeTagName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.RenameTag(id, name); err != nil {
+		return nil, translateRepoError(err)
+	}
+	return s.GetTag(id)
+}
+
+// MergeTags moves the source tags onto the target in one transaction and deletes the
+// sources
+func (s *TagService) MergeTags(req models.MergeTagsRequest) (*models.TagMerge, error) {
+	targetID := strings.TrimSpace(req.TargetID)
+	if _, err := s.repo.GetTag(targetID); err != nil {
+		return nil, translateRepoError(err)
+	}
+
+	var sourceIDs []string
+	seen := make(map[string]bool)
+	for _, id := range cleanValues(req.SourceIDs) {
+		if id == targetID {
+			return nil, &ValidationError{Message: "source_ids must not include the target"}
+		}
+		if !seen[id] {
+			seen[id] = true
+			sourceIDs = append(sourceIDs, id)
+		}
+	}
+	if len(sourceIDs) == 0 {
+		return nil, &ValidationError{Message: "source_ids must name at least one tag"}
+	}
+
+	retagged, err := s.repo.MergeTags(sourceIDs, targetID)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	target, err := s.GetTag(targetID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.TagMerge{Tag: target, ExpensesRetagged: retagged}, nil
+}
+
+// GetTag retrieves a single tag by ID
+func (s *TagService) GetTag(id string) (*models.Tag, error) {
+	tag, err := s.repo.GetTag(id)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	return tag, nil
+}
+
+// DeleteTag removes a tag from every expense and deletes it
+func (s *TagService) DeleteTag(id string) error {
+	return translateRepoError(s.repo.DeleteTag(id))
+}
+
+// normalizeTags validates tag names and returns them lower-cased, de-duplicated and
+// sorted. Blank names are dropped. The result is never nil.
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := []string{}
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		if strings.TrimSpace(tag) == "" {
+			continue
+		}
+		name, err := normalizeTagName(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[name] {
+			seen[name] = true
+			normalized = append(normalized, name)
+		}
+	}
+	if len(normalized) > MaxTagsPerExpense {
+		return nil, &ValidationError{Message: "too many tags"}
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// normalizeTagName trims and lower-cases a tag name and checks its length
+func normalizeTagName(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return "", &ValidationError{Message: "tag name is required"}
+	}
+	if utf8.RuneCountInString(name) > MaxTagLength {
+		return "", &ValidationError{Message: "tag is too long: " + name}
+	}
+	return name, nil
+}
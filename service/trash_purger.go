@@ -0,0 +1,82 @@
+package service
+
+import (
+	"fenmo-ai-assignment/repository"
+	"log"
+	"time"
+)
+
+// TrashPurger periodically hard-deletes expenses that have been in the trash
+// longer than the configured retention
+type TrashPurger struct {
+	repo      repository.ExpenseStore
+	retention time.Duration
+	interval  time.Duration
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// NewTrashPurger creates a new trash purger
+func NewTrashPurger(repo repository.ExpenseStore, retention, interval time.Duration) *TrashPurger {
+	return &TrashPurger{
+		repo:      repo,
+		retention: retention,
+		interval:  interval,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start runs the purge loop in a background goroutine.
+// It does nothing when retention or interval is not positive.
+func (p *TrashPurger) Start() {
+	if p.retention <= 0 || p.interval <= 0 {
+		close(p.done)
+		return
+	}
+
+	go func() {
+		defer close(p.done)
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			p.runOnce()
+
+			select {
+			case <-ticker.C:
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop signals the purge loop to exit and waits for it to finish.
+// It must only be called after Start.
+func (p *TrashPurger) Stop() {
+	select {
+	case <-p.stop:
+	default:
+		close(p.stop)
+	}
+	<-p.done
+}
+
+// PurgeOnce removes expenses deleted more than the retention period ago
+func (p *TrashPurger) PurgeOnce() (int64, error) {
+	return p.repo.PurgeDeletedBefore(time.Now().Add(-p.retention))
+}
+
+// runOnce purges and logs the outcome
+func (p *TrashPurger) runOnce() {
+	purged, err := p.PurgeOnce()
+	if err != nil {
+		log.Printf("Trash purge failed: %v", err)
+		return
+	}
+	if purged > 0 {
+		log.Printf("Trash purge removed %d expense(s)", purged)
+	}
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWeekday(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    time.Weekday
+		wantErr bool
+	}{
+		{"full name", "monday", time.Monday, false},
+		{"mixed case", "Sunday", time.Sunday, false},
+		{"abbreviation", "SAT", time.Saturday, false},
+		{"surrounding spaces", " wed ", time.Wednesday, false},
+		{"unknown", "funday", time.Sunday, true},
+		{"empty", "", time.Sunday, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseWeekday(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseWeekday(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseWeekday(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
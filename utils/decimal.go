@@ -2,31 +2,67 @@ package utils
 
 import (
 	"errors"
+	"fenmo-ai-assignment/money"
+	"fmt"
 	"strconv"
 	"strings"
+	"time"
 )
 
-// ValidateAmount validates that the amount string is a valid positive decimal number
-func ValidateAmount(amount string) error {
+// AmountScale is the number of fractional digits allowed in an amount
+const AmountScale = 2
+
+// maxAmountIntegerDigits bounds the integer part of an amount
+const maxAmountIntegerDigits = 15
+
+// ParseAmount validates an amount string and returns it as an exact decimal.
+// Only plain decimal notation is accepted: no exponents, NaN or Infinity.
+func ParseAmount(amount string) (money.Decimal, error) {
+	return ParseAmountWithScale(amount, AmountScale)
+}
+
+// ParseAmountWithScale is like ParseAmount but allows up to scale fractional digits
+func ParseAmountWithScale(amount string, scale int32) (money.Decimal, error) {
 	if amount == "" {
-		return errors.New("amount cannot be empty")
+		return money.Decimal{}, errors.New("amount cannot be empty")
 	}
 
 	// Remove any whitespace
 	amount = strings.TrimSpace(amount)
 
-	// Parse as float64 to validate it's a number
-	value, err := strconv.ParseFloat(amount, 64)
+	value, err := money.Parse(amount)
 	if err != nil {
-		return errors.New("amount must be a valid number")
+		return money.Decimal{}, errors.New("amount must be a valid number")
 	}
 
 	// Check if negative
-	if value < 0 {
-		return errors.New("amount must be positive")
+	if value.Sign() < 0 {
+		return money.Decimal{}, errors.New("amount must be positive")
 	}
 
-	return nil
+	// Trailing zeros are harmless ("100.0" is a valid JPY amount)
+	if value.Canonical().Scale() > scale {
+		if scale == 0 {
+			return money.Decimal{}, errors.New("amount must be a whole number")
+		}
+		return money.Decimal{}, fmt.Errorf("amount must have at most %d decimal places", scale)
+	}
+	if len(value.Round(0, money.RoundDown).String()) > maxAmountIntegerDigits {
+		return money.Decimal{}, errors.New("amount is too large")
+	}
+
+	return value, nil
+}
+
+// ValidateAmount validates that the amount string is a valid positive decimal number
+func ValidateAmount(amount string) error {
+	_, err := ParseAmount(amount)
+	return err
+}
+
+// CanonicalAmount formats a parsed amount with exactly AmountScale fractional digits
+func CanonicalAmount(value money.Decimal) string {
+	return value.StringFixed(AmountScale)
 }
 
 // ValidateDate validates that the date string is in ISO format (YYYY-MM-DD)
@@ -63,5 +99,10 @@ func ValidateDate(date string) error {
 		return errors.New("date must be in YYYY-MM-DD format")
 	}
 
+	// The layout is right; the month and day must also exist
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return fmt.Errorf("date %s does not exist", date)
+	}
+
 	return nil
 }
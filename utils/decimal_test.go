@@ -16,6 +16,12 @@ func TestValidateAmount(t *testing.T) {
 		{"invalid format", "abc", true},
 		{"whitespace", "  100.50  ", false},
 		{"zero", "0", false},
+		{"exponent", "1e5", true},
+		{"NaN", "NaN", true},
+		{"infinity", "Inf", true},
+		{"too many decimals", "1.005", true},
+		{"long fraction", "0.123456789012345678901234567890", true},
+		{"too large", "1234567890123456", true},
 	}
 
 	for _, tt := range tests {
@@ -28,6 +34,28 @@ func TestValidateAmount(t *testing.T) {
 	}
 }
 
+func TestCanonicalAmount(t *testing.T) {
+	tests := []struct {
+		amount string
+		want   string
+	}{
+		{"100", "100.00"},
+		{"100.5", "100.50"},
+		{"  0007.25 ", "7.25"},
+		{"0", "0.00"},
+	}
+
+	for _, tt := range tests {
+		value, err := ParseAmount(tt.amount)
+		if err != nil {
+			t.Fatalf("ParseAmount(%q) error = %v", tt.amount, err)
+		}
+		if got := CanonicalAmount(value); got != tt.want {
+			t.Errorf("CanonicalAmount(%q) = %q, want %q", tt.amount, got, tt.want)
+		}
+	}
+}
+
 func TestValidateDate(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -44,6 +72,11 @@ func TestValidateDate(t *testing.T) {
 		{"invalid format - short day", "2024-01-5", true},
 		{"invalid format - non-numeric", "abcd-01-15", true},
 		{"whitespace", "  2024-01-15  ", false},
+		{"leap day", "2024-02-29", false},
+		{"day out of range", "2024-02-30", true},
+		{"leap day in a common year", "2023-02-29", true},
+		{"month out of range", "2024-13-01", true},
+		{"day zero", "2024-01-00", true},
 	}
 
 	for _, tt := range tests {